@@ -34,6 +34,11 @@ func SetAction(app *cliV2.App) {
 
 		Parameters.ConfigFile = cfgFile
 
+		if c.IsSet(cliFlags.RollbackTo) {
+			Parameters.Rollback = true
+			Parameters.RollbackHeight = c.Uint64(cliFlags.RollbackTo)
+		}
+
 		return nil
 	}
 }
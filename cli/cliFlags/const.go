@@ -19,4 +19,5 @@ package cliFlags
 
 const (
 	Config  = "config"
+	RollbackTo = "rollback-to"
 )
@@ -19,8 +19,13 @@ package cli
 
 type parameters struct {
     ConfigFile  string
+
+    Rollback       bool
+    RollbackHeight uint64
 }
 
 var Parameters = parameters{
     "",
+    false,
+    0,
 }
@@ -18,7 +18,12 @@
 package blockchainRequest
 
 import (
+    "github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+    "github.com/SealSC/SealABC/crypto/hashes"
     "github.com/SealSC/SealABC/metadata/seal"
+    "bytes"
+    "encoding/hex"
+    "errors"
 )
 
 type EntityData struct {
@@ -26,6 +31,11 @@ type EntityData struct {
     RequestAction       string
     Data                []byte
     QueryString         string
+
+    //the request is only valid on the chain with this id
+    ChainID             string
+    //the request can't be executed in blocks higher than this, zero means never expire
+    ExpiryHeight        uint64
 }
 
 type Entity struct {
@@ -35,3 +45,26 @@ type Entity struct {
     PackedCount uint32
     Seal   seal.Entity
 }
+
+//VerifySeal verifies the seal of the request signed over the entity data, chain id and expiry height included
+func (e *Entity) VerifySeal(hashCalc hashes.IHashCalculator) (passed bool, err error) {
+    dataBytes, err := structSerializer.ToMFBytes(e.EntityData)
+    if err != nil {
+        return
+    }
+
+    return e.Seal.Verify(dataBytes, hashCalc)
+}
+
+//RequireSigner refuses the request if it is not sealed by one of the signers of the payload it carries.
+//chain id and expiry height are only in the seal of the request, so without this check anyone could wrap
+//a payload signed for another chain in a request of this chain
+func (e *Entity) RequireSigner(signers ...[]byte) error {
+    for _, signer := range signers {
+        if len(signer) != 0 && bytes.Equal(signer, e.Seal.SignerPublicKey) {
+            return nil
+        }
+    }
+
+    return errors.New("request signer " + hex.EncodeToString(e.Seal.SignerPublicKey) + " is not the signer of its data")
+}
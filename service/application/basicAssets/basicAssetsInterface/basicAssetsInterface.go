@@ -19,6 +19,7 @@ package basicAssetsInterface
 
 import (
     "github.com/SealSC/SealABC/dataStructure/enum"
+    "github.com/SealSC/SealABC/log"
     "github.com/SealSC/SealABC/metadata/applicationResult"
     "github.com/SealSC/SealABC/metadata/block"
     "github.com/SealSC/SealABC/metadata/blockchainRequest"
@@ -73,7 +74,61 @@ func (b *BasicAssetsApplication) Query(req []byte) (result interface{}, err erro
     }
 }
 
-func (b *BasicAssetsApplication) PreExecute(req blockchainRequest.Entity, _ block.Entity) (result []byte, err error) {
+//ApplicationInternalCall lets other applications query the assets and balances, and settle a signed transfer,
+//the data of the other calls is the json of the transfer transaction and the undo key is saved as its request hash.
+//a transfer is only settled by the application named in its settlement data, and only rolled back by it.
+func (b *BasicAssetsApplication) ApplicationInternalCall(src string, callData []byte) (ret interface{}, err error) {
+    req, err := chainStructure.ParseInternalCall(callData)
+    if err != nil {
+        return
+    }
+
+    if req.Type == chainStructure.InternalCallQuery {
+        return b.Query(req.Data)
+    }
+
+    tx := basicAssetsLedger.Transaction{}
+    err = json.Unmarshal(req.Data, &tx)
+    if err != nil {
+        return
+    }
+
+    if tx.TxType != req.Action {
+        err = errors.New("action not same as tx type")
+        return
+    }
+
+    switch req.Type {
+    case chainStructure.InternalCallVerify:
+        err = b.Ledger.VerifySettlement(tx, src)
+        return tx.HashString(), err
+
+    case chainStructure.InternalCallExecute:
+        execResult, height, exeErr := b.Ledger.ExecuteSettlement(tx, src, req.UndoKey)
+        if exeErr != nil {
+            return nil, exeErr
+        }
+
+        if b.SQLStorage != nil {
+            txWithBlk := basicAssetsLedger.TransactionWithBlockInfo {
+                Transaction: tx,
+            }
+
+            txWithBlk.BlockInfo.RequestHash = req.UndoKey
+            txWithBlk.BlockInfo.BlockHeight = height
+            b.storeTransfer(txWithBlk, execResult)
+        }
+        return tx.HashString(), nil
+
+    case chainStructure.InternalCallRollback:
+        err = b.Ledger.RollbackSettlement(tx, src, req.UndoKey)
+        return
+    }
+
+    return nil, chainStructure.UnsupportedInternalCall(b.Name(), req)
+}
+
+func (b *BasicAssetsApplication) PreExecute(req blockchainRequest.Entity, blk block.Entity) (result []byte, err error) {
     tx := basicAssetsLedger.Transaction{}
     err = json.Unmarshal(req.Data, &tx)
     if err != nil {
@@ -84,7 +139,13 @@ func (b *BasicAssetsApplication) PreExecute(req blockchainRequest.Entity, _ bloc
         err = errors.New("action not same as tx type")
         return
     }
-    err = b.Ledger.VerifyTransaction(tx)
+
+    err = req.RequireSigner(tx.Seal.SignerPublicKey)
+    if err != nil {
+        return
+    }
+
+    err = b.Ledger.VerifyTransaction(tx, blk)
 
     return
 }
@@ -101,7 +162,7 @@ func (b *BasicAssetsApplication) Execute(
         return
     }
 
-    execResult, err := b.Ledger.ExecuteTransaction(tx)
+    execResult, err := b.Ledger.ExecuteTransaction(tx, blk)
     if err != nil {
         return
     }
@@ -129,9 +190,14 @@ func (b *BasicAssetsApplication) Execute(
         case txTypes.IssueAssets.String():
             b.storeAssets(txWithBlk, execResult)
 
+        case txTypes.Swap.String():
+            fallthrough
         case txTypes.Transfer.String():
             b.storeTransfer(txWithBlk, execResult)
 
+        case txTypes.BatchTransfer.String():
+            b.storeBatchTransfer(txWithBlk, execResult)
+
         case txTypes.StartSelling.String():
             fallthrough
         case txTypes.StopSelling.String():
@@ -139,6 +205,20 @@ func (b *BasicAssetsApplication) Execute(
         case txTypes.BuyAssets.String():
             b.storeSelling(txWithBlk, execResult)
 
+        case txTypes.PlaceOrder.String():
+            fallthrough
+        case txTypes.CancelOrder.String():
+            b.storeOrder(txWithBlk, execResult)
+
+        case txTypes.Burn.String():
+            fallthrough
+        case txTypes.Freeze.String():
+            fallthrough
+        case txTypes.Unfreeze.String():
+            fallthrough
+        case txTypes.Clawback.String():
+            b.storeAssetsAction(txWithBlk, execResult)
+
         case txTypes.IncreaseSupply.String():
             //todo: b.saveAssetsUpdate(txWithBlk)
 
@@ -149,10 +229,61 @@ func (b *BasicAssetsApplication) Execute(
     return
 }
 
+func (b *BasicAssetsApplication) Rollback(
+        req blockchainRequest.Entity,
+        _ block.Entity,
+        _ uint32,
+    ) (err error) {
+
+    tx := basicAssetsLedger.Transaction{}
+    err = json.Unmarshal(req.Data, &tx)
+    if err != nil {
+        return
+    }
+
+    return b.Ledger.RollbackTransaction(tx)
+}
+
+//GenesisConfig is the basic assets part of the genesis file, it holds signed transactions executed in block 0
+type GenesisConfig struct {
+    Transactions []basicAssetsLedger.Transaction
+}
+
+func (b *BasicAssetsApplication) ApplyGenesis(config []byte, blk block.Entity) (err error) {
+    cfg := GenesisConfig{}
+    err = json.Unmarshal(config, &cfg)
+    if err != nil {
+        return
+    }
+
+    for idx, tx := range cfg.Transactions {
+        err = b.Ledger.VerifyTransaction(tx, blk)
+        if err != nil {
+            return
+        }
+
+        _, err = b.Ledger.ExecuteTransaction(tx, blk)
+        if err != nil {
+            return
+        }
+
+        err = b.Ledger.SaveTransactionWithBlockInfo(tx, tx.Seal.Hash, blk.Header.Height, uint32(idx))
+        if err != nil {
+            return
+        }
+    }
+
+    return
+}
+
 func (b *BasicAssetsApplication) RequestsForBlock(_ block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
     return b.Ledger.GetTransactionsFromPool()
 }
 
+func (b *BasicAssetsApplication) SetChainInterface(ci chainStructure.IChainInterface) {
+    b.Ledger.SetChain(ci)
+}
+
 func (b *BasicAssetsApplication) Information() (info service.BasicInformation) {
     info.Name = b.Name()
     info.Description = "this is a basic assets application based on a UTXO mode ledger"
@@ -186,6 +317,11 @@ func Load()  {
 func NewApplicationInterface(kvDriver kvDatabase.IDriver, sqlDriver simpleSQLDatabase.IDriver) (app chainStructure.IBlockchainExternalApplication) {
     bs := BasicAssetsApplication{}
     bs.Ledger = basicAssetsLedger.NewLedger(kvDriver)
+    err := bs.Ledger.MigrateAmounts()
+    if err != nil {
+        log.Log.Error("migrate basic assets amounts failed: ", err.Error())
+    }
+
     if sqlDriver != nil {
         bs.SQLStorage = basicAssetsSQLStorage.NewStorage(sqlDriver)
     }
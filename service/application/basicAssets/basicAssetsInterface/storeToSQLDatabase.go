@@ -57,6 +57,26 @@ func (b *BasicAssetsApplication) storeTransfer(tx basicAssetsLedger.TransactionW
 	}
 }
 
+func (b *BasicAssetsApplication) storeBatchTransfer(tx basicAssetsLedger.TransactionWithBlockInfo, execResult interface{}) {
+	batchRet, ok := execResult.(basicAssetsLedger.BatchTransferResult)
+	if !ok {
+		log.Log.Warn("transaction has no batch transfer result")
+		return
+	}
+
+	err := b.SQLStorage.StoreBatchTransfer(tx, batchRet.Legs)
+	if err != nil {
+		log.Log.Warn("save batch transfer failed: ", err.Error())
+	}
+
+	for _, leg := range batchRet.Legs {
+		err = b.SQLStorage.StoreBalance(tx.BlockInfo.BlockHeight, tx.CreateTime, leg.BalanceList)
+		if err != nil {
+			log.Log.Warn("save batch transfer balance failed: ", err.Error())
+		}
+	}
+}
+
 func (b *BasicAssetsApplication) storeSelling(tx basicAssetsLedger.TransactionWithBlockInfo, execResult interface{}) {
 	sellRet := execResult.(basicAssetsLedger.SellingOperationResult)
 
@@ -73,3 +93,38 @@ func (b *BasicAssetsApplication) storeSelling(tx basicAssetsLedger.TransactionWi
 	return
 }
 
+
+func (b *BasicAssetsApplication) storeOrder(tx basicAssetsLedger.TransactionWithBlockInfo, execResult interface{}) {
+	orderRet, ok := execResult.(basicAssetsLedger.OrderOperationResult)
+	if !ok {
+		log.Log.Warn("transaction has no order result")
+		return
+	}
+
+	err := b.SQLStorage.StoreBalance(tx.BlockInfo.BlockHeight, tx.CreateTime, orderRet.BalanceList)
+	if err != nil {
+		log.Log.Warn("save order balance failed: ", err.Error())
+	}
+
+	err = b.SQLStorage.StoreOrder(tx, orderRet)
+	if err != nil {
+		log.Log.Warn("save order data failed: ", err.Error())
+	}
+
+	return
+}
+
+func (b *BasicAssetsApplication) storeAssetsAction(tx basicAssetsLedger.TransactionWithBlockInfo, execResult interface{}) {
+	actionRet, ok := execResult.(basicAssetsLedger.AssetsActionResult)
+	if !ok {
+		log.Log.Warn("transaction has no assets action result")
+		return
+	}
+
+	err := b.SQLStorage.StoreAssetsAction(tx, actionRet)
+	if err != nil {
+		log.Log.Warn("save assets action failed: ", err.Error())
+	}
+
+	return
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "math/big"
+)
+
+//amounts of basic assets are decimal strings of non-negative big integers like the values of smart assets,
+//an amount must not be larger than MaxAmount
+var MaxAmount = big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+const MaxDecimals = 36
+
+const amountBytesLen = 32
+
+func ParseAmount(s string) (amount *big.Int, err error) {
+    amount, valid := big.NewInt(0).SetString(s, 10)
+    if !valid {
+        return nil, errors.New("invalid amount: " + s)
+    }
+
+    if amount.Sign() < 0 {
+        return nil, errors.New("negative amount: " + s)
+    }
+
+    if amount.Cmp(MaxAmount) > 0 {
+        return nil, errors.New("amount overflow: " + s)
+    }
+
+    return
+}
+
+func addAmount(a *big.Int, b *big.Int) (sum *big.Int, err error) {
+    sum = big.NewInt(0).Add(a, b)
+    if sum.Cmp(MaxAmount) > 0 {
+        return nil, errors.New("amount overflow")
+    }
+
+    return
+}
+
+func subAmount(a *big.Int, b *big.Int) (diff *big.Int, err error) {
+    if a.Cmp(b) < 0 {
+        return nil, errors.New("amount underflow")
+    }
+
+    return big.NewInt(0).Sub(a, b), nil
+}
+
+func mulAmount(a *big.Int, b *big.Int) (product *big.Int, err error) {
+    product = big.NewInt(0).Mul(a, b)
+    if product.Cmp(MaxAmount) > 0 {
+        return nil, errors.New("amount overflow")
+    }
+
+    return
+}
+
+//sumAmounts parses and adds all amounts with overflow check
+func sumAmounts(list ...string) (sum *big.Int, err error) {
+    sum = big.NewInt(0)
+    for _, s := range list {
+        amount, parseErr := ParseAmount(s)
+        if parseErr != nil {
+            return nil, parseErr
+        }
+
+        sum, err = addAmount(sum, amount)
+        if err != nil {
+            return
+        }
+    }
+
+    return
+}
+
+//amountBytes is the fixed length big endian bytes of an amount, they keep the order of amounts in storage keys
+func amountBytes(amount *big.Int) []byte {
+    b := make([]byte, amountBytesLen, amountBytesLen)
+    return amount.FillBytes(b)
+}
+
+//legacyAmount accepts the amounts stored by the former uint64 ledger, as json numbers or quoted numbers
+type legacyAmount string
+
+func (a *legacyAmount) UnmarshalJSON(data []byte) (err error) {
+    if len(data) > 0 && data[0] == '"' {
+        var s string
+        err = json.Unmarshal(data, &s)
+        *a = legacyAmount(s)
+        return
+    }
+
+    var n json.Number
+    err = json.Unmarshal(data, &n)
+    *a = legacyAmount(n.String())
+    return
+}
+
+func (a legacyAmount) canonical() (string, error) {
+    amount, err := ParseAmount(string(a))
+    if err != nil {
+        return "", err
+    }
+
+    return amount.String(), nil
+}
+
+//amountStorageVersion is the storage version since the amounts are big integers
+const amountStorageVersion = 1
+
+type legacyUnspent struct {
+    Unspent
+    Value legacyAmount
+}
+
+type legacyAssets struct {
+    Assets
+    Supply legacyAmount
+}
+
+type legacyCopyright struct {
+    Copyright
+    Supply legacyAmount
+}
+
+//MigrateAmounts rewrites the unspent, assets and balances stored by the former uint64 ledger in the big integer format,
+//the stored transactions and selling data keep their quoted uint64 amounts which are valid decimal strings
+func (l *Ledger) MigrateAmounts() (err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    versionKey := []byte(StoragePrefixes.StorageVersion.String())
+    kv, err := l.Storage.Get(versionKey)
+    if err != nil {
+        return
+    }
+
+    if kv.Exists && len(kv.Data) == 8 && binary.BigEndian.Uint64(kv.Data) >= amountStorageVersion {
+        return
+    }
+
+    var kvList []kvDatabase.KVItem
+    for _, item := range l.Storage.Traversal([]byte(StoragePrefixes.Unspent.String())) {
+        legacy := legacyUnspent{}
+        err = json.Unmarshal(item.Data, &legacy)
+        if err != nil {
+            return
+        }
+
+        u := legacy.Unspent
+        u.Value, err = legacy.Value.canonical()
+        if err != nil {
+            return
+        }
+
+        data, _ := json.Marshal(u)
+        kvList = append(kvList, kvDatabase.KVItem{Key: item.Key, Data: data})
+    }
+
+    for _, item := range l.Storage.Traversal([]byte(StoragePrefixes.Assets.String())) {
+        legacy := legacyAssets{}
+        err = json.Unmarshal(item.Data, &legacy)
+        if err != nil {
+            return
+        }
+
+        a := legacy.Assets
+        a.Supply, err = legacy.Supply.canonical()
+        if err != nil {
+            return
+        }
+
+        data, _ := json.Marshal(a)
+        kvList = append(kvList, kvDatabase.KVItem{Key: item.Key, Data: data})
+    }
+
+    for _, item := range l.Storage.Traversal([]byte(StoragePrefixes.Copyright.String())) {
+        legacy := legacyCopyright{}
+        err = json.Unmarshal(item.Data, &legacy)
+        if err != nil {
+            return
+        }
+
+        cr := legacy.Copyright
+        cr.Supply, err = legacy.Supply.canonical()
+        if err != nil {
+            return
+        }
+
+        data, _ := json.Marshal(cr)
+        kvList = append(kvList, kvDatabase.KVItem{Key: item.Key, Data: data})
+    }
+
+    //the former balances are 8 bytes big endian uint64, they are kept as the big endian bytes of big integers
+    for _, item := range l.Storage.Traversal([]byte(StoragePrefixes.Balance.String())) {
+        balance := big.NewInt(0).SetBytes(item.Data)
+        kvList = append(kvList, kvDatabase.KVItem{Key: item.Key, Data: balance.Bytes()})
+    }
+
+    versionBytes := make([]byte, 8, 8)
+    binary.BigEndian.PutUint64(versionBytes, amountStorageVersion)
+    kvList = append(kvList, kvDatabase.KVItem{Key: versionKey, Data: versionBytes})
+
+    return l.Storage.BatchPut(kvList)
+}
@@ -0,0 +1,285 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/storage/db/dbDrivers/levelDB"
+    "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "math/big"
+    "testing"
+)
+
+func bigFromString(t *testing.T, s string) *big.Int {
+    n, valid := big.NewInt(0).SetString(s, 10)
+    if !valid {
+        t.Fatalf("invalid test number %s", s)
+    }
+
+    return n
+}
+
+func TestParseAmount(t *testing.T) {
+    maxPlusOne := big.NewInt(0).Add(MaxAmount, big.NewInt(1))
+
+    cases := []struct {
+        name    string
+        input   string
+        want    string
+        invalid bool
+    }{
+        {name: "zero", input: "0", want: "0"},
+        {name: "small", input: "123", want: "123"},
+        {name: "above uint64", input: "18446744073709551616", want: "18446744073709551616"},
+        {name: "leading zeros", input: "00042", want: "42"},
+        {name: "max", input: MaxAmount.String(), want: MaxAmount.String()},
+        {name: "overflow", input: maxPlusOne.String(), invalid: true},
+        {name: "negative", input: "-1", invalid: true},
+        {name: "decimal point", input: "1.5", invalid: true},
+        {name: "hex", input: "0x10", invalid: true},
+        {name: "empty", input: "", invalid: true},
+        {name: "not a number", input: "abc", invalid: true},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            amount, err := ParseAmount(c.input)
+            if c.invalid {
+                if err == nil {
+                    t.Fatalf("ParseAmount(%q) = %s, want error", c.input, amount.String())
+                }
+                return
+            }
+
+            if err != nil {
+                t.Fatalf("ParseAmount(%q) failed: %s", c.input, err.Error())
+            }
+
+            if amount.String() != c.want {
+                t.Fatalf("ParseAmount(%q) = %s, want %s", c.input, amount.String(), c.want)
+            }
+        })
+    }
+}
+
+func TestAddAmount(t *testing.T) {
+    cases := []struct {
+        name     string
+        a        string
+        b        string
+        want     string
+        overflow bool
+    }{
+        {name: "zero", a: "0", b: "0", want: "0"},
+        {name: "small", a: "1", b: "2", want: "3"},
+        {name: "carry over uint64", a: "18446744073709551615", b: "1", want: "18446744073709551616"},
+        {name: "reach max", a: big.NewInt(0).Sub(MaxAmount, big.NewInt(1)).String(), b: "1", want: MaxAmount.String()},
+        {name: "max plus zero", a: MaxAmount.String(), b: "0", want: MaxAmount.String()},
+        {name: "max plus one", a: MaxAmount.String(), b: "1", overflow: true},
+        {name: "max plus max", a: MaxAmount.String(), b: MaxAmount.String(), overflow: true},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            sum, err := addAmount(bigFromString(t, c.a), bigFromString(t, c.b))
+            if c.overflow {
+                if err == nil {
+                    t.Fatalf("addAmount(%s, %s) = %s, want overflow", c.a, c.b, sum.String())
+                }
+                return
+            }
+
+            if err != nil {
+                t.Fatalf("addAmount(%s, %s) failed: %s", c.a, c.b, err.Error())
+            }
+
+            if sum.String() != c.want {
+                t.Fatalf("addAmount(%s, %s) = %s, want %s", c.a, c.b, sum.String(), c.want)
+            }
+        })
+    }
+}
+
+func TestMulAmount(t *testing.T) {
+    pow128 := big.NewInt(0).Lsh(big.NewInt(1), 128)
+    pow128MinusOne := big.NewInt(0).Sub(pow128, big.NewInt(1))
+
+    cases := []struct {
+        name     string
+        a        string
+        b        string
+        want     string
+        overflow bool
+    }{
+        {name: "by zero", a: MaxAmount.String(), b: "0", want: "0"},
+        {name: "by one", a: MaxAmount.String(), b: "1", want: MaxAmount.String()},
+        {name: "price by quantity", a: "250", b: "4000000000", want: "1000000000000"},
+        {name: "below max", a: pow128.String(), b: pow128MinusOne.String(),
+            want: big.NewInt(0).Mul(pow128, pow128MinusOne).String()},
+        {name: "reach 2^256", a: pow128.String(), b: pow128.String(), overflow: true},
+        {name: "max by two", a: MaxAmount.String(), b: "2", overflow: true},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            product, err := mulAmount(bigFromString(t, c.a), bigFromString(t, c.b))
+            if c.overflow {
+                if err == nil {
+                    t.Fatalf("mulAmount(%s, %s) = %s, want overflow", c.a, c.b, product.String())
+                }
+                return
+            }
+
+            if err != nil {
+                t.Fatalf("mulAmount(%s, %s) failed: %s", c.a, c.b, err.Error())
+            }
+
+            if product.String() != c.want {
+                t.Fatalf("mulAmount(%s, %s) = %s, want %s", c.a, c.b, product.String(), c.want)
+            }
+        })
+    }
+}
+
+func newTestLedger(t *testing.T) *Ledger {
+    Load()
+
+    driver, err := levelDB.NewDriver(levelDB.Config{DBFilePath: t.TempDir()})
+    if err != nil {
+        t.Fatalf("open test database failed: %s", err.Error())
+    }
+    t.Cleanup(driver.Close)
+
+    return NewLedger(driver)
+}
+
+func uint64Bytes(n uint64) []byte {
+    data := make([]byte, 8)
+    binary.BigEndian.PutUint64(data, n)
+    return data
+}
+
+func TestMigrateAmounts(t *testing.T) {
+    l := newTestLedger(t)
+
+    unspentPrefix := StoragePrefixes.Unspent.String()
+    assetsPrefix := StoragePrefixes.Assets.String()
+    balancePrefix := StoragePrefixes.Balance.String()
+
+    //amounts written by the former uint64 ledger, as json numbers and as quoted numbers
+    legacyUnspent := map[string] string{
+        unspentPrefix + "number": `{"Owner":"b3duZXI=","OutputIndex":"1","Value":42}`,
+        unspentPrefix + "quoted": `{"Owner":"b3duZXI=","OutputIndex":"2","Value":"1234567890123"}`,
+        unspentPrefix + "max":    `{"Owner":"b3duZXI=","OutputIndex":"3","Value":18446744073709551615}`,
+    }
+    wantUnspent := map[string] string{
+        unspentPrefix + "number": "42",
+        unspentPrefix + "quoted": "1234567890123",
+        unspentPrefix + "max":    "18446744073709551615",
+    }
+
+    legacyBalances := map[string] uint64{
+        balancePrefix + "small": 1000,
+        balancePrefix + "big":   9876543210,
+        balancePrefix + "max":   18446744073709551615,
+    }
+
+    var kvList []kvDatabase.KVItem
+    for k, v := range legacyUnspent {
+        kvList = append(kvList, kvDatabase.KVItem{Key: []byte(k), Data: []byte(v)})
+    }
+    for k, v := range legacyBalances {
+        kvList = append(kvList, kvDatabase.KVItem{Key: []byte(k), Data: uint64Bytes(v)})
+    }
+    kvList = append(kvList, kvDatabase.KVItem{
+        Key:  []byte(assetsPrefix + "assets"),
+        Data: []byte(`{"Name":"coin","Symbol":"C","Supply":123456789012,"Type":"0","Decimals":"2"}`),
+    })
+
+    err := l.Storage.BatchPut(kvList)
+    if err != nil {
+        t.Fatalf("write legacy data failed: %s", err.Error())
+    }
+
+    //the second migration must find the storage version and change nothing
+    for round := 0; round < 2; round++ {
+        err = l.MigrateAmounts()
+        if err != nil {
+            t.Fatalf("migration round %d failed: %s", round, err.Error())
+        }
+
+        for k, want := range wantUnspent {
+            kv, getErr := l.Storage.Get([]byte(k))
+            if getErr != nil || !kv.Exists {
+                t.Fatalf("unspent %s lost after migration", k)
+            }
+
+            u := Unspent{}
+            err = json.Unmarshal(kv.Data, &u)
+            if err != nil {
+                t.Fatalf("unspent %s is not in the new format: %s", k, err.Error())
+            }
+
+            if u.Value != want {
+                t.Fatalf("unspent %s has value %s, want %s", k, u.Value, want)
+            }
+
+            if string(u.Owner) != "owner" {
+                t.Fatalf("unspent %s lost its owner", k)
+            }
+
+            if _, parseErr := ParseAmount(u.Value); parseErr != nil {
+                t.Fatalf("migrated value of %s can't be parsed: %s", k, parseErr.Error())
+            }
+        }
+
+        for k, want := range legacyBalances {
+            kv, getErr := l.Storage.Get([]byte(k))
+            if getErr != nil || !kv.Exists {
+                t.Fatalf("balance %s lost after migration", k)
+            }
+
+            wantBytes := big.NewInt(0).SetUint64(want).Bytes()
+            if !bytes.Equal(kv.Data, wantBytes) {
+                t.Fatalf("balance %s is stored as %x, want %x", k, kv.Data, wantBytes)
+            }
+        }
+
+        kv, getErr := l.Storage.Get([]byte(assetsPrefix + "assets"))
+        if getErr != nil || !kv.Exists {
+            t.Fatal("assets lost after migration")
+        }
+
+        assets := Assets{}
+        err = json.Unmarshal(kv.Data, &assets)
+        if err != nil {
+            t.Fatalf("assets are not in the new format: %s", err.Error())
+        }
+
+        if assets.Supply != "123456789012" || assets.Name != "coin" {
+            t.Fatalf("assets migrated to %s %s, want coin 123456789012", assets.Name, assets.Supply)
+        }
+    }
+
+    kv, err := l.Storage.Get([]byte(StoragePrefixes.StorageVersion.String()))
+    if err != nil || !kv.Exists || binary.BigEndian.Uint64(kv.Data) != amountStorageVersion {
+        t.Fatal("storage version not saved by the migration")
+    }
+}
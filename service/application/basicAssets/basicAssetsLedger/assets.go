@@ -34,12 +34,17 @@ var AssetsTypes struct{
 }
 
 type AssetsData struct {
-    Name        string
-    Symbol      string
-    Supply      uint64 `json:",string"`
-    Type        uint32 `json:",string"`
-    Increasable bool
-    ExtraInfo   []byte
+    Name         string
+    Symbol       string
+    Supply       string
+    Type         uint32 `json:",string"`
+    Increasable  bool
+    ExtraInfo    []byte
+    Decimals     uint32 `json:",string"`
+
+    //the issuer can freeze addresses or claw back assets only when these are set at issuance
+    Freezable    bool
+    Clawbackable bool
 }
 
 type Assets struct {
@@ -65,12 +70,22 @@ func (a Assets) verify(tools crypto.Tools) (err error) {
         return
     }
 
+    _, err = ParseAmount(a.Supply)
+    if err != nil {
+        return
+    }
+
+    if a.Decimals > MaxDecimals {
+        err = errors.New("too many decimals")
+        return
+    }
+
     fullBytes, err := structSerializer.ToMFBytes(a.AssetsData)
     if err != nil {
         return
     }
 
-    a.Supply = 0
+    a.Supply = "0"
     withoutSupplyBytes, _ := structSerializer.ToMFBytes(a.AssetsData)
 
     _, err = a.IssuedSeal.Verify(fullBytes, tools.HashCalculator)
@@ -23,6 +23,7 @@ import (
     "github.com/SealSC/SealABC/crypto/signers/ed25519"
     "github.com/SealSC/SealABC/dataStructure/enum"
     "github.com/SealSC/SealABC/metadata/blockchainRequest"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
     "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
     "sync"
 )
@@ -36,10 +37,25 @@ var StoragePrefixes struct{
 
     Balance     enum.Element
     SellingList enum.Element
+
+    TransactionUndo enum.Element
+
+    Orders        enum.Element
+    OrderBook     enum.Element
+    OrderSequence enum.Element
+
+    StorageVersion enum.Element
+
+    Frozen enum.Element
+
+    AccountHistory enum.Element
+
+    Settlement enum.Element
 }
 
-type txValidator func(tx Transaction) (ret interface{}, err error)
-type txActuator func(tx Transaction) (ret interface{}, err error)
+//validators and actuators get the lock context of the block the transaction is verified for or executed in
+type txValidator func(tx Transaction, ctx lockContext) (ret interface{}, err error)
+type txActuator func(tx Transaction, ctx lockContext) (ret interface{}, err error)
 type ledgerQuery func(param []string) (ret interface{}, err error)
 
 type Ledger struct {
@@ -49,10 +65,23 @@ type Ledger struct {
     txPool         map[string] blockchainRequest.Entity
     memUTXORecord  map[string] bool
     execUTXORecord map[string] bool
+
+    //smart assets legs of the pending and verifying swaps, keyed by sender and nonce
+    memSwapLegRecord  map[string] bool
+    execSwapLegRecord map[string] bool
+
+    //freezes and unfreezes verified in the block, keyed by frozen key
+    execFreezeRecord map[string] pendingFreeze
+
     txValidators   map[string] txValidator
     txActuators     map[string] txActuator
     ledgerQueries   map[string] ledgerQuery
 
+    journal *kvDatabase.Journal
+
+    chain          chainStructure.IChainInterface
+    historyContext accountHistoryContext
+
     CryptoTools crypto.Tools
     Storage     kvDatabase.IDriver
 }
@@ -62,12 +91,17 @@ func Load()  {
     enum.SimpleBuild(&StoragePrefixes)
     enum.SimpleBuild(&QueryTypes)
     enum.SimpleBuild(&AssetsTypes)
+    enum.SimpleBuild(&OrderSides)
+    enum.SimpleBuild(&OrderStatus)
+    enum.SimpleBuild(&CoinSelectionStrategies)
+    enum.SimpleBuild(&StatementFormats)
 }
 
 func NewLedger(storage kvDatabase.IDriver) (ledger *Ledger) {
     ledger = &Ledger{}
 
-    ledger.Storage = storage
+    ledger.journal = kvDatabase.NewJournal(storage)
+    ledger.Storage = ledger.journal
 
     ledger.CryptoTools = crypto.Tools{
         HashCalculator:  sha3.Sha256,
@@ -77,6 +111,9 @@ func NewLedger(storage kvDatabase.IDriver) (ledger *Ledger) {
     ledger.txPool = map[string] blockchainRequest.Entity{}
     ledger.memUTXORecord = map[string] bool{}
     ledger.execUTXORecord = map[string] bool{}
+    ledger.memSwapLegRecord = map[string] bool{}
+    ledger.execSwapLegRecord = map[string] bool{}
+    ledger.execFreezeRecord = map[string] pendingFreeze{}
 
     ledger.txValidators = map[string] txValidator {
         TransactionTypes.IssueAssets.String(): ledger.verifyIssueAssets,
@@ -86,6 +123,18 @@ func NewLedger(storage kvDatabase.IDriver) (ledger *Ledger) {
         TransactionTypes.StartSelling.String(): ledger.verifyStartSelling,
         TransactionTypes.StopSelling.String(): ledger.verifyStopSelling,
         TransactionTypes.BuyAssets.String(): ledger.verifyBuyAssets,
+
+        TransactionTypes.PlaceOrder.String(): ledger.verifyPlaceOrder,
+        TransactionTypes.CancelOrder.String(): ledger.verifyCancelOrder,
+
+        TransactionTypes.Burn.String(): ledger.verifyBurn,
+        TransactionTypes.Freeze.String(): ledger.verifyFreeze,
+        TransactionTypes.Unfreeze.String(): ledger.verifyFreeze,
+        TransactionTypes.Clawback.String(): ledger.verifyClawback,
+
+        TransactionTypes.Swap.String(): ledger.verifySwap,
+
+        TransactionTypes.BatchTransfer.String(): ledger.verifyBatchTransfer,
     }
 
     ledger.txActuators = map[string] txActuator {
@@ -96,6 +145,18 @@ func NewLedger(storage kvDatabase.IDriver) (ledger *Ledger) {
         TransactionTypes.StartSelling.String(): ledger.confirmStartSelling,
         TransactionTypes.StopSelling.String(): ledger.confirmStopSelling,
         TransactionTypes.BuyAssets.String(): ledger.confirmBuyAssets,
+
+        TransactionTypes.PlaceOrder.String(): ledger.confirmPlaceOrder,
+        TransactionTypes.CancelOrder.String(): ledger.confirmCancelOrder,
+
+        TransactionTypes.Burn.String(): ledger.confirmBurn,
+        TransactionTypes.Freeze.String(): ledger.confirmFreeze,
+        TransactionTypes.Unfreeze.String(): ledger.confirmFreeze,
+        TransactionTypes.Clawback.String(): ledger.confirmClawback,
+
+        TransactionTypes.Swap.String(): ledger.confirmSwap,
+
+        TransactionTypes.BatchTransfer.String(): ledger.confirmBatchTransfer,
     }
 
     ledger.ledgerQueries = map[string] ledgerQuery {
@@ -105,6 +166,11 @@ func NewLedger(storage kvDatabase.IDriver) (ledger *Ledger) {
         QueryTypes.Transaction.String(): ledger.queryTransaction,
         QueryTypes.SellingList.String(): ledger.querySellingList,
         QueryTypes.Copyright.String(): ledger.queryCopyright,
+        QueryTypes.Order.String(): ledger.queryOrder,
+        QueryTypes.OrderBook.String(): ledger.queryOrderBook,
+        QueryTypes.Frozen.String(): ledger.queryFrozen,
+        QueryTypes.BuildTransfer.String(): ledger.queryBuildTransfer,
+        QueryTypes.AccountStatement.String(): ledger.queryAccountStatement,
     }
 
     return
@@ -0,0 +1,301 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/common"
+    "github.com/SealSC/SealABC/dataStructure/enum"
+    "github.com/SealSC/SealABC/metadata/block"
+    "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "bytes"
+    "encoding/binary"
+    "encoding/csv"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "sort"
+    "time"
+)
+
+//every balance change of an address is recorded in the account history of the address and assets with the block
+//of the transaction, the records are written in the journal of the transaction and rolled back with it.
+//the statement of an account is built from the records and the balance in the ledger, so it needs no sql database.
+
+var StatementFormats struct {
+    JSON enum.Element
+    CSV  enum.Element
+}
+
+//accountHistoryContext is the transaction being executed, sequence orders the records of a block
+type accountHistoryContext struct {
+    height    uint64
+    timestamp uint64
+    txHash    []byte
+    txType    string
+    sequence  uint32
+}
+
+//AccountHistoryEntry is a balance change of an account, Change is negative if the balance is reduced
+//and Balance is the balance after the change
+type AccountHistoryEntry struct {
+    Height      uint64 `json:",string"`
+    Time        int64  `json:",string"`
+    Transaction []byte
+    TxType      string
+    Change      string
+    Balance     string
+}
+
+type AccountStatement struct {
+    Address        []byte
+    Assets         []byte
+    OpeningBalance string
+    Entries        []AccountHistoryEntry
+    ClosingBalance string
+}
+
+func (l *Ledger) setHistoryContext(tx Transaction, blk block.Entity) {
+    if l.historyContext.height != blk.Header.Height {
+        l.historyContext.sequence = 0
+    }
+
+    l.historyContext.height = blk.Header.Height
+    l.historyContext.timestamp = blk.Header.Timestamp
+    l.historyContext.txHash = tx.Seal.Hash
+    l.historyContext.txType = tx.TxType
+}
+
+func (l *Ledger) buildAccountHistoryPrefix(address []byte, assetsHash []byte) (prefix []byte) {
+    //prefix + address + assets hash
+    prefix = []byte(StoragePrefixes.AccountHistory.String())
+    prefix = append(prefix, address...)
+    prefix = append(prefix, assetsHash...)
+    return
+}
+
+func (l *Ledger) buildAccountHistoryKey(address []byte, assetsHash []byte, height uint64, sequence uint32) (key []byte) {
+    //prefix + address + assets hash + height + sequence in the block
+    key = l.buildAccountHistoryPrefix(address, assetsHash)
+
+    heightBytes := make([]byte, 8, 8)
+    binary.BigEndian.PutUint64(heightBytes, height)
+    sequenceBytes := make([]byte, 4, 4)
+    binary.BigEndian.PutUint32(sequenceBytes, sequence)
+
+    key = append(key, heightBytes...)
+    key = append(key, sequenceBytes...)
+    return
+}
+
+func (l *Ledger) recordAccountHistory(address []byte, assets Assets, change *big.Int, isIncrease bool, balance *big.Int) (err error) {
+    ctx := &l.historyContext
+
+    changeValue := big.NewInt(0).Set(change)
+    if !isIncrease {
+        changeValue.Neg(changeValue)
+    }
+
+    entry := AccountHistoryEntry{
+        Height:      ctx.height,
+        Time:        int64(ctx.timestamp),
+        Transaction: ctx.txHash,
+        TxType:      ctx.txType,
+        Change:      changeValue.String(),
+        Balance:     balance.String(),
+    }
+
+    data, err := json.Marshal(entry)
+    if err != nil {
+        return
+    }
+
+    key := l.buildAccountHistoryKey(address, assets.getUniqueHash(), ctx.height, ctx.sequence)
+    ctx.sequence += 1
+
+    return l.Storage.Put(kvDatabase.KVItem{
+        Key:  key,
+        Data: data,
+    })
+}
+
+func (p AccountStatementParameter) isTimeRange() bool {
+    return p.FromTime != 0 || p.ToTime != 0
+}
+
+//compareToRange tells if the entry is before (-1), inside (0) or after (1) the range of the statement
+func (p AccountStatementParameter) compareToRange(entry AccountHistoryEntry) int {
+    from, to, at := p.FromHeight, p.ToHeight, entry.Height
+    if p.isTimeRange() {
+        from, to, at = uint64(p.FromTime), uint64(p.ToTime), uint64(entry.Time)
+    }
+
+    if at < from {
+        return -1
+    }
+
+    if to != 0 && at > to {
+        return 1
+    }
+
+    return 0
+}
+
+//accountStatement builds the statement back from the balance in the ledger, so the opening balance is right for the
+//accounts changed before the history was recorded
+func (l *Ledger) accountStatement(param AccountStatementParameter) (statement AccountStatement, err error) {
+    if param.isTimeRange() {
+        if param.ToTime != 0 && param.ToTime < param.FromTime {
+            return statement, errors.New("invalid time range")
+        }
+    } else if param.ToHeight != 0 && param.ToHeight < param.FromHeight {
+        return statement, errors.New("invalid height range")
+    }
+
+    assets, err := l.localAssetsFromHash(param.Assets)
+    if err != nil {
+        return
+    }
+
+    balanceKey, _ := l.buildBalanceKey(param.Address, assets)
+    closing := big.NewInt(0)
+    kv, err := l.Storage.Get(balanceKey)
+    if err != nil {
+        return
+    }
+
+    if kv.Exists {
+        closing.SetBytes(kv.Data)
+    }
+
+    kvList := l.Storage.Traversal(l.buildAccountHistoryPrefix(param.Address, param.Assets))
+    sort.Slice(kvList, func(i, j int) bool {
+        return bytes.Compare(kvList[i].Key, kvList[j].Key) < 0
+    })
+
+    var entries []AccountHistoryEntry
+    for _, item := range kvList {
+        entry := AccountHistoryEntry{}
+        err = json.Unmarshal(item.Data, &entry)
+        if err != nil {
+            return
+        }
+
+        entries = append(entries, entry)
+    }
+
+    //undo the changes after the range from the balance in the ledger to get the closing balance of the range
+    inRange := big.NewInt(0)
+    for i := len(entries) - 1; i >= 0; i-- {
+        entry := entries[i]
+        position := param.compareToRange(entry)
+        if position < 0 {
+            continue
+        }
+
+        change, ok := big.NewInt(0).SetString(entry.Change, 10)
+        if !ok {
+            return statement, errors.New("invalid change in account history: " + entry.Change)
+        }
+
+        if position > 0 {
+            closing.Sub(closing, change)
+            continue
+        }
+
+        inRange.Add(inRange, change)
+        statement.Entries = append(statement.Entries, entry)
+    }
+
+    for i, j := 0, len(statement.Entries) - 1; i < j; i, j = i + 1, j - 1 {
+        statement.Entries[i], statement.Entries[j] = statement.Entries[j], statement.Entries[i]
+    }
+
+    statement.Address = param.Address
+    statement.Assets = param.Assets
+    statement.ClosingBalance = closing.String()
+    statement.OpeningBalance = big.NewInt(0).Sub(closing, inRange).String()
+    return
+}
+
+//csvBytes exports the statement with the opening balance in the first row and the closing balance in the last row
+func (s AccountStatement) csvBytes() (data []byte, err error) {
+    buf := bytes.Buffer{}
+    writer := csv.NewWriter(&buf)
+
+    address := hex.EncodeToString(s.Address)
+    assets := hex.EncodeToString(s.Assets)
+    rows := [][]string{
+        {"address", "assets", "height", "time", "transaction", "type", "change", "balance"},
+        {address, assets, "", "", "", "opening balance", "", s.OpeningBalance},
+    }
+
+    for _, entry := range s.Entries {
+        rows = append(rows, []string{
+            address,
+            assets,
+            fmt.Sprintf("%d", entry.Height),
+            time.Unix(entry.Time, 0).Format(common.BASIC_TIME_FORMAT),
+            hex.EncodeToString(entry.Transaction),
+            entry.TxType,
+            entry.Change,
+            entry.Balance,
+        })
+    }
+
+    rows = append(rows, []string{address, assets, "", "", "", "closing balance", "", s.ClosingBalance})
+
+    err = writer.WriteAll(rows)
+    if err != nil {
+        return
+    }
+
+    data = buf.Bytes()
+    return
+}
+
+func (l *Ledger) queryAccountStatement(p []string) (result interface{}, err error) {
+    param := AccountStatementParameter{}
+    err = json.Unmarshal([]byte(p[0]), &param)
+    if err != nil {
+        return
+    }
+
+    statement, err := l.accountStatement(param)
+    if err != nil {
+        return
+    }
+
+    switch param.Format {
+    case "", StatementFormats.JSON.String():
+        result = statement
+
+    case StatementFormats.CSV.String():
+        data, csvErr := statement.csvBytes()
+        if csvErr != nil {
+            return nil, csvErr
+        }
+        result = string(data)
+
+    default:
+        err = errors.New("unsupported statement format: " + param.Format)
+    }
+
+    return
+}
@@ -24,7 +24,7 @@ import (
     "time"
 )
 
-func (l *Ledger) verifyIncreaseSupply(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyIncreaseSupply(tx Transaction, _ lockContext) (ret interface{}, err error) {
     assets := tx.Assets
     err = assets.verify(l.CryptoTools)
     if err != nil {
@@ -47,7 +47,17 @@ func (l *Ledger) verifyIncreaseSupply(tx Transaction) (ret interface{}, err erro
         return
     }
 
-    if assets.Supply <= localAssets.Supply {
+    newSupply, err := ParseAmount(assets.Supply)
+    if err != nil {
+        return
+    }
+
+    localSupply, err := ParseAmount(localAssets.Supply)
+    if err != nil {
+        return
+    }
+
+    if newSupply.Cmp(localSupply) <= 0 {
         err = errors.New("invalid new supply")
         return
     }
@@ -55,11 +65,11 @@ func (l *Ledger) verifyIncreaseSupply(tx Transaction) (ret interface{}, err erro
     return
 }
 
-func (l *Ledger) confirmIncreaseSupply(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmIncreaseSupply(tx Transaction, ctx lockContext) (ret interface{}, err error) {
     l.operateLock.Lock()
     defer l.operateLock.Unlock()
 
-    ret, err = l.verifyIncreaseSupply(tx)
+    ret, err = l.verifyIncreaseSupply(tx, ctx)
     if err != nil {
         return
     }
@@ -69,7 +79,7 @@ func (l *Ledger) confirmIncreaseSupply(tx Transaction) (ret interface{}, err err
     return
 }
 
-func (l *Ledger) verifyIssueAssets(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyIssueAssets(tx Transaction, _ lockContext) (ret interface{}, err error) {
     if tx.TxType != TransactionTypes.IssueAssets.String() {
         err = errors.New("invalid transaction type")
         return
@@ -89,7 +99,7 @@ func (l *Ledger) verifyIssueAssets(tx Transaction) (ret interface{}, err error)
     return
 }
 
-func (l *Ledger) confirmIssueAssets(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmIssueAssets(tx Transaction, _ lockContext) (ret interface{}, err error) {
     l.operateLock.Lock()
     defer l.operateLock.Unlock()
 
@@ -0,0 +1,393 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "bytes"
+    "encoding/json"
+    "errors"
+    "math/big"
+)
+
+//AssetsActionResult is the result of burn, freeze, unfreeze and clawback transactions,
+//Target is the address the action applied to and Amount is the burned or recovered amount
+type AssetsActionResult struct {
+    UnspentListWithBalance
+
+    Assets []byte
+    Target []byte
+    Amount string
+}
+
+func (l *Ledger) buildFrozenKey(assetsHash []byte, address []byte) (key []byte) {
+    //prefix + assets hash + address
+    key = []byte(StoragePrefixes.Frozen.String())
+    key = append(key, assetsHash...)
+    key = append(key, address...)
+    return
+}
+
+func (l *Ledger) isFrozen(assetsHash []byte, address []byte) bool {
+    kv, err := l.Storage.Get(l.buildFrozenKey(assetsHash, address))
+    if err != nil {
+        return false
+    }
+
+    return kv.Exists
+}
+
+func (l *Ledger) requireNotFrozen(list []Unspent) (err error) {
+    for _, u := range list {
+        if l.isFrozen(u.AssetsHash, u.Owner) {
+            return errors.New("address is frozen for the assets")
+        }
+    }
+
+    return
+}
+
+//pendingFreeze is a freeze or unfreeze verified in the block but not executed yet
+type pendingFreeze struct {
+    txHash []byte
+    freeze bool
+}
+
+//pendingFreezeCheck checks the transaction against the freezes verified before it in the block, which are checked
+//against the state before the block: the freeze state of an address is changed once in a block, and the outputs of
+//an address frozen by an earlier transaction can't be spent.
+func (l *Ledger) pendingFreezeCheck(tx Transaction) (err error) {
+    if tx.TxType == TransactionTypes.Freeze.String() || tx.TxType == TransactionTypes.Unfreeze.String() {
+        data := FreezeData{}
+        _ = json.Unmarshal(tx.ExtraData, &data)
+
+        key := string(l.buildFrozenKey(tx.Assets.getUniqueHash(), data.Address))
+        if pending, exists := l.execFreezeRecord[key]; exists && !bytes.Equal(pending.txHash, tx.Seal.Hash) {
+            return errors.New("freeze state of the address is changed by another transaction in the block")
+        }
+
+        l.execFreezeRecord[key] = pendingFreeze{
+            txHash: tx.Seal.Hash,
+            freeze: tx.TxType == TransactionTypes.Freeze.String(),
+        }
+        return
+    }
+
+    //issuer claws back the outputs of frozen addresses
+    if !spendsInputs(tx.TxType) || tx.TxType == TransactionTypes.Clawback.String() {
+        return
+    }
+
+    for _, u := range l.inputUnspentList(tx) {
+        pending, exists := l.execFreezeRecord[string(l.buildFrozenKey(u.AssetsHash, u.Owner))]
+        if exists && pending.freeze {
+            return errors.New("address is frozen for the assets by another transaction in the block")
+        }
+    }
+
+    return
+}
+
+//controlledAssets gets the local assets of the transaction which must be issued by the signer
+func (l *Ledger) controlledAssets(tx Transaction) (assets Assets, err error) {
+    assets, err = l.localAssetsFromHash(tx.Assets.getUniqueHash())
+    if err != nil {
+        return
+    }
+
+    if !bytes.Equal(assets.IssuedSeal.SignerPublicKey, tx.Seal.SignerPublicKey) {
+        err = errors.New("not assets issuer")
+        return
+    }
+
+    return
+}
+
+func (l *Ledger) outputsAmount(tx Transaction) (amount *big.Int, err error) {
+    var outValues []string
+    for _, output := range tx.Output {
+        err = output.Lock.validate()
+        if err != nil {
+            return
+        }
+
+        outValues = append(outValues, output.Value)
+    }
+
+    return sumAmounts(outValues...)
+}
+
+func (l *Ledger) verifyBurn(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    if len(tx.Input) == 0 {
+        return nil, errors.New("no input to burn")
+    }
+
+    assets, err := l.localAssetsFromHash(tx.Assets.getUniqueHash())
+    if err != nil {
+        return
+    }
+
+    if assets.Type == uint32(AssetsTypes.Copyright.Int()) {
+        return nil, errors.New("copyright can not be burned")
+    }
+
+    unspentList, totalIn, err := l.getUnspentListFromTransaction(tx)
+    if err != nil {
+        return
+    }
+
+    for i, unspent := range unspentList {
+        err = l.verifyUnlock(tx, tx.Input[i], unspent, ctx)
+        if err != nil {
+            return
+        }
+    }
+
+    totalOut, err := l.outputsAmount(tx)
+    if err != nil {
+        return
+    }
+
+    burned, err := subAmount(totalIn, totalOut)
+    if err != nil || burned.Sign() == 0 {
+        return nil, errors.New("nothing to burn")
+    }
+
+    supply, err := ParseAmount(assets.Supply)
+    if err != nil {
+        return
+    }
+
+    _, err = subAmount(supply, burned)
+    if err != nil {
+        return
+    }
+
+    return burned, nil
+}
+
+func (l *Ledger) confirmBurn(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    burnedRet, err := l.verifyBurn(tx, ctx)
+    if err != nil {
+        return
+    }
+    burned := burnedRet.(*big.Int)
+
+    //tx in this phase was verified, get unspent list directly
+    usList, _, err := l.getUnspentListFromTransaction(tx)
+    if err != nil {
+        return
+    }
+
+    localAssets, _ := l.localAssetsFromHash(tx.Assets.getUniqueHash())
+    ul, err := l.saveUnspent(localAssets, tx, usList)
+    if err != nil {
+        return
+    }
+    l.updateDoubleSpentCache(usList)
+
+    supply, _ := ParseAmount(localAssets.Supply)
+    supply, err = subAmount(supply, burned)
+    if err != nil {
+        return
+    }
+
+    localAssets.Supply = supply.String()
+    err = l.updateAssets(localAssets)
+    if err != nil {
+        return
+    }
+
+    ret = AssetsActionResult{
+        UnspentListWithBalance: ul,
+        Assets:                 localAssets.getUniqueHash(),
+        Target:                 tx.Seal.SignerPublicKey,
+        Amount:                 burned.String(),
+    }
+    return
+}
+
+func (l *Ledger) verifyFreeze(tx Transaction, _ lockContext) (ret interface{}, err error) {
+    if len(tx.Input) != 0 || len(tx.Output) != 0 {
+        return nil, errors.New("invalid input or output count")
+    }
+
+    assets, err := l.controlledAssets(tx)
+    if err != nil {
+        return
+    }
+
+    if !assets.Freezable {
+        return nil, errors.New("assets is not freezable")
+    }
+
+    data := FreezeData{}
+    err = json.Unmarshal(tx.ExtraData, &data)
+    if err != nil {
+        return
+    }
+
+    if len(data.Address) == 0 {
+        return nil, errors.New("no address to freeze")
+    }
+
+    frozen := l.isFrozen(assets.getUniqueHash(), data.Address)
+    if tx.TxType == TransactionTypes.Freeze.String() && frozen {
+        return nil, errors.New("address already frozen")
+    }
+
+    if tx.TxType == TransactionTypes.Unfreeze.String() && !frozen {
+        return nil, errors.New("address is not frozen")
+    }
+
+    return data, nil
+}
+
+func (l *Ledger) confirmFreeze(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    dataRet, err := l.verifyFreeze(tx, ctx)
+    if err != nil {
+        return
+    }
+    data := dataRet.(FreezeData)
+
+    assetsHash := tx.Assets.getUniqueHash()
+    key := l.buildFrozenKey(assetsHash, data.Address)
+    if tx.TxType == TransactionTypes.Freeze.String() {
+        err = l.Storage.Put(kvDatabase.KVItem{
+            Key:  key,
+            Data: tx.Seal.Hash,
+        })
+    } else {
+        err = l.Storage.Delete(key)
+    }
+
+    if err != nil {
+        return
+    }
+
+    delete(l.execFreezeRecord, string(key))
+    ret = AssetsActionResult{
+        Assets: assetsHash,
+        Target: data.Address,
+    }
+    return
+}
+
+//clawbackUnspentList gets the outputs the issuer recovers, every input must name the same owner of its output
+func (l *Ledger) clawbackUnspentList(tx Transaction) (list []Unspent, amount *big.Int, err error) {
+    amount = big.NewInt(0)
+    for _, ref := range tx.Input {
+        if len(ref.Owner) == 0 || bytes.Equal(ref.Owner, []byte(MarketAddress)) {
+            err = errors.New("invalid owner to claw back")
+            break
+        }
+
+        if !bytes.Equal(ref.Owner, tx.Input[0].Owner) {
+            err = errors.New("claw back from more than one owner")
+            break
+        }
+
+        key := l.buildUnspentStorageKey(ref.Owner, tx.Assets.getUniqueHash(), ref.Transaction, ref.OutputIndex)
+        unspent, dbErr := l.getUnspent(key)
+        if dbErr != nil {
+            err = errors.New("get Unspent failed: " + dbErr.Error())
+            break
+        }
+
+        value, parseErr := ParseAmount(unspent.Value)
+        if parseErr != nil {
+            err = parseErr
+            break
+        }
+
+        amount, err = addAmount(amount, value)
+        if err != nil {
+            break
+        }
+
+        list = append(list, unspent)
+    }
+
+    return
+}
+
+func (l *Ledger) verifyClawback(tx Transaction, _ lockContext) (ret interface{}, err error) {
+    if len(tx.Input) == 0 {
+        return nil, errors.New("no input to claw back")
+    }
+
+    assets, err := l.controlledAssets(tx)
+    if err != nil {
+        return
+    }
+
+    if !assets.Clawbackable {
+        return nil, errors.New("assets is not clawbackable")
+    }
+
+    unspentList, totalIn, err := l.clawbackUnspentList(tx)
+    if err != nil {
+        return
+    }
+
+    totalOut, err := l.outputsAmount(tx)
+    if err != nil {
+        return
+    }
+
+    if totalIn.Cmp(totalOut) != 0 {
+        return nil, errors.New("input not equal output")
+    }
+
+    return unspentList, nil
+}
+
+func (l *Ledger) confirmClawback(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    _, err = l.verifyClawback(tx, ctx)
+    if err != nil {
+        return
+    }
+
+    usList, amount, err := l.clawbackUnspentList(tx)
+    if err != nil {
+        return
+    }
+
+    localAssets, _ := l.localAssetsFromHash(tx.Assets.getUniqueHash())
+    ul, err := l.saveUnspent(localAssets, tx, usList)
+    if err != nil {
+        return
+    }
+    l.updateDoubleSpentCache(usList)
+
+    ret = AssetsActionResult{
+        UnspentListWithBalance: ul,
+        Assets:                 localAssets.getUniqueHash(),
+        Target:                 tx.Input[0].Owner,
+        Amount:                 amount.String(),
+    }
+    return
+}
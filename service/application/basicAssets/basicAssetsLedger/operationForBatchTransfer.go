@@ -0,0 +1,188 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package basicAssetsLedger
+
+import (
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+)
+
+//a batch transfer pays many addresses in one or more assets with one signature. every leg of the batch is a transfer
+//of a single assets, the legs are verified as transfers of the signer and executed in one journal of the transaction,
+//so the whole batch is committed or none of it. the outputs of the legs are numbered after each other, every output of
+//the batch has its own index under the transaction hash.
+
+//BatchTransferLeg is the transfer of one assets in a batch transfer
+type BatchTransferLeg struct {
+    Assets Assets
+    Input  []UTXOInput
+    Output []UTXOOutput
+}
+
+//BatchTransferData is the extra data of a BatchTransfer transaction, the inputs and outputs of the transaction
+//itself must be empty
+type BatchTransferData struct {
+    Legs []BatchTransferLeg
+}
+
+//BatchTransferLegResult is the result of a leg of an executed batch transfer, Transaction is the leg as a transaction
+//with the hash and seal of the batch
+type BatchTransferLegResult struct {
+    Transaction Transaction
+    UnspentListWithBalance
+}
+
+type BatchTransferResult struct {
+    Legs []BatchTransferLegResult
+}
+
+//BatchTransferLegs unpacks the legs of a batch transfer as transactions with the type, memo, time and seal of the batch,
+//the lock owners of multi-signature inputs sign the witness data of their leg
+func BatchTransferLegs(tx Transaction) (legs []Transaction, err error) {
+    if tx.TxType != TransactionTypes.BatchTransfer.String() {
+        return nil, errors.New("not a batch transfer")
+    }
+
+    batch := BatchTransferData{}
+    err = json.Unmarshal(tx.ExtraData, &batch)
+    if err != nil {
+        return nil, errors.New("invalid batch transfer data: " + err.Error())
+    }
+
+    for _, leg := range batch.Legs {
+        legTx := Transaction{
+            CreateTime: tx.CreateTime,
+            Seal:       tx.Seal,
+        }
+
+        legTx.TxType = tx.TxType
+        legTx.Assets = leg.Assets
+        legTx.Memo = tx.Memo
+        legTx.Input = leg.Input
+        legTx.Output = leg.Output
+
+        legs = append(legs, legTx)
+    }
+
+    return
+}
+
+//batchTransferUnspentList gets the outputs spent by all legs of the batch transfer
+func (l *Ledger) batchTransferUnspentList(tx Transaction) (list []Unspent, err error) {
+    legs, err := BatchTransferLegs(tx)
+    if err != nil {
+        return
+    }
+
+    for _, legTx := range legs {
+        legList, _, legErr := l.getUnspentListFromTransaction(legTx)
+        if legErr != nil {
+            return nil, legErr
+        }
+
+        list = append(list, legList...)
+    }
+
+    return
+}
+
+func (l *Ledger) verifyBatchTransfer(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    if len(tx.Input) != 0 || len(tx.Output) != 0 {
+        return nil, errors.New("inputs and outputs of a batch transfer must be in its legs")
+    }
+
+    err = tx.Verify(l.CryptoTools)
+    if err != nil {
+        return nil, errors.New("invalid batch transfer signature: " + err.Error())
+    }
+
+    legs, err := BatchTransferLegs(tx)
+    if err != nil {
+        return
+    }
+
+    if len(legs) == 0 {
+        return nil, errors.New("batch transfer has no legs")
+    }
+
+    assetsInBatch := map[string] bool {}
+    var unspentList []Unspent
+    for _, legTx := range legs {
+        assetsKey := hex.EncodeToString(legTx.Assets.getUniqueHash())
+        if assetsInBatch[assetsKey] {
+            return nil, errors.New("more than one leg of assets " + assetsKey)
+        }
+        assetsInBatch[assetsKey] = true
+
+        if len(legTx.Input) == 0 || len(legTx.Output) == 0 {
+            return nil, errors.New("empty leg of assets " + assetsKey)
+        }
+
+        legRet, legErr := l.verifyTransfer(legTx, ctx)
+        if legErr != nil {
+            return nil, errors.New("invalid leg " + assetsKey + ": " + legErr.Error())
+        }
+
+        unspentList = append(unspentList, legRet.([]Unspent)...)
+    }
+
+    ret = unspentList
+    return
+}
+
+func (l *Ledger) confirmBatchTransfer(tx Transaction, _ lockContext) (ret interface{}, err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    legs, err := BatchTransferLegs(tx)
+    if err != nil {
+        return
+    }
+
+    //a failed leg leaves the legs before it in the journal of the transaction, which undoes them
+    result := BatchTransferResult{}
+    var spent []Unspent
+    firstIndex := uint64(0)
+    for _, legTx := range legs {
+        usList, _, listErr := l.getUnspentListFromTransaction(legTx)
+        if listErr != nil {
+            return nil, listErr
+        }
+
+        localAssets, _ := l.localAssetsFromHash(legTx.Assets.getUniqueHash())
+
+        legList, saveErr := l.saveUnspentFrom(localAssets, legTx, usList, firstIndex)
+        if saveErr != nil {
+            return nil, saveErr
+        }
+
+        result.Legs = append(result.Legs, BatchTransferLegResult{
+            Transaction:            legTx,
+            UnspentListWithBalance: legList,
+        })
+
+        spent = append(spent, usList...)
+        firstIndex += uint64(len(legTx.Output))
+    }
+
+    l.updateDoubleSpentCache(spent)
+
+    ret = result
+    return
+}
@@ -0,0 +1,567 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"math/big"
+)
+
+//MaxFillsPerOrder is the most resting orders one order is matched against, what is left of it after
+//that many fills rests in the book even if it still crosses the opposite side
+const MaxFillsPerOrder = 64
+
+var OrderSides struct {
+	Bid enum.Element
+	Ask enum.Element
+}
+
+var OrderStatus struct {
+	Open            enum.Element
+	PartiallyFilled enum.Element
+	Filled          enum.Element
+	Cancelled       enum.Element
+}
+
+//Order is a limit order in the order book, the assets it pays are escrowed in an output owned by
+//the MarketAddress, Escrow is the output holding what is left of them
+type Order struct {
+	OrderData
+
+	ID       []byte
+	Owner    []byte
+	Filled   string
+	Status   string
+	Sequence uint64 `json:",string"`
+	Escrow   Unspent
+}
+
+//OrderSettlement is the outputs of one assets spent and created by an order transaction
+type OrderSettlement struct {
+	Assets []byte
+	Input  []Unspent
+	Output []UTXOOutput
+}
+
+type OrderOperationResult struct {
+	UnspentListWithBalance
+
+	Order       Order
+	Makers      []Order
+	Settlements []OrderSettlement
+}
+
+//amountOf parses an amount checked before, such as the amounts of the stored orders
+func amountOf(s string) *big.Int {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
+
+func (o Order) remaining() *big.Int {
+	return big.NewInt(0).Sub(amountOf(o.Amount), amountOf(o.Filled))
+}
+
+func (o Order) isBid() bool {
+	return o.Side == OrderSides.Bid.String()
+}
+
+//payAssets is the assets escrowed by the order
+func (o Order) payAssets() []byte {
+	if o.isBid() {
+		return o.QuoteAssets
+	}
+	return o.BaseAssets
+}
+
+//receiveAssets is the assets the order gets from its fills
+func (o Order) receiveAssets() []byte {
+	if o.isBid() {
+		return o.BaseAssets
+	}
+	return o.QuoteAssets
+}
+
+func (o Order) crosses(maker Order) bool {
+	cmp := amountOf(o.Price).Cmp(amountOf(maker.Price))
+	if o.isBid() {
+		return cmp >= 0
+	}
+	return cmp <= 0
+}
+
+func (o *Order) updateStatus() {
+	if o.remaining().Sign() == 0 {
+		o.Status = OrderStatus.Filled.String()
+	} else if amountOf(o.Filled).Sign() > 0 {
+		o.Status = OrderStatus.PartiallyFilled.String()
+	} else {
+		o.Status = OrderStatus.Open.String()
+	}
+}
+
+func (o Order) isActive() bool {
+	return o.Status == OrderStatus.Open.String() || o.Status == OrderStatus.PartiallyFilled.String()
+}
+
+//escrowAmount is the amount of assets an order must lock for the given base amount
+func (o Order) escrowAmount(amount *big.Int) (*big.Int, error) {
+	if o.isBid() {
+		return mulAmount(amount, amountOf(o.Price))
+	}
+	return amount, nil
+}
+
+func (l *Ledger) buildOrderKey(id []byte) []byte {
+	baseKey := []byte(StoragePrefixes.Orders.String())
+
+	return append(baseKey, id...)
+}
+
+func (l *Ledger) buildOrderBookPrefix(base []byte, quote []byte, side string) (prefix []byte) {
+	//prefix + base assets + quote assets + side
+	prefix = []byte(StoragePrefixes.OrderBook.String())
+	prefix = append(prefix, base...)
+	prefix = append(prefix, quote...)
+	prefix = append(prefix, []byte(side)...)
+	return
+}
+
+func (l *Ledger) buildOrderBookKey(o Order) (key []byte) {
+	//prefix + price + sequence + order id, the best price comes first on both sides,
+	//orders at the same price are in the placing sequence
+	price := amountOf(o.Price)
+	if o.isBid() {
+		price = big.NewInt(0).Sub(MaxAmount, price)
+	}
+
+	priceBytes := amountBytes(price)
+	seqBytes := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(seqBytes, o.Sequence)
+
+	key = l.buildOrderBookPrefix(o.BaseAssets, o.QuoteAssets, o.Side)
+	key = append(key, priceBytes...)
+	key = append(key, seqBytes...)
+	key = append(key, o.ID...)
+	return
+}
+
+func (l *Ledger) nextOrderSequence() (seq uint64, err error) {
+	key := []byte(StoragePrefixes.OrderSequence.String())
+	kv, err := l.Storage.Get(key)
+	if err != nil {
+		return
+	}
+
+	if kv.Exists {
+		seq = binary.BigEndian.Uint64(kv.Data)
+	}
+	seq += 1
+
+	seqBytes := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(seqBytes, seq)
+	err = l.Storage.Put(kvDatabase.KVItem{
+		Key:  key,
+		Data: seqBytes,
+	})
+	return
+}
+
+func (l *Ledger) getOrder(id []byte) (o Order, err error) {
+	kv, err := l.Storage.Get(l.buildOrderKey(id))
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		err = errors.New("no such order")
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &o)
+	return
+}
+
+//storeOrder saves the order and keeps it in the order book only while it is active
+func (l *Ledger) storeOrder(o Order) (err error) {
+	data, _ := json.Marshal(o)
+	err = l.Storage.Put(kvDatabase.KVItem{
+		Key:  l.buildOrderKey(o.ID),
+		Data: data,
+	})
+	if err != nil {
+		return
+	}
+
+	bookKey := l.buildOrderBookKey(o)
+	if !o.isActive() {
+		return l.Storage.Delete(bookKey)
+	}
+
+	return l.Storage.Put(kvDatabase.KVItem{
+		Key:  bookKey,
+		Data: o.ID,
+	})
+}
+
+func (l *Ledger) orderDataVerify(tx Transaction) (data OrderData, err error) {
+	err = json.Unmarshal(tx.ExtraData, &data)
+	if err != nil {
+		return
+	}
+
+	if data.Side != OrderSides.Bid.String() && data.Side != OrderSides.Ask.String() {
+		return data, errors.New("invalid order side")
+	}
+
+	if bytes.Equal(data.BaseAssets, data.QuoteAssets) {
+		return data, errors.New("base assets must not as same as quote assets")
+	}
+
+	for _, s := range []string{data.Price, data.Amount} {
+		amount, amountErr := ParseAmount(s)
+		if amountErr != nil {
+			return data, amountErr
+		}
+
+		if amount.Sign() == 0 {
+			return data, errors.New("invalid price or amount")
+		}
+	}
+
+	for _, hash := range [][]byte{data.BaseAssets, data.QuoteAssets} {
+		assets, assetsErr := l.localAssetsFromHash(hash)
+		if assetsErr != nil {
+			return data, assetsErr
+		}
+
+		if assets.Type == uint32(AssetsTypes.Copyright.Int()) {
+			return data, errors.New("copyright can not be traded in order book")
+		}
+	}
+
+	return
+}
+
+func (l *Ledger) verifyPlaceOrder(tx Transaction, _ lockContext) (ret interface{}, err error) {
+	if len(tx.Input) == 0 || len(tx.Output) != 0 {
+		return nil, errors.New("invalid input or output count")
+	}
+
+	data, err := l.orderDataVerify(tx)
+	if err != nil {
+		return
+	}
+
+	o := Order{OrderData: data}
+	if !bytes.Equal(o.payAssets(), tx.Assets.getUniqueHash()) {
+		return nil, errors.New("invalid assets")
+	}
+
+	escrow, err := o.escrowAmount(amountOf(o.Amount))
+	if err != nil {
+		return
+	}
+
+	usList, inAmount, err := l.getUnspentListFromTransaction(tx)
+	if err != nil {
+		return
+	}
+
+	err = requireUnlocked(usList)
+	if err != nil {
+		return
+	}
+
+	if inAmount.Cmp(escrow) < 0 {
+		return nil, errors.New("input amount not enough for the order")
+	}
+
+	return usList, nil
+}
+
+func (l *Ledger) verifyCancelOrder(tx Transaction, _ lockContext) (ret interface{}, err error) {
+	if len(tx.Input) != 0 || len(tx.Output) != 0 {
+		return nil, errors.New("invalid input or output count")
+	}
+
+	data := CancelOrderData{}
+	err = json.Unmarshal(tx.ExtraData, &data)
+	if err != nil {
+		return
+	}
+
+	o, err := l.getOrder(data.Order)
+	if err != nil {
+		return
+	}
+
+	if !bytes.Equal(o.Owner, tx.Seal.SignerPublicKey) {
+		return nil, errors.New("not order owner")
+	}
+
+	if !o.isActive() {
+		return nil, errors.New("order is not active")
+	}
+
+	return o, nil
+}
+
+//confirmPlaceOrder matches the order against the opposite side of the book by price-time priority,
+//every fill is traded at the price of the resting order, and what is not filled rests in the book.
+//an order is filled by MaxFillsPerOrder resting orders at most, so a single transaction can't take
+//unbounded work to execute
+func (l *Ledger) confirmPlaceOrder(tx Transaction, _ lockContext) (ret interface{}, err error) {
+	l.operateLock.Lock()
+	defer l.operateLock.Unlock()
+
+	data := OrderData{}
+	_ = json.Unmarshal(tx.ExtraData, &data)
+	data.Price = amountOf(data.Price).String()
+	data.Amount = amountOf(data.Amount).String()
+
+	//tx in this phase was verified, get unspent list directly
+	usList, inAmount, err := l.getUnspentListFromTransaction(tx)
+	if err != nil {
+		return
+	}
+
+	seq, err := l.nextOrderSequence()
+	if err != nil {
+		return
+	}
+
+	taker := Order{
+		OrderData: data,
+		ID:        tx.Seal.Hash,
+		Owner:     tx.Seal.SignerPublicKey,
+		Filled:    "0",
+		Sequence:  seq,
+	}
+
+	oppositeSide := OrderSides.Bid.String()
+	if taker.isBid() {
+		oppositeSide = OrderSides.Ask.String()
+	}
+
+	//outputs of the assets paid by the taker and of the assets paid by the makers,
+	//the first output of the makers' assets goes to the taker
+	var payOut []UTXOOutput
+	receiveOut := []UTXOOutput{{To: taker.Owner}}
+	var makerEscrows []Unspent
+	var makers []Order
+	spent := big.NewInt(0)
+	received := big.NewInt(0)
+
+	bookList := l.Storage.Traversal(l.buildOrderBookPrefix(data.BaseAssets, data.QuoteAssets, oppositeSide))
+	for _, item := range bookList {
+		if taker.remaining().Sign() == 0 || len(makers) >= MaxFillsPerOrder {
+			break
+		}
+
+		maker, getErr := l.getOrder(item.Data)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if !taker.crosses(maker) {
+			break
+		}
+
+		fill := taker.remaining()
+		if maker.remaining().Cmp(fill) < 0 {
+			fill = maker.remaining()
+		}
+
+		quote, quoteErr := mulAmount(fill, amountOf(maker.Price))
+		if quoteErr != nil {
+			return nil, quoteErr
+		}
+
+		paid, got := quote, fill
+		if !taker.isBid() {
+			paid, got = fill, quote
+		}
+
+		payOut = append(payOut, UTXOOutput{To: maker.Owner, Value: paid.String()})
+		spent.Add(spent, paid)
+		received.Add(received, got)
+
+		left, subErr := subAmount(amountOf(maker.Escrow.Value), got)
+		if subErr != nil {
+			return nil, errors.New("maker escrow not enough")
+		}
+
+		makerEscrows = append(makerEscrows, maker.Escrow)
+
+		taker.Filled = big.NewInt(0).Add(amountOf(taker.Filled), fill).String()
+		maker.Filled = big.NewInt(0).Add(amountOf(maker.Filled), fill).String()
+		maker.updateStatus()
+
+		if left.Sign() > 0 {
+			maker.Escrow = Unspent{
+				Owner:       []byte(MarketAddress),
+				AssetsHash:  maker.payAssets(),
+				Transaction: tx.Seal.Hash,
+				Singer:      tx.Seal.SignerPublicKey,
+				OutputIndex: uint64(len(receiveOut)),
+				Value:       left.String(),
+			}
+			receiveOut = append(receiveOut, UTXOOutput{To: []byte(MarketAddress), Value: left.String()})
+		} else {
+			maker.Escrow = Unspent{}
+		}
+
+		makers = append(makers, maker)
+	}
+
+	taker.updateStatus()
+	escrow, err := taker.escrowAmount(taker.remaining())
+	if err != nil {
+		return
+	}
+
+	receiveOut[0].Value = received.String()
+	change, err := subAmount(inAmount, big.NewInt(0).Add(spent, escrow))
+	if err != nil {
+		return nil, errors.New("input amount not enough for the order")
+	}
+
+	if escrow.Sign() > 0 {
+		taker.Escrow = Unspent{
+			Owner:       []byte(MarketAddress),
+			AssetsHash:  taker.payAssets(),
+			Transaction: tx.Seal.Hash,
+			Singer:      tx.Seal.SignerPublicKey,
+			OutputIndex: uint64(len(payOut)),
+			Value:       escrow.String(),
+		}
+		payOut = append(payOut, UTXOOutput{To: []byte(MarketAddress), Value: escrow.String()})
+	}
+
+	if change.Sign() > 0 {
+		payOut = append(payOut, UTXOOutput{To: taker.Owner, Value: change.String()})
+	}
+
+	payAssets, _ := l.localAssetsFromHash(taker.payAssets())
+	tx.Output = payOut
+	ul, err := l.saveUnspent(payAssets, tx, usList)
+	if err != nil {
+		return
+	}
+	l.updateDoubleSpentCache(usList)
+
+	settlements := []OrderSettlement{{
+		Assets: taker.payAssets(),
+		Input:  usList,
+		Output: payOut,
+	}}
+
+	if len(makerEscrows) > 0 {
+		receiveAssets, _ := l.localAssetsFromHash(taker.receiveAssets())
+		tx.Output = receiveOut
+		receiveUl, saveErr := l.saveUnspent(receiveAssets, tx, makerEscrows)
+		if saveErr != nil {
+			return nil, saveErr
+		}
+
+		ul.BalanceList = append(ul.BalanceList, receiveUl.BalanceList...)
+		ul.UnspentList = append(ul.UnspentList, receiveUl.UnspentList...)
+
+		settlements = append(settlements, OrderSettlement{
+			Assets: taker.receiveAssets(),
+			Input:  makerEscrows,
+			Output: receiveOut,
+		})
+	}
+
+	for _, maker := range makers {
+		err = l.storeOrder(maker)
+		if err != nil {
+			return
+		}
+	}
+
+	err = l.storeOrder(taker)
+	if err != nil {
+		return
+	}
+
+	ret = OrderOperationResult{
+		UnspentListWithBalance: ul,
+		Order:                  taker,
+		Makers:                 makers,
+		Settlements:            settlements,
+	}
+	return
+}
+
+func (l *Ledger) confirmCancelOrder(tx Transaction, _ lockContext) (ret interface{}, err error) {
+	l.operateLock.Lock()
+	defer l.operateLock.Unlock()
+
+	data := CancelOrderData{}
+	_ = json.Unmarshal(tx.ExtraData, &data)
+
+	o, err := l.getOrder(data.Order)
+	if err != nil {
+		return
+	}
+
+	if !o.isActive() {
+		return nil, errors.New("order is not active")
+	}
+
+	escrowList := []Unspent{o.Escrow}
+	tx.Output = []UTXOOutput{
+		{
+			To:    o.Owner,
+			Value: o.Escrow.Value,
+		},
+	}
+
+	localAssets, _ := l.localAssetsFromHash(o.Escrow.AssetsHash)
+	ul, err := l.saveUnspent(localAssets, tx, escrowList)
+	if err != nil {
+		return
+	}
+
+	o.Status = OrderStatus.Cancelled.String()
+	o.Escrow = Unspent{}
+	err = l.storeOrder(o)
+	if err != nil {
+		return
+	}
+
+	ret = OrderOperationResult{
+		UnspentListWithBalance: ul,
+		Order:                  o,
+		Settlements: []OrderSettlement{{
+			Assets: localAssets.getUniqueHash(),
+			Input:  escrowList,
+			Output: tx.Output,
+		}},
+	}
+	return
+}
@@ -0,0 +1,306 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package basicAssetsLedger
+
+import (
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "testing"
+)
+
+type orderBookTest struct {
+    t     *testing.T
+    l     *Ledger
+    base  Assets
+    quote Assets
+    txSeq int
+}
+
+func newOrderBookTest(t *testing.T) *orderBookTest {
+    ot := &orderBookTest{
+        t: t,
+        l: newTestLedger(t),
+    }
+
+    ot.base = ot.issue("base")
+    ot.quote = ot.issue("quote")
+    return ot
+}
+
+func (ot *orderBookTest) issue(name string) (assets Assets) {
+    assets.Name = name
+    assets.Supply = MaxAmount.String()
+    assets.MetaSeal.Hash = []byte(name)
+
+    err := ot.l.storeAssets(assets)
+    if err != nil {
+        ot.t.Fatalf("store assets %s failed: %s", name, err.Error())
+    }
+    return
+}
+
+func (ot *orderBookTest) nextTxHash() []byte {
+    ot.txSeq += 1
+    return []byte(fmt.Sprintf("tx-%04d", ot.txSeq))
+}
+
+//fund gives the owner an output of the assets and returns the input spending it
+func (ot *orderBookTest) fund(owner string, assets Assets, value string) UTXOInput {
+    tx := Transaction{}
+    tx.Seal.Hash = ot.nextTxHash()
+    tx.Output = []UTXOOutput{{To: []byte(owner), Value: value}}
+
+    _, err := ot.l.saveUnspent(assets, tx, nil)
+    if err != nil {
+        ot.t.Fatalf("fund %s failed: %s", owner, err.Error())
+    }
+
+    return UTXOInput{Transaction: tx.Seal.Hash, OutputIndex: 0}
+}
+
+//unspentOf is the sum of the outputs of the assets owned by the address
+func (ot *orderBookTest) unspentOf(owner string, assets Assets) string {
+    sum := big.NewInt(0)
+    prefix := ot.l.buildUnspentQueryPrefix([]byte(owner), assets.getUniqueHash())
+    for _, item := range ot.l.Storage.Traversal(prefix) {
+        u := Unspent{}
+        _ = json.Unmarshal(item.Data, &u)
+        sum.Add(sum, amountOf(u.Value))
+    }
+
+    return sum.String()
+}
+
+func (ot *orderBookTest) requireUnspent(owner string, assets Assets, want string) {
+    got := ot.unspentOf(owner, assets)
+    if got != want {
+        ot.t.Fatalf("%s owns %s of %s, want %s", owner, got, assets.Name, want)
+    }
+}
+
+func (ot *orderBookTest) place(owner string, side string, price string, amount string, funding string) OrderOperationResult {
+    data := OrderData{
+        Side:        side,
+        BaseAssets:  ot.base.getUniqueHash(),
+        QuoteAssets: ot.quote.getUniqueHash(),
+        Price:       price,
+        Amount:      amount,
+    }
+
+    payAssets := ot.base
+    if side == OrderSides.Bid.String() {
+        payAssets = ot.quote
+    }
+
+    tx := Transaction{}
+    tx.TxType = TransactionTypes.PlaceOrder.String()
+    tx.Assets = payAssets
+    tx.Input = []UTXOInput{ot.fund(owner, payAssets, funding)}
+    tx.ExtraData, _ = json.Marshal(data)
+    tx.Seal.Hash = ot.nextTxHash()
+    tx.Seal.SignerPublicKey = []byte(owner)
+
+    _, err := ot.l.verifyPlaceOrder(tx, lockContext{})
+    if err != nil {
+        ot.t.Fatalf("verify order of %s failed: %s", owner, err.Error())
+    }
+
+    ret, err := ot.l.confirmPlaceOrder(tx, lockContext{})
+    if err != nil {
+        ot.t.Fatalf("place order of %s failed: %s", owner, err.Error())
+    }
+
+    return ret.(OrderOperationResult)
+}
+
+func (ot *orderBookTest) cancelTx(owner string, order []byte) Transaction {
+    tx := Transaction{}
+    tx.TxType = TransactionTypes.CancelOrder.String()
+    tx.ExtraData, _ = json.Marshal(CancelOrderData{Order: order})
+    tx.Seal.Hash = ot.nextTxHash()
+    tx.Seal.SignerPublicKey = []byte(owner)
+    return tx
+}
+
+func (ot *orderBookTest) requireOrder(id []byte, status string, filled string, escrow string) Order {
+    o, err := ot.l.getOrder(id)
+    if err != nil {
+        ot.t.Fatalf("get order failed: %s", err.Error())
+    }
+
+    if o.Status != status || o.Filled != filled || amountOf(o.Escrow.Value).String() != escrow {
+        ot.t.Fatalf("order is %s filled %s escrow %s, want %s filled %s escrow %s",
+            o.Status, o.Filled, amountOf(o.Escrow.Value).String(), status, filled, escrow)
+    }
+    return o
+}
+
+func (ot *orderBookTest) bookSize(side string) int {
+    prefix := ot.l.buildOrderBookPrefix(ot.base.getUniqueHash(), ot.quote.getUniqueHash(), side)
+    return len(ot.l.Storage.Traversal(prefix))
+}
+
+func TestOrderPartialFill(t *testing.T) {
+    ot := newOrderBookTest(t)
+    bid := OrderSides.Bid.String()
+    ask := OrderSides.Ask.String()
+
+    maker := ot.place("alice", ask, "5", "10", "10")
+    ot.requireOrder(maker.Order.ID, OrderStatus.Open.String(), "0", "10")
+
+    //the bid takes 4 of the 10 asked, the rest of the ask stays in the book
+    taker := ot.place("bob", bid, "5", "4", "20")
+    if taker.Order.Status != OrderStatus.Filled.String() || len(taker.Makers) != 1 {
+        t.Fatalf("bid is %s with %d fills, want filled by one ask", taker.Order.Status, len(taker.Makers))
+    }
+
+    ot.requireOrder(maker.Order.ID, OrderStatus.PartiallyFilled.String(), "4", "6")
+    ot.requireUnspent("alice", ot.quote, "20")
+    ot.requireUnspent("bob", ot.base, "4")
+    ot.requireUnspent(MarketAddress, ot.base, "6")
+    if ot.bookSize(ask) != 1 || ot.bookSize(bid) != 0 {
+        t.Fatal("the partially filled ask must be the only order in the book")
+    }
+
+    //a larger bid fills the rest of the ask and rests in the book with what is left
+    taker = ot.place("carol", bid, "5", "10", "50")
+    if taker.Order.Status != OrderStatus.PartiallyFilled.String() || taker.Order.Filled != "6" {
+        t.Fatalf("bid is %s filled %s, want partially filled 6", taker.Order.Status, taker.Order.Filled)
+    }
+
+    ot.requireOrder(maker.Order.ID, OrderStatus.Filled.String(), "10", "0")
+    ot.requireOrder(taker.Order.ID, OrderStatus.PartiallyFilled.String(), "6", "20")
+    ot.requireUnspent("alice", ot.quote, "50")
+    ot.requireUnspent("carol", ot.base, "6")
+    ot.requireUnspent(MarketAddress, ot.base, "0")
+    ot.requireUnspent(MarketAddress, ot.quote, "20")
+    if ot.bookSize(ask) != 0 || ot.bookSize(bid) != 1 {
+        t.Fatal("the filled ask must leave the book and the rest of the bid must rest in it")
+    }
+}
+
+func TestOrderMakerPriceAndChange(t *testing.T) {
+    ot := newOrderBookTest(t)
+    bid := OrderSides.Bid.String()
+    ask := OrderSides.Ask.String()
+
+    ot.place("alice", ask, "3", "10", "10")
+
+    //the bid of 15 at 5 trades 10 at the ask price 3, escrows 5 at its own price 5,
+    //and gets back what it paid beyond that
+    taker := ot.place("bob", bid, "5", "15", "100")
+    if taker.Order.Filled != "10" {
+        t.Fatalf("bid filled %s, want 10", taker.Order.Filled)
+    }
+
+    ot.requireOrder(taker.Order.ID, OrderStatus.PartiallyFilled.String(), "10", "25")
+    ot.requireUnspent("alice", ot.quote, "30")
+    ot.requireUnspent("bob", ot.base, "10")
+    ot.requireUnspent("bob", ot.quote, "45")
+    ot.requireUnspent(MarketAddress, ot.quote, "25")
+    ot.requireUnspent(MarketAddress, ot.base, "0")
+
+    //an ask below the resting bid trades at the bid price
+    seller := ot.place("carol", ask, "4", "5", "5")
+    if seller.Order.Status != OrderStatus.Filled.String() {
+        t.Fatalf("ask is %s, want filled", seller.Order.Status)
+    }
+
+    ot.requireOrder(taker.Order.ID, OrderStatus.Filled.String(), "15", "0")
+    ot.requireUnspent("carol", ot.quote, "25")
+    ot.requireUnspent("bob", ot.base, "15")
+    ot.requireUnspent(MarketAddress, ot.quote, "0")
+}
+
+func TestOrderNotCrossing(t *testing.T) {
+    ot := newOrderBookTest(t)
+    bid := OrderSides.Bid.String()
+    ask := OrderSides.Ask.String()
+
+    maker := ot.place("alice", ask, "6", "10", "10")
+    taker := ot.place("bob", bid, "5", "10", "50")
+    if len(taker.Makers) != 0 {
+        t.Fatal("a bid below the ask must not be filled")
+    }
+
+    ot.requireOrder(maker.Order.ID, OrderStatus.Open.String(), "0", "10")
+    ot.requireOrder(taker.Order.ID, OrderStatus.Open.String(), "0", "50")
+    if ot.bookSize(ask) != 1 || ot.bookSize(bid) != 1 {
+        t.Fatal("both orders must rest in the book")
+    }
+}
+
+func TestOrderFillLimit(t *testing.T) {
+    ot := newOrderBookTest(t)
+    bid := OrderSides.Bid.String()
+    ask := OrderSides.Ask.String()
+
+    makerCount := MaxFillsPerOrder + 2
+    for i := 0; i < makerCount; i++ {
+        ot.place(fmt.Sprintf("maker-%04d", i), ask, "1", "1", "1")
+    }
+
+    taker := ot.place("bob", bid, "1", fmt.Sprint(makerCount), fmt.Sprint(makerCount))
+    if len(taker.Makers) != MaxFillsPerOrder {
+        t.Fatalf("bid filled by %d asks, want %d", len(taker.Makers), MaxFillsPerOrder)
+    }
+
+    ot.requireOrder(taker.Order.ID, OrderStatus.PartiallyFilled.String(), fmt.Sprint(MaxFillsPerOrder), "2")
+    ot.requireUnspent("bob", ot.base, fmt.Sprint(MaxFillsPerOrder))
+    if ot.bookSize(ask) != 2 || ot.bookSize(bid) != 1 {
+        t.Fatal("the asks beyond the fill limit and the rest of the bid must rest in the book")
+    }
+}
+
+func TestCancelOrder(t *testing.T) {
+    ot := newOrderBookTest(t)
+    ask := OrderSides.Ask.String()
+
+    maker := ot.place("alice", ask, "5", "10", "12")
+    ot.requireUnspent("alice", ot.base, "2")
+    ot.requireUnspent(MarketAddress, ot.base, "10")
+
+    _, err := ot.l.verifyCancelOrder(ot.cancelTx("bob", maker.Order.ID), lockContext{})
+    if err == nil {
+        t.Fatal("an order must not be cancelled by others")
+    }
+
+    tx := ot.cancelTx("alice", maker.Order.ID)
+    _, err = ot.l.verifyCancelOrder(tx, lockContext{})
+    if err != nil {
+        t.Fatalf("verify cancel failed: %s", err.Error())
+    }
+
+    _, err = ot.l.confirmCancelOrder(tx, lockContext{})
+    if err != nil {
+        t.Fatalf("cancel failed: %s", err.Error())
+    }
+
+    ot.requireOrder(maker.Order.ID, OrderStatus.Cancelled.String(), "0", "0")
+    ot.requireUnspent("alice", ot.base, "12")
+    ot.requireUnspent(MarketAddress, ot.base, "0")
+    if ot.bookSize(ask) != 0 {
+        t.Fatal("the cancelled order must leave the book")
+    }
+
+    _, err = ot.l.verifyCancelOrder(ot.cancelTx("alice", maker.Order.ID), lockContext{})
+    if err == nil {
+        t.Fatal("a cancelled order must not be cancelled again")
+    }
+}
@@ -22,6 +22,7 @@ import (
     "encoding/base64"
     "encoding/json"
     "errors"
+    "math/big"
 )
 
 func (l *Ledger)DoQuery(req QueryRequest) (data interface{}, err error) {
@@ -149,3 +150,76 @@ func (l *Ledger) queryCopyright(_ []string) (result interface{}, err error) {
     }
     return copyrightList, nil
 }
+
+func (l *Ledger) queryOrder(p []string) (result interface{}, err error) {
+    queryParam := OrderQueryParameter{}
+    err = json.Unmarshal([]byte(p[0]), &queryParam)
+    if err != nil {
+        return
+    }
+
+    result, err = l.getOrder(queryParam.Order)
+    return
+}
+
+func (l *Ledger) orderBookLevels(prefix []byte, maxLevels int) (levels []OrderBookLevel) {
+    list := l.Storage.Traversal(prefix)
+    for _, item := range list {
+        o, err := l.getOrder(item.Data)
+        if err != nil {
+            continue
+        }
+
+        last := len(levels) - 1
+        if last >= 0 && levels[last].Price == o.Price {
+            levels[last].Amount = big.NewInt(0).Add(amountOf(levels[last].Amount), o.remaining()).String()
+            levels[last].Orders += 1
+            continue
+        }
+
+        if maxLevels > 0 && len(levels) == maxLevels {
+            break
+        }
+
+        levels = append(levels, OrderBookLevel{
+            Price:  o.Price,
+            Amount: o.remaining().String(),
+            Orders: 1,
+        })
+    }
+
+    return
+}
+
+func (l *Ledger) queryOrderBook(p []string) (result interface{}, err error) {
+    queryParam := OrderBookQueryParameter{}
+    err = json.Unmarshal([]byte(p[0]), &queryParam)
+    if err != nil {
+        return
+    }
+
+    depth := OrderBookDepth{
+        BaseAssets:  queryParam.BaseAssets,
+        QuoteAssets: queryParam.QuoteAssets,
+    }
+
+    bidPrefix := l.buildOrderBookPrefix(queryParam.BaseAssets, queryParam.QuoteAssets, OrderSides.Bid.String())
+    askPrefix := l.buildOrderBookPrefix(queryParam.BaseAssets, queryParam.QuoteAssets, OrderSides.Ask.String())
+
+    depth.Bids = l.orderBookLevels(bidPrefix, queryParam.Levels)
+    depth.Asks = l.orderBookLevels(askPrefix, queryParam.Levels)
+
+    result = depth
+    return
+}
+
+func (l *Ledger) queryFrozen(p []string) (result interface{}, err error) {
+    queryParam := FrozenQueryParameter{}
+    err = json.Unmarshal([]byte(p[0]), &queryParam)
+    if err != nil {
+        return
+    }
+
+    result = l.isFrozen(queryParam.Assets, queryParam.Address)
+    return
+}
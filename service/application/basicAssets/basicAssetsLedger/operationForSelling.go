@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"math/big"
 )
 
 const MarketAddress = "MarketAddress"
@@ -48,7 +49,7 @@ func (l *Ledger) sellingDataVerify(tx Transaction) (data SellingData, err error)
 	return
 }
 
-func (l *Ledger) verifyStartSelling(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyStartSelling(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	if len(tx.Input) != 1 || len(tx.Output) != 0 {
 		return nil, errors.New("invalid input or output count")
 	}
@@ -58,10 +59,20 @@ func (l *Ledger) verifyStartSelling(tx Transaction) (ret interface{}, err error)
 		return
 	}
 
-	if sellingData.Amount < 1 {
+	amount, err := ParseAmount(sellingData.Amount)
+	if err != nil {
+		return
+	}
+
+	if amount.Sign() == 0 {
 		return nil, errors.New("invalid amount")
 	}
 
+	_, err = ParseAmount(sellingData.Price)
+	if err != nil {
+		return
+	}
+
 	if !bytes.Equal(sellingData.SellingAssets, tx.Assets.MetaSeal.Hash) {
 		return nil, errors.New("invalid assets")
 	}
@@ -77,10 +88,20 @@ func (l *Ledger) verifyStartSelling(tx Transaction) (ret interface{}, err error)
 		return
 	}
 
+	err = requireUnlocked([]Unspent{unspent})
+	if err != nil {
+		return
+	}
+
+	err = l.requireNotFrozen([]Unspent{unspent})
+	if err != nil {
+		return
+	}
+
 	return []Unspent{unspent}, nil
 }
 
-func (l *Ledger) verifyStopSelling(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyStopSelling(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	if len(tx.Input) != 0 || len(tx.Output) != 0 {
 		return nil, errors.New("invalid input or output count")
 	}
@@ -103,7 +124,7 @@ func (l *Ledger) verifyStopSelling(tx Transaction) (ret interface{}, err error)
 	return []Unspent{unspent}, nil
 }
 
-func (l *Ledger) verifyBuyAssets(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyBuyAssets(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	inCount := len(tx.Input)
 	if inCount < 2 {
 		return nil, errors.New("invalid input or output count")
@@ -123,7 +144,7 @@ func (l *Ledger) verifyBuyAssets(tx Transaction) (ret interface{}, err error) {
 	sellingData := SellingData{}
 	_ = json.Unmarshal(data.Data, &sellingData)
 
-	inAmount := uint64(0)
+	inAmount := big.NewInt(0)
 	var uList []Unspent
 
 	for i:=0; i < inCount - 1; i++ {
@@ -134,7 +155,17 @@ func (l *Ledger) verifyBuyAssets(tx Transaction) (ret interface{}, err error) {
 			break
 		}
 
-		inAmount += unspent.Value
+		value, parseErr := ParseAmount(unspent.Value)
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+
+		inAmount, err = addAmount(inAmount, value)
+		if err != nil {
+			break
+		}
+
 		uList = append(uList, unspent)
 	}
 
@@ -142,19 +173,39 @@ func (l *Ledger) verifyBuyAssets(tx Transaction) (ret interface{}, err error) {
 		return
 	}
 
-	outAmount := uint64(0)
+	err = requireUnlocked(uList)
+	if err != nil {
+		return
+	}
+
+	err = l.requireNotFrozen(uList)
+	if err != nil {
+		return
+	}
+
+	var outValues []string
 	for _, out := range tx.Output {
-		outAmount += out.Value
+		err = out.Lock.validate()
+		if err != nil {
+			return
+		}
+
+		outValues = append(outValues, out.Value)
+	}
+
+	outAmount, err := sumAmounts(outValues...)
+	if err != nil {
+		return
 	}
 
-	if inAmount != outAmount {
+	if inAmount.Cmp(outAmount) != 0 {
 		return nil, errors.New("input amount not equal output")
 	}
 
 	return uList, nil
 }
 
-func (l *Ledger) confirmStartSelling(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmStartSelling(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	l.operateLock.Lock()
 	defer l.operateLock.Unlock()
 
@@ -169,7 +220,7 @@ func (l *Ledger) confirmStartSelling(tx Transaction) (ret interface{}, err error
 	tx.Output = []UTXOOutput{
 		{
 			To:    []byte(MarketAddress),
-			Value: inAmount,
+			Value: inAmount.String(),
 		},
 	}
 
@@ -199,7 +250,7 @@ func (l *Ledger) confirmStartSelling(tx Transaction) (ret interface{}, err error
 	return
 }
 
-func (l *Ledger) confirmStopSelling(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmStopSelling(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	l.operateLock.Lock()
 	defer l.operateLock.Unlock()
 
@@ -239,7 +290,7 @@ func (l *Ledger) confirmStopSelling(tx Transaction) (ret interface{}, err error)
 	return
 }
 
-func (l *Ledger) confirmBuyAssets(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmBuyAssets(tx Transaction, _ lockContext) (ret interface{}, err error) {
 	l.operateLock.Lock()
 	defer l.operateLock.Unlock()
 
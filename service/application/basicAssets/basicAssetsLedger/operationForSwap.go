@@ -0,0 +1,180 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+)
+
+//a swap exchanges basic assets for a smart assets balance or token in one transaction. the swap transaction is a
+//transfer of the basic assets leg, its extra data holds the signed smart assets transaction of the other leg,
+//which carries the hex hash of the basic assets leg in its memo to commit its signer to the swap.
+//the smart assets leg is settled by an internal call when the swap is executed and rolled back with it,
+//so both legs are committed or neither is.
+
+//swapApplication is the application settling the other leg of a swap, the swap calls it as basicAssetsApplication
+const (
+    swapApplication        = "Smart Assets"
+    basicAssetsApplication = "Basic Assets"
+)
+
+//SwapData is the extra data of a Swap transaction, Action is the type of the signed smart assets transaction
+//in Leg, a Transfer for the base assets or a ContractCall for a token
+type SwapData struct {
+    Action string
+    Leg    []byte
+}
+
+//swapLegCommitment is the part of the json of the smart assets transaction the swap checks
+type swapLegCommitment struct {
+    From  []byte
+    Nonce uint64
+    Memo  string
+}
+
+//SwapLegHash is the hash the smart assets leg commits to, the transaction data of the swap without its extra data
+func (l *Ledger) SwapLegHash(data TransactionData) []byte {
+    data.ExtraData = nil
+    dataBytes, _ := structSerializer.ToMFBytes(data)
+    return l.CryptoTools.HashCalculator.Sum(dataBytes)
+}
+
+func (l *Ledger) swapLegCall(tx Transaction, callType string) (ret interface{}, err error) {
+    if l.chain == nil {
+        return nil, errors.New("no chain to settle the swap")
+    }
+
+    swap := SwapData{}
+    err = json.Unmarshal(tx.ExtraData, &swap)
+    if err != nil {
+        return nil, errors.New("invalid swap data: " + err.Error())
+    }
+
+    req := chainStructure.InternalCallRequest{
+        Type:    callType,
+        Action:  swap.Action,
+        Data:    swap.Leg,
+        UndoKey: tx.Seal.Hash,
+    }
+
+    return l.chain.InternalCall(basicAssetsApplication, swapApplication, req.Bytes())
+}
+
+func swapLegKey(tx Transaction) (key string, err error) {
+    swap := SwapData{}
+    err = json.Unmarshal(tx.ExtraData, &swap)
+    if err != nil {
+        return "", errors.New("invalid swap data: " + err.Error())
+    }
+
+    commitment := swapLegCommitment{}
+    err = json.Unmarshal(swap.Leg, &commitment)
+    if err != nil {
+        return "", errors.New("invalid swap leg: " + err.Error())
+    }
+
+    return fmt.Sprintf("%x:%d", commitment.From, commitment.Nonce), nil
+}
+
+//swapLegCheck rejects a swap whose smart assets leg uses the nonce of its sender taken by another swap, the legs are
+//verified against the state before the block, so only one of them could be settled when the block is executed
+func (l *Ledger) swapLegCheck(tx Transaction, cachePool map[string] bool) (err error) {
+    key, err := swapLegKey(tx)
+    if err != nil {
+        return
+    }
+
+    if cachePool[key] {
+        return errors.New("nonce of the swap leg is used by another swap")
+    }
+
+    cachePool[key] = true
+    return
+}
+
+func (l *Ledger) updateSwapLegCache(tx Transaction) {
+    key, err := swapLegKey(tx)
+    if err != nil {
+        return
+    }
+
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    delete(l.memSwapLegRecord, key)
+    delete(l.execSwapLegRecord, key)
+}
+
+func (l *Ledger) verifySwap(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    ret, err = l.verifyTransfer(tx, ctx)
+    if err != nil {
+        return
+    }
+
+    swap := SwapData{}
+    err = json.Unmarshal(tx.ExtraData, &swap)
+    if err != nil {
+        return nil, errors.New("invalid swap data: " + err.Error())
+    }
+
+    commitment := swapLegCommitment{}
+    err = json.Unmarshal(swap.Leg, &commitment)
+    if err != nil {
+        return nil, errors.New("invalid swap leg: " + err.Error())
+    }
+
+    if commitment.Memo != hex.EncodeToString(l.SwapLegHash(tx.TransactionData)) {
+        return nil, errors.New("swap leg is not committed to the transaction")
+    }
+
+    _, err = l.swapLegCall(tx, chainStructure.InternalCallVerify)
+    if err != nil {
+        return nil, errors.New("verify swap leg failed: " + err.Error())
+    }
+
+    return
+}
+
+func (l *Ledger) confirmSwap(tx Transaction, ctx lockContext) (ret interface{}, err error) {
+    ret, err = l.confirmTransfer(tx, ctx)
+    if err != nil {
+        return
+    }
+
+    //the basic assets leg is undone by the journal of the transaction if the other leg failed
+    _, err = l.swapLegCall(tx, chainStructure.InternalCallExecute)
+    if err != nil {
+        return nil, errors.New("settle swap leg failed: " + err.Error())
+    }
+
+    l.updateSwapLegCache(tx)
+    return
+}
+
+func (l *Ledger) rollbackSwapLeg(tx Transaction) (err error) {
+    if tx.TxType != TransactionTypes.Swap.String() {
+        return
+    }
+
+    _, err = l.swapLegCall(tx, chainStructure.InternalCallRollback)
+    return
+}
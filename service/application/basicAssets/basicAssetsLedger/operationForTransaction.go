@@ -19,10 +19,13 @@ package basicAssetsLedger
 
 import (
     "github.com/SealSC/SealABC/log"
+    "github.com/SealSC/SealABC/metadata/block"
     "github.com/SealSC/SealABC/metadata/blockchainRequest"
     "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "bytes"
     "encoding/json"
     "errors"
+    "fmt"
     "time"
 )
 
@@ -33,14 +36,14 @@ func (l *Ledger) buildTransactionKey(txHash []byte) (key [] byte) {
     return
 }
 
-func (l *Ledger) verifyTransaction(tx Transaction) (err error) {
+func (l *Ledger) verifyTransaction(tx Transaction, ctx lockContext) (err error) {
     validator, exists := l.txValidators[tx.TxType]
     if !exists {
         err = errors.New("no validator for this transaction: " + tx.TxType)
         return
     }
 
-    _, err = validator(tx)
+    _, err = validator(tx, ctx)
     if err != nil {
         log.Log.Error("invalid transaction: ", err.Error(), "\r\n", tx)
         return
@@ -76,13 +79,18 @@ func (l *Ledger) PushTransaction(req blockchainRequest.Entity) (err error) {
         return
     }
 
-    err = l.verifyTransaction(tx)
+    err = req.RequireSigner(tx.Seal.SignerPublicKey)
     if err != nil {
         return
     }
 
-    if tx.TxType == TransactionTypes.Transfer.String() {
-        usList, _, _ := l.getUnspentListFromTransaction(tx)
+    err = l.verifyTransaction(tx, l.nextBlockContext())
+    if err != nil {
+        return
+    }
+
+    if spendsInputs(tx.TxType) {
+        usList := l.inputUnspentList(tx)
 
         err = l.doubleSpentCheck(usList, l.memUTXORecord)
         if err != nil {
@@ -90,6 +98,13 @@ func (l *Ledger) PushTransaction(req blockchainRequest.Entity) (err error) {
         }
     }
 
+    if tx.TxType == TransactionTypes.Swap.String() {
+        err = l.swapLegCheck(tx, l.memSwapLegRecord)
+        if err != nil {
+            return err
+        }
+    }
+
     l.poolLock.Lock()
     defer l.poolLock.Unlock()
 
@@ -119,43 +134,279 @@ func (l *Ledger) RemoveTransactionFromPool(txKey string) {
     return
 }
 
-//wrap verify transaction method to solve interlock
-func (l *Ledger) VerifyTransaction(tx Transaction) (err error) {
+//wrap verify transaction method to solve interlock, locks of outputs are checked for the block
+func (l *Ledger) VerifyTransaction(tx Transaction, blk block.Entity) (err error) {
     l.operateLock.Lock()
     defer l.operateLock.Unlock()
 
-    err = l.verifyTransaction(tx)
+    err = l.verifyTransaction(tx, blockLockContext(blk))
     if err != nil {
         return
     }
 
-    if tx.TxType == TransactionTypes.Transfer.String() {
-        usList, _, _ := l.getUnspentListFromTransaction(tx)
+    err = l.pendingFreezeCheck(tx)
+    if err != nil {
+        return
+    }
+
+    if spendsInputs(tx.TxType) {
+        usList := l.inputUnspentList(tx)
         err = l.doubleSpentCheck(usList, l.execUTXORecord)
         if err != nil{
             return err
         }
     }
 
+    if tx.TxType == TransactionTypes.Swap.String() {
+        err = l.swapLegCheck(tx, l.execSwapLegRecord)
+        if err != nil {
+            return err
+        }
+    }
+
     return
 }
 
-func (l *Ledger) ExecuteTransaction(tx Transaction) (ret interface{}, err error) {
+//ExecuteTransaction executes the transaction in the block, the balance changes are recorded in the account history
+//with the height and time of the block
+func (l *Ledger) ExecuteTransaction(tx Transaction, blk block.Entity) (ret interface{}, err error) {
     handle, exists := l.txActuators[tx.TxType]
     if !exists {
         err = errors.New("no actuator for this transaction: " + tx.TxType)
         return
     }
 
-    ret, err = handle(tx)
+    l.setHistoryContext(tx, blk)
+
+    l.journal.Begin()
+    ret, err = handle(tx, blockLockContext(blk))
+    preImages := l.journal.End()
+
     if err != nil {
         log.Log.Error("execute transaction failed: ", tx)
+        if undoErr := l.journal.Undo(preImages); undoErr != nil {
+            log.Log.Error("undo failed transaction failed: ", undoErr.Error())
+        }
+        return
+    }
+
+    undoBytes, err := json.Marshal(preImages)
+    if err != nil {
+        return
+    }
+
+    err = l.Storage.Put(kvDatabase.KVItem{
+        Key: l.buildTransactionUndoKey(tx.Seal.Hash),
+        Data: undoBytes,
+    })
+
+    return
+}
+
+//settlementBlock is the block a transfer settled for another application is executed in,
+//it is the next block of the chain and has the time of the last block to keep the execution deterministic
+func (l *Ledger) settlementBlock() (blk block.Entity) {
+    if l.chain == nil {
+        return
+    }
+
+    blk.Header.Height = l.chain.CurrentHeight() + 1
+    if last := l.chain.GetLastBlock(); last != nil {
+        blk.Header.Timestamp = last.Header.Timestamp
+    }
+
+    return
+}
+
+//SettlementData is the extra data of a transfer settled by another application, it is signed with the transfer,
+//so the transfer is only settled on the chain, before the expiry height and by the application the signer chose.
+//an expiry height of zero means never expire.
+type SettlementData struct {
+    ChainID      string
+    ExpiryHeight uint64
+    Application  string
+}
+
+//settlementRecord is saved for an executed settlement, only the application settled it can roll it back
+//with the same undo key
+type settlementRecord struct {
+    Application string
+    UndoKey     []byte
+}
+
+func (l *Ledger) buildSettlementKey(txHash []byte) (key []byte) {
+    key = []byte(StoragePrefixes.Settlement.String())
+    key = append(key, txHash...)
+    return
+}
+
+//verifySettlementData checks the transfer is settled on this chain by the application it was signed for,
+//and is never executed before
+func (l *Ledger) verifySettlementData(tx Transaction, src string, height uint64) (err error) {
+    data := SettlementData{}
+    err = json.Unmarshal(tx.ExtraData, &data)
+    if err != nil {
+        return errors.New("invalid settlement data: " + err.Error())
+    }
+
+    if data.ChainID != l.chain.ChainID() {
+        return fmt.Errorf("settlement is not for chain [%s]", l.chain.ChainID())
+    }
+
+    if data.ExpiryHeight != 0 && height > data.ExpiryHeight {
+        return fmt.Errorf("settlement expired at height %d", data.ExpiryHeight)
+    }
+
+    if data.Application != src {
+        return fmt.Errorf("settlement is not signed for %s", src)
+    }
+
+    kv, err := l.Storage.Get(l.buildTransactionKey(tx.Seal.Hash))
+    if err != nil {
         return
     }
 
+    if kv.Exists {
+        return errors.New("transaction " + tx.HashString() + " has been executed")
+    }
+
     return
 }
 
+//VerifySettlement verifies a signed transfer the src application settles through an internal call
+func (l *Ledger) VerifySettlement(tx Transaction, src string) (err error) {
+    if tx.TxType != TransactionTypes.Transfer.String() {
+        return errors.New("only transfers can be settled")
+    }
+
+    if l.chain == nil {
+        return errors.New("no chain for settlements")
+    }
+
+    blk := l.settlementBlock()
+
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    err = l.verifySettlementData(tx, src, blk.Header.Height)
+    if err != nil {
+        return
+    }
+
+    //a settlement may be verified again with its caller, so the spent outputs are not recorded here,
+    //the caller reserves them in its block
+    return l.verifyTransaction(tx, blockLockContext(blk))
+}
+
+//ExecuteSettlement executes a signed transfer for the src application outside the transaction pool and saves it with
+//the undo key of the call as its request hash, it is rolled back by RollbackSettlement
+func (l *Ledger) ExecuteSettlement(tx Transaction, src string, undoKey []byte) (ret interface{}, blockHeight uint64, err error) {
+    if len(undoKey) == 0 {
+        err = errors.New("no undo key for the settlement")
+        return
+    }
+
+    err = l.VerifySettlement(tx, src)
+    if err != nil {
+        return
+    }
+
+    blk := l.settlementBlock()
+    ret, err = l.ExecuteTransaction(tx, blk)
+    if err != nil {
+        return
+    }
+
+    blockHeight = blk.Header.Height
+    err = l.SaveTransactionWithBlockInfo(tx, undoKey, blockHeight, 0)
+    if err != nil {
+        return
+    }
+
+    recordBytes, _ := json.Marshal(settlementRecord{
+        Application: src,
+        UndoKey:     undoKey,
+    })
+
+    err = l.Storage.Put(kvDatabase.KVItem{
+        Key:  l.buildSettlementKey(tx.Seal.Hash),
+        Data: recordBytes,
+    })
+    return
+}
+
+//RollbackSettlement rolls back a transfer settled by the src application with the undo key,
+//transfers executed in blocks or settled by other applications are not rolled back by it
+func (l *Ledger) RollbackSettlement(tx Transaction, src string, undoKey []byte) (err error) {
+    key := l.buildSettlementKey(tx.Seal.Hash)
+    kv, err := l.Storage.Get(key)
+    if err != nil {
+        return
+    }
+
+    if !kv.Exists {
+        return errors.New("transaction " + tx.HashString() + " is not a settlement")
+    }
+
+    record := settlementRecord{}
+    err = json.Unmarshal(kv.Data, &record)
+    if err != nil {
+        return
+    }
+
+    if record.Application != src || !bytes.Equal(record.UndoKey, undoKey) {
+        return errors.New("settlement " + tx.HashString() + " is not made by the call")
+    }
+
+    err = l.RollbackTransaction(tx)
+    if err != nil {
+        return
+    }
+
+    return l.Storage.Delete(key)
+}
+
+func (l *Ledger) buildTransactionUndoKey(txHash []byte) (key [] byte) {
+    key = []byte(StoragePrefixes.TransactionUndo.String())
+    key = append(key, txHash...)
+    return
+}
+
+//RollbackTransaction restores every key the transaction changed and removes the transaction record
+func (l *Ledger) RollbackTransaction(tx Transaction) (err error) {
+    l.operateLock.Lock()
+    defer l.operateLock.Unlock()
+
+    undoKey := l.buildTransactionUndoKey(tx.Seal.Hash)
+    kv, err := l.Storage.Get(undoKey)
+    if err != nil {
+        return
+    }
+
+    if !kv.Exists {
+        err = errors.New("no undo record for transaction " + tx.HashString())
+        return
+    }
+
+    var preImages []kvDatabase.KVItem
+    err = json.Unmarshal(kv.Data, &preImages)
+    if err != nil {
+        return
+    }
+
+    err = l.rollbackSwapLeg(tx)
+    if err != nil {
+        return
+    }
+
+    err = l.journal.Undo(preImages)
+    if err != nil {
+        return
+    }
+
+    return l.Storage.BatchDelete([][]byte{undoKey, l.buildTransactionKey(tx.Seal.Hash)})
+}
+
 func (l *Ledger) GetOriginalTransactionWithBlockInfo(hash []byte) (tx TransactionWithBlockInfo, err error) {
     l.operateLock.Lock()
     defer l.operateLock.Unlock()
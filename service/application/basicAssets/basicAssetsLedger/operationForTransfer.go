@@ -22,20 +22,37 @@ import (
     "fmt"
 )
 
-func (l *Ledger) verifyTransfer(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) verifyTransfer(tx Transaction, ctx lockContext) (ret interface{}, err error) {
     unspentList, totalIn, err := l.getUnspentListFromTransaction(tx)
 
     if err != nil {
         return
     }
 
+    for i, unspent := range unspentList {
+        err = l.verifyUnlock(tx, tx.Input[i], unspent, ctx)
+        if err != nil {
+            return
+        }
+    }
+
     //verify transfer input is equal to output
-    var totalOut uint64 = 0
+    var outValues []string
     for _, output := range tx.Output {
-        totalOut += output.Value
+        err = output.Lock.validate()
+        if err != nil {
+            return
+        }
+
+        outValues = append(outValues, output.Value)
+    }
+
+    totalOut, err := sumAmounts(outValues...)
+    if err != nil {
+        return
     }
 
-    if totalIn != totalOut {
+    if totalIn.Cmp(totalOut) != 0 {
         err = errors.New("input not equal output")
         return
     }
@@ -44,6 +61,34 @@ func (l *Ledger) verifyTransfer(tx Transaction) (ret interface{}, err error) {
     return
 }
 
+//spendsInputs tells if the inputs of the transaction type are outputs tracked by the double spent cache
+func spendsInputs(txType string) bool {
+    switch txType {
+    case TransactionTypes.Transfer.String(),
+        TransactionTypes.PlaceOrder.String(),
+        TransactionTypes.Burn.String(),
+        TransactionTypes.Clawback.String(),
+        TransactionTypes.Swap.String(),
+        TransactionTypes.BatchTransfer.String():
+        return true
+    default:
+        return false
+    }
+}
+
+//inputUnspentList gets the outputs spent by the transaction for the double spent check
+func (l *Ledger) inputUnspentList(tx Transaction) (list []Unspent) {
+    if tx.TxType == TransactionTypes.Clawback.String() {
+        list, _, _ = l.clawbackUnspentList(tx)
+    } else if tx.TxType == TransactionTypes.BatchTransfer.String() {
+        list, _ = l.batchTransferUnspentList(tx)
+    } else {
+        list, _, _ = l.getUnspentListFromTransaction(tx)
+    }
+
+    return
+}
+
 func (l *Ledger) doubleSpentCheck(usList []Unspent, cachePool map[string] bool) (err error) {
     for _, utxo := range usList {
         key := string(utxo.Transaction) + fmt.Sprintf("%d", utxo.OutputIndex)
@@ -66,7 +111,7 @@ func (l *Ledger)updateDoubleSpentCache(usList []Unspent)  {
     }
 }
 
-func (l *Ledger) confirmTransfer(tx Transaction) (ret interface{}, err error) {
+func (l *Ledger) confirmTransfer(tx Transaction, _ lockContext) (ret interface{}, err error) {
     l.operateLock.Lock()
     defer l.operateLock.Unlock()
 
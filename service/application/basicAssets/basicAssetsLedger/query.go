@@ -26,6 +26,13 @@ var QueryTypes struct {
     Transaction enum.Element
     SellingList enum.Element
     Copyright   enum.Element
+    Order       enum.Element
+    OrderBook   enum.Element
+    Frozen      enum.Element
+
+    BuildTransfer enum.Element
+
+    AccountStatement enum.Element
 }
 
 type AssetsList struct {
@@ -46,6 +53,48 @@ type UnspentQueryParameter struct {
     Assets  []byte
 }
 
+type OrderQueryParameter struct {
+    Order []byte
+}
+
+//OrderBookQueryParameter selects the market of the depth query, Levels limits the price levels of each side, 0 means all
+type OrderBookQueryParameter struct {
+    BaseAssets  []byte
+    QuoteAssets []byte
+    Levels      int
+}
+
+type OrderBookLevel struct {
+    Price  string
+    Amount string
+    Orders int
+}
+
+type OrderBookDepth struct {
+    BaseAssets  []byte
+    QuoteAssets []byte
+    Bids        []OrderBookLevel
+    Asks        []OrderBookLevel
+}
+
+type FrozenQueryParameter struct {
+    Assets  []byte
+    Address []byte
+}
+
+//AccountStatementParameter selects the account and the range of the statement, the range is a time range of unix
+//seconds if FromTime or ToTime is set, otherwise a height range, 0 of the end of a range means no end.
+//Format is one of StatementFormats, the default is JSON
+type AccountStatementParameter struct {
+    Address    []byte
+    Assets     []byte
+    FromHeight uint64 `json:",string"`
+    ToHeight   uint64 `json:",string"`
+    FromTime   int64  `json:",string"`
+    ToTime     int64  `json:",string"`
+    Format     string
+}
+
 type QueryRequest struct {
     DBType    string
     QueryType string
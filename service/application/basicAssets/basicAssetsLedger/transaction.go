@@ -33,17 +33,50 @@ var TransactionTypes struct{
     StartSelling enum.Element
     StopSelling  enum.Element
     BuyAssets    enum.Element
+
+    PlaceOrder  enum.Element
+    CancelOrder enum.Element
+
+    Burn     enum.Element
+    Freeze   enum.Element
+    Unfreeze enum.Element
+    Clawback enum.Element
+
+    Swap enum.Element
+
+    BatchTransfer enum.Element
 }
 
 type SellingData struct {
-    Price         uint64 `json:",string"`
-    Amount        uint64 `json:",string"`
+    Price         string
+    Amount        string
     Seller        []byte
     SellingAssets []byte
     PaymentAssets []byte
     Transaction   []byte
 }
 
+//OrderData is the extra data of a PlaceOrder transaction, a bid buys Amount of the base assets with
+//the quote assets and an ask sells Amount of the base assets for the quote assets,
+//Price is the amount of quote assets for one unit of the base assets
+type OrderData struct {
+    Side        string
+    BaseAssets  []byte
+    QuoteAssets []byte
+    Price       string
+    Amount      string
+}
+
+//CancelOrderData is the extra data of a CancelOrder transaction
+type CancelOrderData struct {
+    Order []byte
+}
+
+//FreezeData is the extra data of Freeze and Unfreeze transactions
+type FreezeData struct {
+    Address []byte
+}
+
 type TransactionData struct {
     TxType  string
     Assets  Assets
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+    "github.com/SealSC/SealABC/dataStructure/enum"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "sort"
+)
+
+var CoinSelectionStrategies struct {
+    LargestFirst   enum.Element
+    BranchAndBound enum.Element
+}
+
+//branchAndBoundTries limits the search of an exact match, the largest first selection is used when no match is found
+const branchAndBoundTries = 100000
+
+//TransferBuildParameter is the parameter of the BuildTransfer query, Strategy is one of CoinSelectionStrategies
+//and the largest first selection is used when it is empty
+type TransferBuildParameter struct {
+    From     []byte
+    To       []byte
+    Assets   []byte
+    Amount   string
+    Memo     string
+    Strategy string
+}
+
+//TransferBuildResult holds the unsigned transfer, BytesToSign is the data the seal of the transaction signs
+//and Hash is its hash for the signer of the ledger
+type TransferBuildResult struct {
+    TransactionData TransactionData
+    BytesToSign     []byte
+    Hash            []byte
+}
+
+type selectableUnspent struct {
+    unspent Unspent
+    value   *big.Int
+}
+
+//selectableUnspentList gets the outputs of the address which can be spent by a plain transfer, outputs with
+//locks and outputs used by transactions in the pool are skipped, sorted from the largest.
+//the pool records are changed under operateLock, so the caller must hold it for reading
+func (l *Ledger) selectableUnspentList(address []byte, assets []byte) (list []selectableUnspent, err error) {
+    if l.isFrozen(assets, address) {
+        return nil, errors.New("address is frozen for the assets")
+    }
+
+    kvList := l.Storage.Traversal(l.buildUnspentQueryPrefix(address, assets))
+    for _, kv := range kvList {
+        u := Unspent{}
+        if json.Unmarshal(kv.Data, &u) != nil || u.Lock.IsLocked() {
+            continue
+        }
+
+        if l.memUTXORecord[string(u.Transaction) + fmt.Sprintf("%d", u.OutputIndex)] {
+            continue
+        }
+
+        value, parseErr := ParseAmount(u.Value)
+        if parseErr != nil || value.Sign() == 0 {
+            continue
+        }
+
+        list = append(list, selectableUnspent{unspent: u, value: value})
+    }
+
+    sort.SliceStable(list, func(i, j int) bool {
+        return list[i].value.Cmp(list[j].value) > 0
+    })
+    return
+}
+
+func selectLargestFirst(list []selectableUnspent, target *big.Int) (selected []selectableUnspent, total *big.Int) {
+    total = big.NewInt(0)
+    for _, u := range list {
+        if total.Cmp(target) >= 0 {
+            break
+        }
+
+        selected = append(selected, u)
+        total.Add(total, u.value)
+    }
+
+    return
+}
+
+//selectBranchAndBound searches a set of outputs which is exactly the target so no change is needed,
+//the list must be sorted from the largest
+func selectBranchAndBound(list []selectableUnspent, target *big.Int) (selected []selectableUnspent, found bool) {
+    //remains[i] is the total of the outputs from i to the end, a branch is cut when they can't reach the target
+    remains := make([]*big.Int, len(list) + 1)
+    remains[len(list)] = big.NewInt(0)
+    for i := len(list) - 1; i >= 0; i-- {
+        remains[i] = big.NewInt(0).Add(remains[i + 1], list[i].value)
+    }
+
+    tries := 0
+    var picked []int
+    var search func(idx int, total *big.Int) bool
+    search = func(idx int, total *big.Int) bool {
+        tries += 1
+        if tries > branchAndBoundTries {
+            return false
+        }
+
+        cmp := total.Cmp(target)
+        if cmp == 0 {
+            return true
+        }
+
+        if cmp > 0 || idx == len(list) || big.NewInt(0).Add(total, remains[idx]).Cmp(target) < 0 {
+            return false
+        }
+
+        picked = append(picked, idx)
+        if search(idx + 1, big.NewInt(0).Add(total, list[idx].value)) {
+            return true
+        }
+        picked = picked[:len(picked) - 1]
+
+        return search(idx + 1, total)
+    }
+
+    if !search(0, big.NewInt(0)) {
+        return nil, false
+    }
+
+    for _, idx := range picked {
+        selected = append(selected, list[idx])
+    }
+    return selected, true
+}
+
+//buildTransfer runs with operateLock held for reading, it is only called by the BuildTransfer query and DoQuery takes
+//the lock for every query, taking it again here could deadlock with a waiting writer
+func (l *Ledger) buildTransfer(param TransferBuildParameter) (result TransferBuildResult, err error) {
+    if len(param.From) == 0 || len(param.To) == 0 {
+        return result, errors.New("no from or to address")
+    }
+
+    amount, err := ParseAmount(param.Amount)
+    if err != nil {
+        return
+    }
+
+    if amount.Sign() == 0 {
+        return result, errors.New("invalid amount")
+    }
+
+    assets, err := l.localAssetsFromHash(param.Assets)
+    if err != nil {
+        return
+    }
+
+    list, err := l.selectableUnspentList(param.From, param.Assets)
+    if err != nil {
+        return
+    }
+
+    var selected []selectableUnspent
+    var found bool
+    switch param.Strategy {
+    case CoinSelectionStrategies.BranchAndBound.String():
+        selected, found = selectBranchAndBound(list, amount)
+    case "", CoinSelectionStrategies.LargestFirst.String():
+    default:
+        return result, errors.New("unsupported coin selection strategy: " + param.Strategy)
+    }
+
+    total := big.NewInt(0).Set(amount)
+    if !found {
+        selected, total = selectLargestFirst(list, amount)
+    }
+
+    change, err := subAmount(total, amount)
+    if err != nil {
+        return result, errors.New("insufficient balance")
+    }
+
+    txData := TransactionData{
+        TxType: TransactionTypes.Transfer.String(),
+        Assets: assets,
+        Memo:   param.Memo,
+    }
+
+    for _, s := range selected {
+        txData.Input = append(txData.Input, UTXOInput{
+            Transaction: s.unspent.Transaction,
+            OutputIndex: s.unspent.OutputIndex,
+        })
+    }
+
+    txData.Output = append(txData.Output, UTXOOutput{
+        To:    param.To,
+        Value: amount.String(),
+    })
+
+    if change.Sign() > 0 {
+        txData.Output = append(txData.Output, UTXOOutput{
+            To:    param.From,
+            Value: change.String(),
+        })
+    }
+
+    result.TransactionData = txData
+    result.BytesToSign, err = structSerializer.ToMFBytes(txData)
+    if err != nil {
+        return
+    }
+
+    result.Hash = l.CryptoTools.HashCalculator.Sum(result.BytesToSign)
+    return
+}
+
+func (l *Ledger) queryBuildTransfer(p []string) (result interface{}, err error) {
+    param := TransferBuildParameter{}
+    err = json.Unmarshal([]byte(p[0]), &param)
+    if err != nil {
+        return
+    }
+
+    return l.buildTransfer(param)
+}
@@ -19,19 +19,26 @@ package basicAssetsLedger
 
 import (
     "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "bytes"
     "encoding/binary"
     "encoding/json"
     "errors"
+    "math/big"
 )
 
 type UTXOInput struct {
     Transaction  []byte
     OutputIndex  uint64 `json:",string"`
+
+    //owner of the output, only needed when the output is spent by its lock owners instead of the signer
+    Owner        []byte
+    Witness      UTXOWitness
 }
 
 type UTXOOutput struct {
     To      []byte
-    Value   uint64 `json:",string"`
+    Value   string
+    Lock    OutputLock
 }
 
 type Unspent struct {
@@ -40,13 +47,17 @@ type Unspent struct {
     Transaction []byte
     Singer      []byte
     OutputIndex uint64 `json:",string"`
-    Value       uint64 `json:",string"`
+    Value       string
+    Lock        OutputLock
+
+    //time of the block the output was created in, relative time locks count from it
+    CreateTime  uint64 `json:",string"`
 }
 
 type Balance struct {
     Address []byte
     Assets  Assets
-    Amount  uint64
+    Amount  string
 }
 
 type UnspentListWithBalance struct {
@@ -119,20 +130,48 @@ func (l *Ledger) getUnspent(key []byte) (unspent Unspent, err error) {
     return
 }
 
-func (l *Ledger) getUnspentListFromTransaction(tx Transaction) (list []Unspent, amount uint64, err error) {
-    amount = 0
+//getUnspentListFromTransaction gets the outputs spent by the inputs, only the outputs of the signer
+//or the outputs with lock owners can be spent, the locks are checked by verifyUnlock,
+//outputs of the addresses frozen by the issuer can't be spent
+func (l *Ledger) getUnspentListFromTransaction(tx Transaction) (list []Unspent, amount *big.Int, err error) {
+    amount = big.NewInt(0)
     for _, ref := range tx.Input {
-        key := l.buildUnspentStorageKey(tx.Seal.SignerPublicKey, tx.Assets.getUniqueHash(), ref.Transaction, ref.OutputIndex)
+        owner := ref.Owner
+        if len(owner) == 0 {
+            owner = tx.Seal.SignerPublicKey
+        }
+
+        key := l.buildUnspentStorageKey(owner, tx.Assets.getUniqueHash(), ref.Transaction, ref.OutputIndex)
         unspent, dbErr := l.getUnspent(key)
         if dbErr != nil {
             err = errors.New("get Unspent failed: " + dbErr.Error())
             break
         }
 
-        amount += unspent.Value
+        if !bytes.Equal(owner, tx.Seal.SignerPublicKey) && !unspent.Lock.isMultiSig() {
+            err = errors.New("output is not owned by the signer")
+            break
+        }
+
+        value, parseErr := ParseAmount(unspent.Value)
+        if parseErr != nil {
+            err = parseErr
+            break
+        }
+
+        amount, err = addAmount(amount, value)
+        if err != nil {
+            break
+        }
+
         list = append(list, unspent)
     }
 
+    if err != nil {
+        return
+    }
+
+    err = l.requireNotFrozen(list)
     return
 }
 
@@ -147,33 +186,31 @@ func (l *Ledger) deleteUnspent(in []Unspent) (err error) {
     return
 }
 
-func (l *Ledger) storeBalance(key []byte, change uint64, isIncrease bool) (amount uint64, err error) {
+//storeBalance keeps the balance as big endian bytes, the 8 bytes balances of the former uint64 ledger are read as they are
+func (l *Ledger) storeBalance(key []byte, change *big.Int, isIncrease bool) (amount *big.Int, err error) {
     bKV, err :=l.Storage.Get(key)
     if err != nil || !bKV.Exists {
         if !isIncrease {
             err = errors.New("invalid address")
             return
         }
-        amount = change
+        amount = big.NewInt(0).Set(change)
     } else {
-        current := binary.BigEndian.Uint64(bKV.Data)
+        current := big.NewInt(0).SetBytes(bKV.Data)
         if isIncrease {
-            amount = current + change
+            amount, err = addAmount(current, change)
         } else {
-            if current < change {
-                err = errors.New("reduce must <= current")
-                return
-            }
+            amount, err = subAmount(current, change)
+        }
 
-            amount = current - change
+        if err != nil {
+            return
         }
     }
 
-    varBytes := make([]byte, 8)
-    binary.BigEndian.PutUint64(varBytes, amount)
     kv := kvDatabase.KVItem{
         Key:    key,
-        Data:   varBytes,
+        Data:   amount.Bytes(),
     }
 
     err = l.Storage.Put(kv)
@@ -181,13 +218,18 @@ func (l *Ledger) storeBalance(key []byte, change uint64, isIncrease bool) (amoun
     return
 }
 
-func (l *Ledger) updateBalance(address []byte, assets Assets, change uint64, isIncrease bool) (amount uint64, err error) {
+func (l *Ledger) updateBalance(address []byte, assets Assets, change *big.Int, isIncrease bool) (amount *big.Int, err error) {
     addressKey, assetsKey := l.buildBalanceKey(address, assets)
     amount, err = l.storeBalance(addressKey, change, isIncrease)
     if err != nil {
         return
     }
 
+    err = l.recordAccountHistory(address, assets, change, isIncrease, amount)
+    if err != nil {
+        return
+    }
+
     _, err = l.storeBalance(assetsKey, change, isIncrease)
     if err != nil {
         return
@@ -200,25 +242,40 @@ func (l *Ledger) updateBalance(address []byte, assets Assets, change uint64, isI
 type balanceDataInTx struct {
     address     []byte
     assets      Assets
-    val         uint64
+    val         *big.Int
     isIncrease  bool
 }
 func (l *Ledger) saveUnspent(localAssets Assets, tx Transaction, in []Unspent) (list UnspentListWithBalance, err error) {
+    return l.saveUnspentFrom(localAssets, tx, in, 0)
+}
+
+//saveUnspentFrom saves the outputs of the transaction from the output index firstIndex, the legs of a batch transfer
+//share the transaction hash and number their outputs after the outputs of the legs before them
+func (l *Ledger) saveUnspentFrom(localAssets Assets, tx Transaction, in []Unspent, firstIndex uint64) (list UnspentListWithBalance, err error) {
     var unspentList []kvDatabase.KVItem
 
     balanceIncreaseList := map[string] *balanceDataInTx{}
     assetsHash := localAssets.getUniqueHash()
 
     for idx, output := range tx.Output {
-        key := l.buildUnspentStorageKey(output.To, assetsHash, tx.Seal.Hash, uint64(idx))
+        value, parseErr := ParseAmount(output.Value)
+        if parseErr != nil {
+            err = parseErr
+            return
+        }
+
+        outputIdx := firstIndex + uint64(idx)
+        key := l.buildUnspentStorageKey(output.To, assetsHash, tx.Seal.Hash, outputIdx)
 
         u := Unspent{
             Owner:          output.To,
             AssetsHash:     assetsHash,
             Transaction:    tx.Seal.Hash,
-            OutputIndex:    uint64(idx),
+            OutputIndex:    outputIdx,
             Singer:         tx.Seal.SignerPublicKey,
             Value:          output.Value,
+            Lock:           output.Lock,
+            CreateTime:     l.historyContext.timestamp,
         }
         data, _ := json.Marshal(u)
 
@@ -229,12 +286,15 @@ func (l *Ledger) saveUnspent(localAssets Assets, tx Transaction, in []Unspent) (
 
         bKey := string(output.To) + string(assetsHash)
         if _, exist := balanceIncreaseList[bKey]; exist {
-            balanceIncreaseList[bKey].val += output.Value
+            balanceIncreaseList[bKey].val, err = addAmount(balanceIncreaseList[bKey].val, value)
+            if err != nil {
+                return
+            }
         } else {
             balanceIncreaseList[bKey] = &balanceDataInTx{
                 address: output.To,
                 assets: localAssets,
-                val: output.Value,
+                val: value,
                 isIncrease: true,
             }
         }
@@ -252,14 +312,23 @@ func (l *Ledger) saveUnspent(localAssets Assets, tx Transaction, in []Unspent) (
 
     balanceReduceList := map[string] *balanceDataInTx{}
     for _, i := range in {
+        value, parseErr := ParseAmount(i.Value)
+        if parseErr != nil {
+            err = parseErr
+            return
+        }
+
         bKey := string(i.Owner) + string(i.AssetsHash)
         if _, exist := balanceReduceList[bKey]; exist {
-            balanceReduceList[bKey].val += i.Value
+            balanceReduceList[bKey].val, err = addAmount(balanceReduceList[bKey].val, value)
+            if err != nil {
+                return
+            }
         } else {
             balanceReduceList[bKey] = &balanceDataInTx{
                 address: i.Owner,
                 assets: localAssets,
-                val: i.Value,
+                val: value,
                 isIncrease: false,
             }
         }
@@ -274,7 +343,7 @@ func (l *Ledger) saveUnspent(localAssets Assets, tx Transaction, in []Unspent) (
         balanceList = append(balanceList, Balance {
             Address: b.address,
             Assets:  localAssets,
-            Amount:  amount,
+            Amount:  amount.String(),
         })
     }
 
@@ -286,7 +355,7 @@ func (l *Ledger) saveUnspent(localAssets Assets, tx Transaction, in []Unspent) (
         balanceList = append(balanceList, Balance{
             Address: b.address,
             Assets:  localAssets,
-            Amount:  amount,
+            Amount:  amount.String(),
         })
     }
 
@@ -302,6 +371,7 @@ func (l *Ledger) saveUnspentInsideIssueAssetsTransaction(tx Transaction) (balanc
         Transaction:    tx.Seal.Hash,
         OutputIndex:    uint64(0),
         Value:          tx.Assets.Supply,
+        CreateTime:     l.historyContext.timestamp,
     }
 
     key := l.buildUnspentStorageKey(tx.Assets.IssuedSeal.SignerPublicKey, tx.Assets.getUniqueHash(), tx.Seal.Hash, uint64(0))
@@ -316,12 +386,20 @@ func (l *Ledger) saveUnspentInsideIssueAssetsTransaction(tx Transaction) (balanc
         return
     }
 
-    amount, err := l.updateBalance(u.Owner, tx.Assets, u.Value, true)
+    supply, err := ParseAmount(u.Value)
+    if err != nil {
+        return
+    }
+
+    amount, err := l.updateBalance(u.Owner, tx.Assets, supply, true)
+    if err != nil {
+        return
+    }
 
     balance = Balance{
         Address: u.Owner,
         Assets:  tx.Assets,
-        Amount:  amount,
+        Amount:  amount.String(),
     }
     return
 }
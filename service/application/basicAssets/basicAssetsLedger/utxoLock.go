@@ -0,0 +1,255 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsLedger
+
+import (
+    "github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+    "github.com/SealSC/SealABC/metadata/block"
+    "github.com/SealSC/SealABC/metadata/seal"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+    "bytes"
+    "crypto/sha256"
+    "errors"
+    "fmt"
+    "time"
+)
+
+//OutputLock sets the conditions to spend an output besides the key of its owner, all the set conditions must be met.
+//an output with lock owners is spent by the signatures of Threshold owners in the witness of the input,
+//To of such an output only names the shared account, and the input gives it as Owner.
+type OutputLock struct {
+    Owners    [][]byte
+    Threshold uint64 `json:",string"`
+
+    //absolute locks, the output can't be spent before the block height or the unix time
+    Height uint64 `json:",string"`
+    Time   uint64 `json:",string"`
+
+    //relative locks, counted from the block which created the output
+    RelativeHeight uint64 `json:",string"`
+    RelativeTime   uint64 `json:",string"`
+
+    //sha256 hash of the secret the witness of the input must reveal
+    HashLock []byte
+}
+
+//UTXOWitness unlocks a locked output, every signature is a seal of the witness data of the transaction
+type UTXOWitness struct {
+    Signatures []seal.Entity
+    Preimage   []byte
+}
+
+//lockContext is the block a transaction is verified for
+type lockContext struct {
+    height    uint64
+    timestamp uint64
+}
+
+func (o OutputLock) isMultiSig() bool {
+    return len(o.Owners) != 0
+}
+
+func (o OutputLock) IsLocked() bool {
+    return o.isMultiSig() ||
+        o.Height != 0 || o.Time != 0 ||
+        o.RelativeHeight != 0 || o.RelativeTime != 0 ||
+        len(o.HashLock) != 0
+}
+
+func (o OutputLock) validate() error {
+    if len(o.HashLock) != 0 && len(o.HashLock) != sha256.Size {
+        return errors.New("hash lock must be a sha256 hash")
+    }
+
+    if !o.isMultiSig() {
+        if o.Threshold != 0 {
+            return errors.New("threshold without lock owners")
+        }
+        return nil
+    }
+
+    if o.Threshold == 0 || o.Threshold > uint64(len(o.Owners)) {
+        return errors.New("threshold must be between one and the count of lock owners")
+    }
+
+    owners := map[string] bool{}
+    for _, owner := range o.Owners {
+        if owners[string(owner)] {
+            return errors.New("duplicate lock owner")
+        }
+        owners[string(owner)] = true
+    }
+
+    return nil
+}
+
+//WitnessData is the data signed by the lock owners, it's the transaction data without the witnesses of the inputs
+func (t *Transaction) WitnessData() []byte {
+    data := t.TransactionData
+    data.Input = make([]UTXOInput, len(t.Input))
+    for i, in := range t.Input {
+        in.Witness = UTXOWitness{}
+        data.Input[i] = in
+    }
+
+    dataBytes, _ := structSerializer.ToMFBytes(data)
+    return dataBytes
+}
+
+func (l *Ledger) SetChain(chain chainStructure.IChainInterface) {
+    l.chain = chain
+}
+
+//nextBlockContext is the lock context for the transactions pushed to the pool, they are packed in the next block
+func (l *Ledger) nextBlockContext() lockContext {
+    ctx := lockContext{
+        timestamp: uint64(time.Now().Unix()),
+    }
+
+    if l.chain != nil {
+        ctx.height = l.chain.CurrentHeight() + 1
+    }
+
+    return ctx
+}
+
+//blockLockContext is the lock context for the transactions verified for or executed in the block
+func blockLockContext(blk block.Entity) lockContext {
+    return lockContext{
+        height:    blk.Header.Height,
+        timestamp: blk.Header.Timestamp,
+    }
+}
+
+func (l *Ledger) verifyLockSignatures(witnessData []byte, witness UTXOWitness, lock OutputLock) error {
+    owners := map[string] bool{}
+    for _, owner := range lock.Owners {
+        owners[string(owner)] = true
+    }
+
+    signed := map[string] bool{}
+    for _, s := range witness.Signatures {
+        key := string(s.SignerPublicKey)
+        if !owners[key] || signed[key] {
+            continue
+        }
+
+        if passed, _ := s.Verify(witnessData, l.CryptoTools.HashCalculator); passed {
+            signed[key] = true
+        }
+    }
+
+    if uint64(len(signed)) < lock.Threshold {
+        return fmt.Errorf("%d of %d lock owners signed", len(signed), lock.Threshold)
+    }
+
+    return nil
+}
+
+func (l *Ledger) verifyTimeLocks(unspent Unspent, ctx lockContext) error {
+    lock := unspent.Lock
+
+    if lock.Height > ctx.height {
+        return fmt.Errorf("output is locked until height %d", lock.Height)
+    }
+
+    if lock.Time > ctx.timestamp {
+        return fmt.Errorf("output is locked until time %d", lock.Time)
+    }
+
+    if lock.RelativeHeight == 0 && lock.RelativeTime == 0 {
+        return nil
+    }
+
+    created, err := l.getLocalTransaction(unspent.Transaction)
+    if err != nil {
+        return err
+    }
+
+    createdHeight := created.BlockInfo.BlockHeight
+    if createdHeight + lock.RelativeHeight > ctx.height {
+        return fmt.Errorf("output is locked until height %d", createdHeight + lock.RelativeHeight)
+    }
+
+    if lock.RelativeTime == 0 {
+        return nil
+    }
+
+    createTime, err := l.outputCreateTime(unspent, createdHeight)
+    if err != nil {
+        return err
+    }
+
+    if createTime + lock.RelativeTime > ctx.timestamp {
+        return fmt.Errorf("output is locked until time %d", createTime + lock.RelativeTime)
+    }
+
+    return nil
+}
+
+//outputCreateTime gets the time of the block the output was created in, outputs saved before the time was kept
+//in them get it from the block, which may have been pruned
+func (l *Ledger) outputCreateTime(unspent Unspent, createdHeight uint64) (uint64, error) {
+    if unspent.CreateTime != 0 {
+        return unspent.CreateTime, nil
+    }
+
+    if l.chain == nil {
+        return 0, errors.New("no chain to get the time of the output")
+    }
+
+    blk, err := l.chain.GetBlockByHeight(createdHeight)
+    if err != nil {
+        return 0, err
+    }
+
+    return blk.Header.Timestamp, nil
+}
+
+//verifyUnlock checks the input meets the lock of the output it spends in the block of the lock context
+func (l *Ledger) verifyUnlock(tx Transaction, in UTXOInput, unspent Unspent, ctx lockContext) (err error) {
+    lock := unspent.Lock
+
+    if lock.isMultiSig() {
+        err = l.verifyLockSignatures(tx.WitnessData(), in.Witness, lock)
+    } else if !bytes.Equal(unspent.Owner, tx.Seal.SignerPublicKey) {
+        err = errors.New("output is not owned by the signer")
+    }
+
+    if err != nil {
+        return
+    }
+
+    if len(lock.HashLock) != 0 {
+        secretHash := sha256.Sum256(in.Witness.Preimage)
+        if !bytes.Equal(secretHash[:], lock.HashLock) {
+            return errors.New("preimage not matches the hash lock")
+        }
+    }
+
+    return l.verifyTimeLocks(unspent, ctx)
+}
+
+func requireUnlocked(list []Unspent) error {
+    for _, u := range list {
+        if u.Lock.IsLocked() {
+            return errors.New("locked output can only be spent by transfer")
+        }
+    }
+
+    return nil
+}
@@ -100,6 +100,61 @@ func (s *Storage) StoreUnspent(tx basicAssetsLedger.TransactionWithBlockInfo, in
     return
 }
 
+//StoreBatchTransfer unpacks the legs of a batch transfer to a transfer record for every recipient
+func (s *Storage) StoreBatchTransfer(tx basicAssetsLedger.TransactionWithBlockInfo, legs []basicAssetsLedger.BatchTransferLegResult) (err error) {
+    transfersRows := basicAssetsSQLTables.Transfers.NewRows().(basicAssetsSQLTables.TransfersRows)
+    addressRecordRows := basicAssetsSQLTables.AddressRecord.NewRows().(basicAssetsSQLTables.AddressRecordRows)
+    addressListRows := basicAssetsSQLTables.AddressList.NewRows().(basicAssetsSQLTables.AddressListRows)
+    addrCache := map[string] bool {}
+
+    for _, leg := range legs {
+        legTx := tx
+        legTx.Transaction = leg.Transaction
+
+        assetsHash := leg.Transaction.Assets.MetaSeal.Hash
+        for _, out := range leg.Transaction.Output {
+            transfersRows.InsertTransferByDetail(legTx, assetsHash, leg.UnspentList, []basicAssetsLedger.UTXOOutput{out})
+        }
+
+        addressRecordRows.InsertAddressesInTransfer(legTx, leg.UnspentList)
+
+        var addresses []string
+        for _, out := range leg.Transaction.Output {
+            addresses = append(addresses, hex.EncodeToString(out.To))
+        }
+
+        for _, in := range leg.UnspentList {
+            addresses = append(addresses, hex.EncodeToString(in.Owner))
+        }
+
+        for _, addr := range addresses {
+            if addrCache[addr] {
+                continue
+            }
+
+            addrCache[addr] = true
+            addressListRows.InsertAddress(legTx, addr)
+        }
+    }
+
+    _, err = s.Driver.Insert(&transfersRows, true)
+    if err != nil {
+        log.Log.Error("insert batch transfer to sql database failed: ", err.Error())
+    }
+
+    _, err = s.Driver.Insert(&addressRecordRows, true)
+    if err != nil {
+        log.Log.Error("insert address record to sql database failed: ", err.Error())
+    }
+
+    _, err = s.Driver.Insert(&addressListRows, true)
+    if err != nil {
+        log.Log.Error("insert address to sql database failed: ", err.Error())
+    }
+
+    return
+}
+
 func (s *Storage) StoreBalance(height uint64, tm int64, balanceList []basicAssetsLedger.Balance) (err error) {
     rows := basicAssetsSQLTables.Balance.NewRows().(basicAssetsSQLTables.BalanceRows)
     rows.InsertBalances(height, tm, balanceList)
@@ -188,3 +243,65 @@ func (s *Storage) StoreSelling(tx basicAssetsLedger.TransactionWithBlockInfo, re
 
     return
 }
+
+func (s *Storage) StoreOrder(tx basicAssetsLedger.TransactionWithBlockInfo, result basicAssetsLedger.OrderOperationResult) (err error) {
+    //store transfers of every assets settled by the order transaction
+    transferRows := basicAssetsSQLTables.Transfers.NewRows().(basicAssetsSQLTables.TransfersRows)
+    for _, settlement := range result.Settlements {
+        transferRows.InsertTransferByDetail(tx, settlement.Assets, settlement.Input, settlement.Output)
+    }
+
+    _, err = s.Driver.Insert(&transferRows, false)
+    if err != nil {
+        log.Log.Warn("insert transfers in order transaction failed: ", err.Error())
+    }
+
+    //the placed order is a new row of the selling list, the changed orders are updated
+    if tx.TxType == basicAssetsLedger.TransactionTypes.PlaceOrder.String() {
+        rows := basicAssetsSQLTables.SellingList.NewRows().(basicAssetsSQLTables.SellingListRows)
+        rows.InsertOrderRow(tx, result.Order)
+        _, err = s.Driver.Insert(&rows, false)
+        if err != nil {
+            log.Log.Warn("insert order failed: ", err.Error())
+        }
+    } else {
+        result.Makers = append(result.Makers, result.Order)
+    }
+
+    for _, order := range result.Makers {
+        rows := basicAssetsSQLTables.SellingList.NewRows().(basicAssetsSQLTables.SellingListRows)
+        rows.InsertOrderRow(tx, order)
+
+        fields, condition := rows.GetOrderUpdateInfo()
+        _, err = s.Driver.Update(&rows, fields, condition, []interface{}{hex.EncodeToString(order.ID)})
+        if err != nil {
+            log.Log.Warn("update order failed: ", err.Error())
+        }
+    }
+
+    return
+}
+
+func (s *Storage) StoreAssetsAction(tx basicAssetsLedger.TransactionWithBlockInfo, result basicAssetsLedger.AssetsActionResult) (err error) {
+    //burn and clawback move outputs like a transfer, freeze and unfreeze only have the action record
+    if len(result.UnspentList) > 0 {
+        err = s.StoreUnspent(tx, result.UnspentList)
+        if err != nil {
+            log.Log.Warn("store unspent of assets action failed: ", err.Error())
+        }
+
+        err = s.StoreBalance(tx.BlockInfo.BlockHeight, tx.CreateTime, result.BalanceList)
+        if err != nil {
+            log.Log.Warn("store balance of assets action failed: ", err.Error())
+        }
+    }
+
+    rows := basicAssetsSQLTables.AssetsActions.NewRows().(basicAssetsSQLTables.AssetsActionsRows)
+    rows.InsertAction(tx, result)
+    _, err = s.Driver.Insert(&rows, false)
+    if err != nil {
+        log.Log.Error("insert assets action to sql database failed: ", err.Error())
+    }
+
+    return
+}
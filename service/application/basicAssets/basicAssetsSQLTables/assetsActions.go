@@ -0,0 +1,95 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package basicAssetsSQLTables
+
+import (
+    "github.com/SealSC/SealABC/common"
+    "github.com/SealSC/SealABC/dataStructure/enum"
+    "github.com/SealSC/SealABC/service/application/basicAssets/basicAssetsLedger"
+    "github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+    "encoding/hex"
+    "fmt"
+    "time"
+)
+
+type AssetsActionsTable struct {
+    ID       enum.Element `col:"c_id" ignoreInsert:"true"`
+    Height   enum.Element `col:"c_height"`
+    ReqHash  enum.Element `col:"c_req_hash"`
+    TxHash   enum.Element `col:"c_tx_hash"`
+    Assets   enum.Element `col:"c_assets"`
+    Action   enum.Element `col:"c_action"`
+    Operator enum.Element `col:"c_operator"`
+    Target   enum.Element `col:"c_target"`
+    Amount   enum.Element `col:"c_amount"`
+    Time     enum.Element `col:"c_time"`
+
+    simpleSQLDatabase.BasicTable
+}
+
+var AssetsActions AssetsActionsTable
+
+func (a AssetsActionsTable) Name() (name string) {
+    return "t_basic_assets_actions"
+}
+
+func (a AssetsActionsTable) NewRows() interface{} {
+    return simpleSQLDatabase.NewRowsInstance(AssetsActionsRows{})
+}
+
+func (a *AssetsActionsTable) load() {
+    enum.SimpleBuild(a)
+    a.Instance = *a
+}
+
+type AssetsActionsRow struct {
+    ID       string
+    Height   string
+    ReqHash  string
+    TxHash   string
+    Assets   string
+    Action   string
+    Operator string
+    Target   string
+    Amount   string
+    Time     string
+}
+
+type AssetsActionsRows struct {
+    simpleSQLDatabase.BasicRows
+}
+
+func (a *AssetsActionsRows) InsertAction(tx basicAssetsLedger.TransactionWithBlockInfo, result basicAssetsLedger.AssetsActionResult) {
+    timestamp := time.Unix(tx.CreateTime, 0)
+    newRow := AssetsActionsRow{
+        Height:   fmt.Sprintf("%d", tx.BlockInfo.BlockHeight),
+        ReqHash:  hex.EncodeToString(tx.BlockInfo.RequestHash),
+        TxHash:   hex.EncodeToString(tx.Seal.Hash),
+        Assets:   hex.EncodeToString(result.Assets),
+        Action:   tx.TxType,
+        Operator: hex.EncodeToString(tx.Seal.SignerPublicKey),
+        Target:   hex.EncodeToString(result.Target),
+        Amount:   result.Amount,
+        Time:     timestamp.Format(common.BASIC_TIME_FORMAT),
+    }
+
+    a.Rows = append(a.Rows, newRow)
+}
+
+func (a *AssetsActionsRows) Table() simpleSQLDatabase.ITable {
+    return &AssetsActions
+}
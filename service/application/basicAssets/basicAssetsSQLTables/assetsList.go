@@ -42,6 +42,7 @@ type AssetsListTable struct {
     IssuedSignature enum.Element `col:"c_issued_signature"`
     IssueTo         enum.Element `col:"c_issue_to"`
     Time            enum.Element `col:"c_time"`
+    Decimals        enum.Element `col:"c_decimals"`
 
     simpleSQLDatabase.BasicTable
 }
@@ -77,6 +78,7 @@ type AssetsListRow struct {
     IssuedSignature string
     IssueTo         string
     Time            string
+    Decimals        string
 }
 
 func (b *AssetsListRow) FromTransaction(tx basicAssetsLedger.TransactionWithBlockInfo)  {
@@ -85,7 +87,8 @@ func (b *AssetsListRow) FromTransaction(tx basicAssetsLedger.TransactionWithBloc
     b.TxHash = hex.EncodeToString(tx.Seal.Hash)
     b.AssetsName = tx.Assets.Name
     b.AssetsSymbol = tx.Assets.Symbol
-    b.Supply = fmt.Sprintf("%d", tx.Assets.Supply)
+    b.Supply = tx.Assets.Supply
+    b.Decimals = fmt.Sprintf("%d", tx.Assets.Decimals)
     if tx.Assets.Increasable {
         b.Increasable = "1"
     } else {
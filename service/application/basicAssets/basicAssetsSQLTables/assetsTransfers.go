@@ -25,6 +25,7 @@ import (
     "encoding/hex"
     "encoding/json"
     "fmt"
+    "math/big"
     "time"
 )
 
@@ -78,9 +79,35 @@ type TransfersRows struct {
     simpleSQLDatabase.BasicRows
 }
 
+//amountsByAddress sums the amounts of every address, the sums are saved as decimal strings
+type amountsByAddress map[string] *big.Int
+
+func (a amountsByAddress) add(address string, value string) (amount *big.Int) {
+    amount, _ = big.NewInt(0).SetString(value, 10)
+    if amount == nil {
+        amount = big.NewInt(0)
+    }
+
+    if _, exist := a[address]; !exist {
+        a[address] = big.NewInt(0)
+    }
+    a[address].Add(a[address], amount)
+    return
+}
+
+func (a amountsByAddress) toJson() []byte {
+    amounts := map[string] string {}
+    for address, amount := range a {
+        amounts[address] = amount.String()
+    }
+
+    data, _ := json.Marshal(amounts)
+    return data
+}
+
 func (b *TransfersRows) InsertTransferInsideIssueTransaction(tx basicAssetsLedger.TransactionWithBlockInfo)  {
-    fromJson, _ := json.Marshal(map[string] uint64 {"blockchain": tx.Assets.Supply})
-    toJson, _ := json.Marshal(map[string] uint64 {
+    fromJson, _ := json.Marshal(map[string] string {"blockchain": tx.Assets.Supply})
+    toJson, _ := json.Marshal(map[string] string {
         hex.EncodeToString(tx.Assets.MetaSeal.SignerPublicKey): tx.Assets.Supply,
     })
 
@@ -92,7 +119,7 @@ func (b *TransfersRows) InsertTransferInsideIssueTransaction(tx basicAssetsLedge
         From: string(fromJson),
         To: string(toJson),
         Assets: hex.EncodeToString(tx.Assets.MetaSeal.Hash),
-        Amount: fmt.Sprintf("%d", tx.Assets.Supply),
+        Amount: tx.Assets.Supply,
         Type: tx.TxType,
         Memo: tx.Memo,
         Time: timestamp.Format(common.BASIC_TIME_FORMAT),
@@ -102,21 +129,20 @@ func (b *TransfersRows) InsertTransferInsideIssueTransaction(tx basicAssetsLedge
 }
 
 func (b *TransfersRows) InsertTransfer(tx basicAssetsLedger.TransactionWithBlockInfo, unspentList []basicAssetsLedger.Unspent)  {
-    from := map[string] uint64 {}
+    from := amountsByAddress{}
     for _, in := range unspentList {
         ownerKey := hex.EncodeToString(in.Owner)
-        from[ownerKey] += in.Value
+        from.add(ownerKey, in.Value)
     }
-    fromJson, _ := json.Marshal(from)
+    fromJson := from.toJson()
 
-    to := map[string] uint64 {}
-    var amount uint64 = 0
+    to := amountsByAddress{}
+    amount := big.NewInt(0)
     for _, out := range tx.Output {
         outKey := hex.EncodeToString(out.To)
-        to[outKey] += out.Value
-        amount += out.Value
+        amount.Add(amount, to.add(outKey, out.Value))
     }
-    toJson, _ := json.Marshal(to)
+    toJson := to.toJson()
 
     timestamp := time.Unix(tx.CreateTime, 0)
     newTransfersRow := TransfersRow{
@@ -126,7 +152,7 @@ func (b *TransfersRows) InsertTransfer(tx basicAssetsLedger.TransactionWithBlock
         From: string(fromJson),
         To: string(toJson),
         Assets: hex.EncodeToString(tx.Assets.MetaSeal.Hash),
-        Amount: fmt.Sprintf("%d", amount),
+        Amount: amount.String(),
         Type: tx.TxType,
         Memo: tx.Memo,
         Time: timestamp.Format(common.BASIC_TIME_FORMAT),
@@ -141,22 +167,21 @@ func (b *TransfersRows) InsertTransferByDetail(
     input  []basicAssetsLedger.Unspent,
     output []basicAssetsLedger.UTXOOutput) {
 
-    var amount uint64 = 0
-    from := map[string] uint64 {}
+    amount := big.NewInt(0)
+    from := amountsByAddress{}
     for _, in := range input {
         ownerKey := hex.EncodeToString(in.Owner)
-        from[ownerKey] += in.Value
+        from.add(ownerKey, in.Value)
     }
-    fromJson, _ := json.Marshal(from)
+    fromJson := from.toJson()
 
-    to := map[string] uint64 {}
+    to := amountsByAddress{}
     for _, o := range output {
         outKey := hex.EncodeToString(o.To)
-        to[outKey] += o.Value
-        amount += o.Value
+        amount.Add(amount, to.add(outKey, o.Value))
     }
 
-    toJson, _ := json.Marshal(to)
+    toJson := to.toJson()
 
     timestamp := time.Unix(tx.CreateTime, 0)
 
@@ -167,7 +192,7 @@ func (b *TransfersRows) InsertTransferByDetail(
         From:    string(fromJson),
         To:      string(toJson),
         Assets:  hex.EncodeToString(assets),
-        Amount:  fmt.Sprintf("%d", amount),
+        Amount:  amount.String(),
         Type:    tx.TxType,
         Memo:    tx.Memo,
         Time:    timestamp.Format(common.BASIC_TIME_FORMAT),
@@ -79,7 +79,7 @@ func (b *BalanceRows) InsertBalances(height uint64, tm int64, balanceList []basi
             Assets: hex.EncodeToString(balance.Assets.MetaSeal.Hash),
             AssetsName: balance.Assets.Name,
             AssetsSymbol: balance.Assets.Symbol,
-            Amount: fmt.Sprintf("%d", balance.Amount),
+            Amount: balance.Amount,
             Time:    timestamp.Format(common.BASIC_TIME_FORMAT),
         }
 
@@ -18,6 +18,7 @@
 package basicAssetsSQLTables
 
 import (
+	"bytes"
 	"github.com/SealSC/SealABC/common"
 	"github.com/SealSC/SealABC/dataStructure/enum"
 	"github.com/SealSC/SealABC/service/application/basicAssets/basicAssetsLedger"
@@ -40,6 +41,9 @@ type SellingListTable struct {
 	Status           enum.Element `col:"c_status"`
 	StartTime        enum.Element `col:"c_start_time"`
 	StopTime         enum.Element `col:"c_stop_time"`
+	Side             enum.Element `col:"c_side"`
+	Amount           enum.Element `col:"c_amount"`
+	Filled           enum.Element `col:"c_filled"`
 
 	simpleSQLDatabase.BasicTable
 }
@@ -73,6 +77,9 @@ type SellingListRow struct {
 	Status           string
 	StartTime        string
 	StopTime         string
+	Side             string
+	Amount           string
+	Filled           string
 }
 
 func (s *SellingListRow) FromTransaction(tx basicAssetsLedger.TransactionWithBlockInfo, sellingData basicAssetsLedger.SellingData)  {
@@ -81,7 +88,9 @@ func (s *SellingListRow) FromTransaction(tx basicAssetsLedger.TransactionWithBlo
 	s.Seller = hex.EncodeToString(sellingData.Seller)
 	s.SellingAssets = hex.EncodeToString(sellingData.SellingAssets)
 	s.PaymentAssets = hex.EncodeToString(sellingData.PaymentAssets)
-	s.Price = fmt.Sprintf("%d", sellingData.Price)
+	s.Price = sellingData.Price
+	s.Amount = sellingData.Amount
+	s.Filled = "0"
 
 	timestamp := time.Unix(tx.CreateTime, 0)
 	timeString := timestamp.Format(common.BASIC_TIME_FORMAT)
@@ -98,12 +107,61 @@ func (s *SellingListRow) FromTransaction(tx basicAssetsLedger.TransactionWithBlo
 			s.Status = "2"
 		} else {
 			s.Status = "1"
+			s.Filled = s.Amount
 			s.Buyer = hex.EncodeToString(tx.Seal.SignerPublicKey)
 		}
 	}
 	return
 }
 
+//orderStatus maps the order status to the status column, 0 open, 1 filled, 2 cancelled, 3 partially filled
+func orderStatus(status string) string {
+	switch status {
+	case basicAssetsLedger.OrderStatus.Filled.String():
+		return "1"
+	case basicAssetsLedger.OrderStatus.Cancelled.String():
+		return "2"
+	case basicAssetsLedger.OrderStatus.PartiallyFilled.String():
+		return "3"
+	default:
+		return "0"
+	}
+}
+
+//FromOrder fills the row with an order of the order book, the seller is the order owner and the selling
+//assets are the assets paid by the order, the buyer is the owner of the transaction changed the order last
+func (s *SellingListRow) FromOrder(tx basicAssetsLedger.TransactionWithBlockInfo, order basicAssetsLedger.Order) {
+	s.Height = fmt.Sprintf("%d", tx.BlockInfo.BlockHeight)
+
+	s.Seller = hex.EncodeToString(order.Owner)
+	s.Side = order.Side
+	s.Price = order.Price
+	s.Amount = order.Amount
+	s.Filled = order.Filled
+	s.Status = orderStatus(order.Status)
+
+	if order.Side == basicAssetsLedger.OrderSides.Bid.String() {
+		s.SellingAssets = hex.EncodeToString(order.QuoteAssets)
+		s.PaymentAssets = hex.EncodeToString(order.BaseAssets)
+	} else {
+		s.SellingAssets = hex.EncodeToString(order.BaseAssets)
+		s.PaymentAssets = hex.EncodeToString(order.QuoteAssets)
+	}
+
+	timestamp := time.Unix(tx.CreateTime, 0)
+	timeString := timestamp.Format(common.BASIC_TIME_FORMAT)
+
+	s.StartTransaction = hex.EncodeToString(order.ID)
+	s.StopTransaction = hex.EncodeToString(tx.Seal.Hash)
+	s.StopTime = timeString
+	if bytes.Equal(order.ID, tx.Seal.Hash) {
+		s.StartTime = timeString
+	} else if tx.TxType != basicAssetsLedger.TransactionTypes.CancelOrder.String() {
+		s.Buyer = hex.EncodeToString(tx.Seal.SignerPublicKey)
+	}
+	return
+}
+
 type SellingListRows struct {
 	simpleSQLDatabase.BasicRows
 }
@@ -114,6 +172,12 @@ func (s *SellingListRows) InsertRow(tx basicAssetsLedger.TransactionWithBlockInf
 	s.Rows = append(s.Rows, newRow)
 }
 
+func (s *SellingListRows) InsertOrderRow(tx basicAssetsLedger.TransactionWithBlockInfo, order basicAssetsLedger.Order) {
+	newRow := SellingListRow{}
+	newRow.FromOrder(tx, order)
+	s.Rows = append(s.Rows, newRow)
+}
+
 func (s *SellingListRows) GetUpdateInfo() ([]string, string) {
 	return []string{
 		"Height",
@@ -125,6 +189,11 @@ func (s *SellingListRows) GetUpdateInfo() ([]string, string) {
 	"where c_start_transaction=?"
 }
 
+func (s *SellingListRows) GetOrderUpdateInfo() ([]string, string) {
+	fields, condition := s.GetUpdateInfo()
+	return append(fields, "Filled"), condition
+}
+
 func (s *SellingListRows) Table() simpleSQLDatabase.ITable {
 	return &SellingList
 }
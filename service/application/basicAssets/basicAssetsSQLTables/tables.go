@@ -24,4 +24,5 @@ func Load()  {
     AddressList.load()
     Balance.load()
     SellingList.load()
+    AssetsActions.load()
 }
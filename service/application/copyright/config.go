@@ -28,6 +28,9 @@ import (
 
 type Config struct {
 	commonCfg.Config
+
+	//Platforms are the public keys allowed to seal certificates, any platform is accepted if empty
+	Platforms [][]byte
 }
 
 func DefaultConfig() *Config {
@@ -14,20 +14,62 @@
  * limitations under the License.
  *
  */
-
 package copyrightData
 
 import (
 	"github.com/SealSC/SealABC/crypto"
+	"github.com/SealSC/SealABC/dataStructure/enum"
 	"github.com/SealSC/SealABC/metadata/seal"
 )
 
+var CopyrightActionTypes struct {
+	Register enum.Element
+	Transfer enum.Element
+	License  enum.Element
+	Trade    enum.Element
+
+	GrantLicense    enum.Element
+	SetRoyaltySplit enum.Element
+	PayLicense      enum.Element
+}
+
+var QueryTypes struct {
+	Certificate           enum.Element
+	CertificateByResource enum.Element
+	CertificatesOfOwner   enum.Element
+	Tradings              enum.Element
+
+	LicensesOfCertificate enum.Element
+	LicensesOfLicensee    enum.Element
+	RoyaltySplit          enum.Element
+	LicensePayments       enum.Element
+	ProveLicense          enum.Element
+}
+
 type CopyrightResource struct {
 	ID              [] byte
 	Owner           []byte
 	ResourceHash    []byte
 }
 
+//CopyrightApplicationData is the part of the certificate signed by the applicant and the owner
+type CopyrightApplicationData struct {
+	Applicant  []byte
+	Owner      []byte
+	BasicHash  []byte
+	ExtraHash  []byte
+	CustomData []byte
+}
+
+//PlatformSignedData is signed by the platform after the applicant and the owner signed the application
+type PlatformSignedData struct {
+	CopyrightApplicationData
+	ApplicantSeal seal.Entity
+	OwnSeal       seal.Entity
+}
+
+//CopyrightCertificate is registered with the seals of the applicant, the owner and the platform,
+//BasicHash is the hash of the resource and the ID is the hash of the application data
 type CopyrightCertificate struct {
 	ID                  []byte
 	Applicant           []byte
@@ -49,8 +91,59 @@ type CopyrightCertificate struct {
 	Algorithm           crypto.AlgorithmDescription
 }
 
+func (c CopyrightCertificate) ApplicationData() CopyrightApplicationData {
+	return CopyrightApplicationData{
+		Applicant:  c.Applicant,
+		Owner:      c.Owner,
+		BasicHash:  c.BasicHash,
+		ExtraHash:  c.ExtraHash,
+		CustomData: c.CustomData,
+	}
+}
+
+func (c CopyrightCertificate) PlatformSignedData() PlatformSignedData {
+	return PlatformSignedData{
+		CopyrightApplicationData: c.ApplicationData(),
+		ApplicantSeal:            c.ApplicantSeal,
+		OwnSeal:                  c.OwnSeal,
+	}
+}
+
+//CopyrightTradingData is signed by the owner of the certificate, and by the other side for licensing and trading,
+//Price is a decimal amount and ExpireTime is the unix time a license ends, 0 means never
+type CopyrightTradingData struct {
+	CertificateID []byte
+	From          []byte
+	To            []byte
+	Price         string
+	Terms         []byte
+	ExpireTime    int64 `json:",string"`
+}
+
+//CopyrightTrading is the record of an ownership transfer, a license or a trade of a certificate,
+//the ID is the hash of the trading data signed by the owner
 type CopyrightTrading struct {
+	CopyrightTradingData
+
+	FromSeal seal.Entity
+	ToSeal   seal.Entity
+
+	ID          []byte
+	Type        string
+	Transaction []byte
+	Time        string
+}
+
+//TradingResult is the result of the transfer, license and trade actions
+type TradingResult struct {
+	Trading     CopyrightTrading
+	Certificate CopyrightCertificate
+}
 
+type QueryRequest struct {
+	DBType    string
+	QueryType string
+	Parameter []string
 }
 
-const APPName = "Traceable Storage"
+const APPName = "Copyright"
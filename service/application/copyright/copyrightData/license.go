@@ -0,0 +1,107 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightData
+
+import (
+	"github.com/SealSC/SealABC/metadata/seal"
+)
+
+//applications a license payment can be settled in
+const (
+	SettleInBasicAssets = "Basic Assets"
+	SettleInSmartAssets = "Smart Assets"
+)
+
+//TotalRoyaltyPoints is the sum of the shares of a royalty split, a point is 0.01 percent
+const TotalRoyaltyPoints = 10000
+
+//LicenseGrantData is signed by the owner of the certificate and the licensee,
+//StartTime and EndTime are unix times, an EndTime of 0 means the license never ends
+type LicenseGrantData struct {
+	CertificateID []byte
+	Licensee      []byte
+	Scope         string
+	Territory     string
+	StartTime     int64 `json:",string"`
+	EndTime       int64 `json:",string"`
+	Exclusive     bool
+}
+
+//LicenseGrant is identified by the hash of the grant data signed by the owner
+type LicenseGrant struct {
+	LicenseGrantData
+
+	OwnerSeal    seal.Entity
+	LicenseeSeal seal.Entity
+
+	ID          []byte
+	Owner       []byte
+	Transaction []byte
+	Time        string
+}
+
+//RoyaltyShare is the part of every license payment a payee receives, in points of TotalRoyaltyPoints
+type RoyaltyShare struct {
+	Payee  []byte
+	Points uint32 `json:",string"`
+}
+
+//RoyaltySplitData is signed by the owner of the certificate and replaces the split set before,
+//Sequence must be greater than the sequence of the split it replaces, so an old split can't be set again
+type RoyaltySplitData struct {
+	CertificateID []byte
+	Shares        []RoyaltyShare
+	Sequence      uint64 `json:",string"`
+}
+
+type RoyaltySplit struct {
+	RoyaltySplitData
+
+	OwnerSeal seal.Entity
+
+	Transaction []byte
+	Time        string
+}
+
+//LicensePaymentData pays Amount of the assets for a license, settled by the Settlement application.
+//Payments are the json of the signed transfers of that application, the one at index i pays share i of the split,
+//Assets is the hash of the basic assets paid and is empty for smart assets.
+//a basic assets transfer carries the settlement data naming the copyright application in its extra data.
+type LicensePaymentData struct {
+	LicenseID  []byte
+	Settlement string
+	Assets     []byte
+	Amount     string
+	Payments   [][]byte
+}
+
+//LicensePayment is signed by the licensee and identified by the hash of the payment data
+type LicensePayment struct {
+	LicensePaymentData
+
+	PayerSeal seal.Entity
+
+	ID          []byte
+	Transaction []byte
+	Time        string
+}
+
+//LicenseProof shows whether Licensee holds a valid license for the scope and territory at the time
+type LicenseProof struct {
+	Licensed bool
+	Licenses []LicenseGrant
+}
@@ -14,12 +14,14 @@
  * limitations under the License.
  *
  */
-
-package tsInterface
+package copyrightInterface
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/crypto"
+	"github.com/SealSC/SealABC/dataStructure/enum"
 	"github.com/SealSC/SealABC/dataStructure/merkleTree"
 	"github.com/SealSC/SealABC/metadata/applicationResult"
 	"github.com/SealSC/SealABC/metadata/block"
@@ -27,15 +29,20 @@ import (
 	"github.com/SealSC/SealABC/metadata/seal"
 	"github.com/SealSC/SealABC/service"
 	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
-	"github.com/SealSC/SealABC/service/application/traceableStorage/tsData"
-	"github.com/SealSC/SealABC/service/application/traceableStorage/tsLedger"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightLedger"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightSQLStorage"
 	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
 	"sync"
 )
 
-type CopyrightStorageApplication struct {
+var QueryDBType struct {
+	KV  enum.Element
+	SQL enum.Element
+}
+
+type CopyrightApplication struct {
 	chainStructure.BlankApplication
 
 	reqList []string
@@ -43,45 +50,83 @@ type CopyrightStorageApplication struct {
 
 	poolLimit int
 
-	poolLock sync.RWMutex
-	tsLedger *tsLedger.TSLedger
+	poolLock sync.Mutex
+	ledger   *copyrightLedger.CopyrightLedger
+
+	sqlStorage *copyrightSQLStorage.Storage
+	chain      chainStructure.IChainInterface
 }
 
 type RequestList struct {
 	Requests []blockchainRequest.Entity
 }
 
-func (t *CopyrightStorageApplication) Name() (name string) {
+func (c *CopyrightApplication) Name() (name string) {
 	return copyrightData.APPName
 }
 
-func (t *CopyrightStorageApplication) PushClientRequest(req blockchainRequest.Entity) (result interface{}, err error) {
-	reqKey := string(req.Seal.Hash)
-	if _, exists := t.reqMap[reqKey]; exists {
+func (c *CopyrightApplication) verifyRequest(req blockchainRequest.Entity) (ret interface{}, err error) {
+	_, err = req.VerifySeal(c.ledger.CryptoTools.HashCalculator)
+	if err != nil {
+		return
+	}
+
+	signers, err := c.ledger.ActionSigners(req.RequestAction, req.Data)
+	if err != nil {
+		return
+	}
+
+	err = req.RequireSigner(signers...)
+	if err != nil {
 		return
 	}
 
-	tsReq := tsData.TSServiceRequest{}
-	err = json.Unmarshal(req.Data, &tsReq)
+	return c.ledger.VerifyAction(req.RequestAction, req.Data)
+}
+
+func (c *CopyrightApplication) PushClientRequest(req blockchainRequest.Entity) (result interface{}, err error) {
+	result, err = c.verifyRequest(req)
 	if err != nil {
 		return
 	}
 
-	err = t.tsLedger.VerifyRequest(tsReq)
-	if err == nil {
-		t.poolLock.RLock()
-		t.reqMap[reqKey] = req
-		t.reqList = append(t.reqList, reqKey)
-		t.poolLock.RUnlock()
+	c.poolLock.Lock()
+	defer c.poolLock.Unlock()
+
+	reqKey := string(req.Seal.Hash)
+	if _, exists := c.reqMap[reqKey]; exists {
+		return
 	}
+
+	c.reqMap[reqKey] = req
+	c.reqList = append(c.reqList, reqKey)
 	return
 }
 
-func (t *CopyrightStorageApplication) Query(reqData []byte) (result interface{}, err error) {
-	return t.tsLedger.GetLocalData(string(reqData))
+func (c *CopyrightApplication) Query(reqData []byte) (result interface{}, err error) {
+	queryReq := copyrightData.QueryRequest{}
+	err = json.Unmarshal(reqData, &queryReq)
+	if err != nil {
+		return
+	}
+
+	switch queryReq.DBType {
+	case QueryDBType.KV.String():
+		return c.ledger.Query(queryReq)
+
+	case QueryDBType.SQL.String():
+		if c.sqlStorage == nil {
+			return nil, errors.New("sql database not enabled")
+		}
+		return c.sqlStorage.DoQuery(queryReq)
+
+	default:
+		err = errors.New("no such database type: " + queryReq.DBType)
+		return
+	}
 }
 
-func (t *CopyrightStorageApplication) PreExecute(req blockchainRequest.Entity, _ block.Entity) (result []byte, err error) {
+func (c *CopyrightApplication) PreExecute(req blockchainRequest.Entity, _ block.Entity) (result []byte, err error) {
 	var reqList = RequestList{}
 	err = structSerializer.FromMFBytes(req.Data, &reqList)
 	if err != nil {
@@ -89,7 +134,7 @@ func (t *CopyrightStorageApplication) PreExecute(req blockchainRequest.Entity, _
 	}
 
 	for _, req := range reqList.Requests {
-		_, err = t.PushClientRequest(req)
+		_, err = c.verifyRequest(req)
 		if err != nil {
 			break
 		}
@@ -97,32 +142,52 @@ func (t *CopyrightStorageApplication) PreExecute(req blockchainRequest.Entity, _
 	return
 }
 
-func (t *CopyrightStorageApplication) removeTransactionsFromPool(list RequestList) {
+func (c *CopyrightApplication) removeRequestsFromPool(list RequestList) {
 	removedReq := map[string] bool {}
 
 	for _, req := range list.Requests {
 		reqKey := string(req.Seal.Hash)
 
-		if _, exists := t.reqMap[reqKey]; exists {
+		if _, exists := c.reqMap[reqKey]; exists {
 			removedReq[reqKey] = true
-			delete(t.reqMap, reqKey)
+			delete(c.reqMap, reqKey)
 		}
 	}
 
-	var newTxPoolRecord []string
-	for _, reqHash := range t.reqList {
+	var newPoolRecord []string
+	for _, reqHash := range c.reqList {
 		if !removedReq[reqHash] {
-			newTxPoolRecord = append(newTxPoolRecord, reqHash)
+			newPoolRecord = append(newPoolRecord, reqHash)
 		}
 	}
 
-	t.reqList = newTxPoolRecord
+	c.reqList = newPoolRecord
 }
 
-func (t *CopyrightStorageApplication) Execute (
+func (c *CopyrightApplication) storeResult(height uint64, ret interface{}) {
+	if c.sqlStorage == nil {
+		return
+	}
+
+	switch r := ret.(type) {
+	case copyrightData.CopyrightCertificate:
+		_ = c.sqlStorage.StoreCertificate(height, r)
+
+	case copyrightData.TradingResult:
+		_ = c.sqlStorage.StoreTrading(height, r)
+
+	case copyrightData.LicenseGrant:
+		_ = c.sqlStorage.StoreLicense(height, r)
+
+	case copyrightData.LicensePayment:
+		_ = c.sqlStorage.StoreLicensePayment(height, r)
+	}
+}
+
+func (c *CopyrightApplication) Execute (
 	req blockchainRequest.Entity,
 	blk block.Entity,
-	actIndex uint32,
+	_ uint32,
 ) (result applicationResult.Entity, err error) {
 	var reqList = RequestList{}
 	err = structSerializer.FromMFBytes(req.Data, &reqList)
@@ -130,33 +195,108 @@ func (t *CopyrightStorageApplication) Execute (
 		return
 	}
 
-	for _, req := range reqList.Requests {
-		_, err = t.PushClientRequest(req)
-		if err != nil {
-			break
+	c.poolLock.Lock()
+	defer c.poolLock.Unlock()
+
+	var retList []interface{}
+	err = c.ledger.ExecuteActionsWithUndo(req.Seal.Hash, func() error {
+		for _, act := range reqList.Requests {
+			_, sealErr := act.VerifySeal(c.ledger.CryptoTools.HashCalculator)
+			if sealErr != nil {
+				return sealErr
+			}
+
+			ctx := copyrightLedger.ActionContext{
+				Transaction: act.Seal.Hash,
+				Height:      blk.Header.Height,
+				Time:        int64(blk.Header.Timestamp),
+			}
+
+			ret, exeErr := c.ledger.ExecuteAction(ctx, act.RequestAction, act.Data)
+			if exeErr != nil {
+				return exeErr
+			}
+
+			retList = append(retList, ret)
 		}
 
-		tsReq := tsData.TSServiceRequest{}
-		err = json.Unmarshal(req.Data, &tsReq)
-		if err != nil {
-			break
-		}
+		return nil
+	})
 
-		_, err = t.tsLedger.ExecuteRequest(tsReq)
-		if err != nil {
-			break
-		}
+	if err != nil {
+		return
 	}
 
-	if err == nil {
-		t.removeTransactionsFromPool(reqList)
+	for _, ret := range retList {
+		c.storeResult(blk.Header.Height, ret)
 	}
+
+	c.removeRequestsFromPool(reqList)
 	return
 }
 
-func (t *CopyrightStorageApplication) Information() (info service.BasicInformation) {
-	info.Name = t.Name()
-	info.Description = "this is an traceableStorage application"
+func (c *CopyrightApplication) Rollback(
+	req blockchainRequest.Entity,
+	_ block.Entity,
+	_ uint32,
+) (err error) {
+	c.poolLock.Lock()
+	defer c.poolLock.Unlock()
+
+	return c.ledger.Rollback(req.Seal.Hash)
+}
+
+func (c *CopyrightApplication) internalCallContext(undoKey []byte) (ctx copyrightLedger.ActionContext) {
+	ctx.Transaction = undoKey
+	if c.chain == nil {
+		return
+	}
+
+	ctx.Height = c.chain.CurrentHeight() + 1
+	if last := c.chain.GetLastBlock(); last != nil {
+		ctx.Time = int64(last.Header.Timestamp)
+	}
+	return
+}
+
+//ApplicationInternalCall lets other applications query certificates or verify, execute and roll back a signed copyright action
+func (c *CopyrightApplication) ApplicationInternalCall(_ string, callData []byte) (ret interface{}, err error) {
+	req, err := chainStructure.ParseInternalCall(callData)
+	if err != nil {
+		return
+	}
+
+	switch req.Type {
+	case chainStructure.InternalCallQuery:
+		return c.Query(req.Data)
+
+	case chainStructure.InternalCallVerify:
+		return c.ledger.VerifyAction(req.Action, req.Data)
+
+	case chainStructure.InternalCallExecute:
+		c.poolLock.Lock()
+		defer c.poolLock.Unlock()
+
+		err = c.ledger.ExecuteActionsWithUndo(req.UndoKey, func() (exeErr error) {
+			ret, exeErr = c.ledger.ExecuteAction(c.internalCallContext(req.UndoKey), req.Action, req.Data)
+			return
+		})
+		return
+
+	case chainStructure.InternalCallRollback:
+		c.poolLock.Lock()
+		defer c.poolLock.Unlock()
+
+		err = c.ledger.Rollback(req.UndoKey)
+		return
+	}
+
+	return nil, chainStructure.UnsupportedInternalCall(c.Name(), req)
+}
+
+func (c *CopyrightApplication) Information() (info service.BasicInformation) {
+	info.Name = c.Name()
+	info.Description = "this is a copyright application"
 
 	info.Api.Protocol = service.ApiProtocols.INTERNAL.String()
 	info.Api.Address = ""
@@ -164,35 +304,33 @@ func (t *CopyrightStorageApplication) Information() (info service.BasicInformati
 	return
 }
 
+func (c *CopyrightApplication) RequestsForBlock(_ block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
+	c.poolLock.Lock()
+	defer c.poolLock.Unlock()
 
-func (t *CopyrightStorageApplication) RequestsForBlock(_ block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
-	t.poolLock.Lock()
-	defer t.poolLock.Unlock()
-
-	cnt = uint32(len(t.reqList))
-	if cnt == 0 {
+	if len(c.reqList) == 0 {
 		return
 	}
 
-	tsReqList := RequestList{}
+	packedList := RequestList{}
 	mt := merkleTree.Tree{}
-	for idx, reqKey := range t.reqList {
-		req := t.reqMap[reqKey]
+	for _, reqKey := range c.reqList {
+		req := c.reqMap[reqKey]
 		mt.AddHash(req.Seal.Hash)
-		tsReqList.Requests = append(tsReqList.Requests, req)
+		packedList.Requests = append(packedList.Requests, req)
 
-		if idx >= (t.poolLimit - 1) {
-			cnt = uint32(idx)
+		if len(packedList.Requests) >= c.poolLimit {
 			break
 		}
 	}
 
+	cnt = uint32(len(packedList.Requests))
 	txRoot, _ := mt.Calculate()
 
-	blkReqData, _ := structSerializer.ToMFBytes(tsReqList)
+	blkReqData, _ := structSerializer.ToMFBytes(packedList)
 	packedReq := blockchainRequest.Entity{
 		EntityData: blockchainRequest.EntityData{
-			RequestApplication: t.Name(),
+			RequestApplication: c.Name(),
 			RequestAction:      "",
 			Data:               blkReqData,
 			QueryString:        "",
@@ -211,20 +349,47 @@ func (t *CopyrightStorageApplication) RequestsForBlock(_ block.Entity) (reqList
 	return []blockchainRequest.Entity{packedReq}, 1
 }
 
+func (c *CopyrightApplication) UnpackingActionsAsRequests(req blockchainRequest.Entity) (list []blockchainRequest.Entity, err error) {
+	if !req.Packed {
+		return []blockchainRequest.Entity{req}, nil
+	}
+
+	reqList := RequestList{}
+	err = structSerializer.FromMFBytes(req.Data, &reqList)
+	if err != nil {
+		return
+	}
+
+	return reqList.Requests, nil
+}
+
+func (c *CopyrightApplication) GetActionAsRequest(req blockchainRequest.Entity) blockchainRequest.Entity {
+	return req
+}
+
+func (c *CopyrightApplication) SetChainInterface(ci chainStructure.IChainInterface) {
+	c.chain = ci
+	c.ledger.SetChain(ci)
+}
+
 func Load()  {
-	tsLedger.Load()
+	enum.SimpleBuild(&QueryDBType)
+	copyrightLedger.Load()
+	copyrightSQLStorage.Load()
 }
 
-func NewApplicationInterface(kvDriver kvDatabase.IDriver, sqlDriver simpleSQLDatabase.IDriver) (app chainStructure.IBlockchainExternalApplication) {
-	ts := CopyrightStorageApplication{
+func NewApplicationInterface(kvDriver kvDatabase.IDriver, sqlDriver simpleSQLDatabase.IDriver, tools crypto.Tools, platforms [][]byte) (app chainStructure.IBlockchainExternalApplication) {
+	cp := CopyrightApplication{
 		reqList:   []string {},
 		reqMap:    map[string]blockchainRequest.Entity{},
-		poolLock:  sync.RWMutex{},
 		poolLimit: 1000,
-		tsLedger:  tsLedger.NewTraceableStorage(kvDriver, sqlDriver),
+		ledger:    copyrightLedger.NewLedger(kvDriver, tools, platforms),
 	}
 
-	app = &ts
+	if sqlDriver != nil {
+		cp.sqlStorage = copyrightSQLStorage.NewStorage(sqlDriver)
+	}
+
+	app = &cp
 	return
 }
-
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/crypto"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+)
+
+var StoragePrefixes struct {
+	Certificate          enum.Element
+	Resource             enum.Element
+	Owner                enum.Element
+	Trading              enum.Element
+	TradingOfCertificate enum.Element
+	Undo                 enum.Element
+
+	License              enum.Element
+	LicenseOfCertificate enum.Element
+	LicenseOfLicensee    enum.Element
+	RoyaltySplit         enum.Element
+	Payment              enum.Element
+	PaymentOfLicense     enum.Element
+}
+
+func Load() {
+	enum.SimpleBuild(&StoragePrefixes)
+	enum.SimpleBuild(&copyrightData.CopyrightActionTypes)
+	enum.SimpleBuild(&copyrightData.QueryTypes)
+}
+
+//ActionContext is the block information of an executing action
+type ActionContext struct {
+	Transaction []byte
+	Height      uint64
+	Time        int64
+}
+
+type actionValidator func(actData []byte) (ret interface{}, err error)
+type actionExecutor func(ctx ActionContext, actData []byte) (ret interface{}, err error)
+type queryHandler func(param []string) (ret interface{}, err error)
+
+type CopyrightLedger struct {
+	validators    map[string] actionValidator
+	executors     map[string] actionExecutor
+	queryHandlers map[string] queryHandler
+
+	//Platforms are the public keys allowed to seal certificates, any platform is accepted if empty
+	Platforms [][]byte
+
+	CryptoTools crypto.Tools
+	KVStorage   kvDatabase.IDriver
+
+	journal *kvDatabase.Journal
+	chain   chainStructure.IChainInterface
+
+	//settlements made in other applications by the executing actions, rolled back with them
+	settlements []settlementRecord
+}
+
+//undoRecord is saved for executed actions to roll back the ledger and the settlements they made
+type undoRecord struct {
+	PreImages   []kvDatabase.KVItem
+	Settlements []settlementRecord
+}
+
+func NewLedger(kvDriver kvDatabase.IDriver, tools crypto.Tools, platforms [][]byte) (ledger *CopyrightLedger) {
+	ledger = &CopyrightLedger{
+		Platforms:   platforms,
+		CryptoTools: tools,
+	}
+
+	ledger.journal = kvDatabase.NewJournal(kvDriver)
+	ledger.KVStorage = ledger.journal
+
+	ledger.validators = map[string] actionValidator {
+		copyrightData.CopyrightActionTypes.Register.String(): ledger.verifyRegister,
+		copyrightData.CopyrightActionTypes.Transfer.String(): ledger.verifyTransfer,
+		copyrightData.CopyrightActionTypes.License.String():  ledger.verifyLicense,
+		copyrightData.CopyrightActionTypes.Trade.String():    ledger.verifyTrade,
+
+		copyrightData.CopyrightActionTypes.GrantLicense.String():    ledger.verifyGrantLicense,
+		copyrightData.CopyrightActionTypes.SetRoyaltySplit.String(): ledger.verifySetRoyaltySplit,
+		copyrightData.CopyrightActionTypes.PayLicense.String():      ledger.verifyPayLicense,
+	}
+
+	ledger.executors = map[string] actionExecutor {
+		copyrightData.CopyrightActionTypes.Register.String(): ledger.register,
+		copyrightData.CopyrightActionTypes.Transfer.String(): ledger.transfer,
+		copyrightData.CopyrightActionTypes.License.String():  ledger.license,
+		copyrightData.CopyrightActionTypes.Trade.String():    ledger.trade,
+
+		copyrightData.CopyrightActionTypes.GrantLicense.String():    ledger.grantLicense,
+		copyrightData.CopyrightActionTypes.SetRoyaltySplit.String(): ledger.setRoyaltySplit,
+		copyrightData.CopyrightActionTypes.PayLicense.String():      ledger.payLicense,
+	}
+
+	ledger.queryHandlers = map[string] queryHandler {
+		copyrightData.QueryTypes.Certificate.String():           ledger.queryCertificate,
+		copyrightData.QueryTypes.CertificateByResource.String(): ledger.queryCertificateByResource,
+		copyrightData.QueryTypes.CertificatesOfOwner.String():   ledger.queryCertificatesOfOwner,
+		copyrightData.QueryTypes.Tradings.String():              ledger.queryTradings,
+
+		copyrightData.QueryTypes.LicensesOfCertificate.String(): ledger.queryLicensesOfCertificate,
+		copyrightData.QueryTypes.LicensesOfLicensee.String():    ledger.queryLicensesOfLicensee,
+		copyrightData.QueryTypes.RoyaltySplit.String():          ledger.queryRoyaltySplit,
+		copyrightData.QueryTypes.LicensePayments.String():       ledger.queryLicensePayments,
+		copyrightData.QueryTypes.ProveLicense.String():          ledger.proveLicense,
+	}
+
+	return
+}
+
+func (c *CopyrightLedger) VerifyAction(action string, data []byte) (ret interface{}, err error) {
+	if validate, exists := c.validators[action]; exists {
+		return validate(data)
+	}
+
+	return nil, errors.New("action not supported")
+}
+
+//ActionSigners gets the signers of the seals in the action data, the request of the action must be sealed by one of them
+func (c *CopyrightLedger) ActionSigners(action string, data []byte) (signers [][]byte, err error) {
+	types := copyrightData.CopyrightActionTypes
+
+	switch action {
+	case types.Register.String():
+		cert := copyrightData.CopyrightCertificate{}
+		err = json.Unmarshal(data, &cert)
+		signers = [][]byte{cert.ApplicantSeal.SignerPublicKey, cert.OwnSeal.SignerPublicKey, cert.PlatformSeal.SignerPublicKey}
+
+	case types.Transfer.String(), types.License.String(), types.Trade.String():
+		trading := copyrightData.CopyrightTrading{}
+		err = json.Unmarshal(data, &trading)
+		signers = [][]byte{trading.FromSeal.SignerPublicKey, trading.ToSeal.SignerPublicKey}
+
+	case types.GrantLicense.String():
+		license := copyrightData.LicenseGrant{}
+		err = json.Unmarshal(data, &license)
+		signers = [][]byte{license.OwnerSeal.SignerPublicKey, license.LicenseeSeal.SignerPublicKey}
+
+	case types.SetRoyaltySplit.String():
+		split := copyrightData.RoyaltySplit{}
+		err = json.Unmarshal(data, &split)
+		signers = [][]byte{split.OwnerSeal.SignerPublicKey}
+
+	case types.PayLicense.String():
+		payment := copyrightData.LicensePayment{}
+		err = json.Unmarshal(data, &payment)
+		signers = [][]byte{payment.PayerSeal.SignerPublicKey}
+
+	default:
+		err = errors.New("action not supported")
+	}
+
+	return
+}
+
+//ExecuteAction verifies the action against the current ledger state before executing it
+func (c *CopyrightLedger) ExecuteAction(ctx ActionContext, action string, data []byte) (ret interface{}, err error) {
+	executor, exists := c.executors[action]
+	if !exists {
+		return nil, errors.New("action not supported")
+	}
+
+	_, err = c.VerifyAction(action, data)
+	if err != nil {
+		return
+	}
+
+	return executor(ctx, data)
+}
+
+func (c *CopyrightLedger) Query(req copyrightData.QueryRequest) (ret interface{}, err error) {
+	if handler, exists := c.queryHandlers[req.QueryType]; exists {
+		return handler(req.Parameter)
+	}
+
+	return nil, errors.New("no such query handler: " + req.QueryType)
+}
+
+func (c *CopyrightLedger) SetChain(chain chainStructure.IChainInterface) {
+	c.chain = chain
+}
+
+//ExecuteActionsWithUndo executes the actions and saves the original data of the changed keys under the given key,
+//with the settlements the actions made in other applications
+func (c *CopyrightLedger) ExecuteActionsWithUndo(undoKey []byte, executeActions func() error) (err error) {
+	c.settlements = nil
+	c.journal.Begin()
+	err = executeActions()
+	preImages := c.journal.End()
+	settlements := c.settlements
+	c.settlements = nil
+
+	if err != nil {
+		_ = c.rollbackSettlements(settlements)
+		_ = c.journal.Undo(preImages)
+		return
+	}
+
+	undoData, _ := json.Marshal(undoRecord{
+		PreImages:   preImages,
+		Settlements: settlements,
+	})
+	return c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.Undo, undoKey),
+		Data:   undoData,
+		Exists: true,
+	})
+}
+
+func (c *CopyrightLedger) Rollback(undoKey []byte) (err error) {
+	key := c.buildKey(StoragePrefixes.Undo, undoKey)
+	kv, err := c.KVStorage.Get(key)
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		return errors.New("no undo record")
+	}
+
+	record := undoRecord{}
+	err = json.Unmarshal(kv.Data, &record)
+	if err != nil {
+		return
+	}
+
+	err = c.rollbackSettlements(record.Settlements)
+	if err != nil {
+		return
+	}
+
+	err = c.journal.Undo(record.PreImages)
+	if err != nil {
+		return
+	}
+
+	return c.KVStorage.Delete(key)
+}
+
+func (c *CopyrightLedger) buildKey(prefix enum.Element, keys ...[]byte) []byte {
+	return append([]byte(prefix.String()), bytes.Join(keys, nil)...)
+}
+
+func (c *CopyrightLedger) isPlatform(key []byte) bool {
+	if len(c.Platforms) == 0 {
+		return true
+	}
+
+	for _, p := range c.Platforms {
+		if bytes.Equal(p, key) {
+			return true
+		}
+	}
+
+	return false
+}
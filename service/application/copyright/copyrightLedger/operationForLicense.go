@@ -0,0 +1,399 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SealSC/SealABC/common"
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"math/big"
+	"time"
+)
+
+//an empty scope or territory of a license covers all scopes or territories
+func covers(licensed string, wanted string) bool {
+	return licensed == "" || licensed == wanted
+}
+
+func intersects(a string, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func termsOverlap(a copyrightData.LicenseGrantData, b copyrightData.LicenseGrantData) bool {
+	aAfterB := b.EndTime != 0 && a.StartTime >= b.EndTime
+	bAfterA := a.EndTime != 0 && b.StartTime >= a.EndTime
+	return !aAfterB && !bAfterA
+}
+
+//conflicts is true if one of the licenses is exclusive and the other one of another licensee overlaps it
+func conflicts(a copyrightData.LicenseGrantData, b copyrightData.LicenseGrantData) bool {
+	if !a.Exclusive && !b.Exclusive {
+		return false
+	}
+
+	if bytes.Equal(a.Licensee, b.Licensee) {
+		return false
+	}
+
+	return intersects(a.Scope, b.Scope) && intersects(a.Territory, b.Territory) && termsOverlap(a, b)
+}
+
+func (c *CopyrightLedger) getLicense(id []byte) (license copyrightData.LicenseGrant, exists bool, err error) {
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.License, id))
+	if err != nil || !kv.Exists {
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &license)
+	exists = err == nil
+	return
+}
+
+func (c *CopyrightLedger) licensesUnder(prefix []byte) (list []copyrightData.LicenseGrant) {
+	list = []copyrightData.LicenseGrant{}
+	for _, kv := range c.KVStorage.Traversal(prefix) {
+		license, exists, _ := c.getLicense(kv.Data)
+		if exists {
+			list = append(list, license)
+		}
+	}
+
+	return
+}
+
+func (c *CopyrightLedger) verifyGrantLicense(actData []byte) (ret interface{}, err error) {
+	license := copyrightData.LicenseGrant{}
+	err = json.Unmarshal(actData, &license)
+	if err != nil {
+		return nil, errors.New("invalid license data: " + err.Error())
+	}
+
+	if len(license.Licensee) == 0 {
+		return nil, errors.New("no licensee")
+	}
+
+	if license.StartTime < 0 || (license.EndTime != 0 && license.EndTime <= license.StartTime) {
+		return nil, errors.New("invalid license term")
+	}
+
+	cert, exists, err := c.getCertificate(license.CertificateID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no such certificate")
+	}
+
+	if !bytes.Equal(license.OwnerSeal.SignerPublicKey, cert.Owner) {
+		return nil, errors.New("license not granted by the owner of the certificate")
+	}
+
+	if !bytes.Equal(license.LicenseeSeal.SignerPublicKey, license.Licensee) {
+		return nil, errors.New("license not accepted by the licensee")
+	}
+
+	hashCalc := c.CryptoTools.HashCalculator
+	grantData, _ := structSerializer.ToMFBytes(license.LicenseGrantData)
+	_, err = license.OwnerSeal.Verify(grantData, hashCalc)
+	if err != nil {
+		return nil, errors.New("invalid seal of the owner: " + err.Error())
+	}
+
+	_, err = license.LicenseeSeal.Verify(grantData, hashCalc)
+	if err != nil {
+		return nil, errors.New("invalid seal of the licensee: " + err.Error())
+	}
+
+	_, exists, err = c.getLicense(license.OwnerSeal.Hash)
+	if err != nil {
+		return
+	}
+
+	if exists {
+		return nil, errors.New("license already granted")
+	}
+
+	for _, granted := range c.licensesUnder(c.buildKey(StoragePrefixes.LicenseOfCertificate, cert.ID)) {
+		if conflicts(granted.LicenseGrantData, license.LicenseGrantData) {
+			return nil, errors.New("license conflicts with the exclusive license " + granted.OwnerSeal.HexHash())
+		}
+	}
+
+	return license.OwnerSeal.Hash, nil
+}
+
+func (c *CopyrightLedger) grantLicense(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	license := copyrightData.LicenseGrant{}
+	_ = json.Unmarshal(actData, &license)
+
+	license.ID = license.OwnerSeal.Hash
+	license.Owner = license.OwnerSeal.SignerPublicKey
+	license.Transaction = ctx.Transaction
+	license.Time = time.Unix(ctx.Time, 0).Format(common.BASIC_TIME_FORMAT)
+
+	data, _ := json.Marshal(license)
+	err = c.KVStorage.BatchPut([]kvDatabase.KVItem{
+		{
+			Key:    c.buildKey(StoragePrefixes.License, license.ID),
+			Data:   data,
+			Exists: true,
+		},
+		{
+			Key:    c.buildKey(StoragePrefixes.LicenseOfCertificate, license.CertificateID, license.ID),
+			Data:   license.ID,
+			Exists: true,
+		},
+		{
+			Key:    c.buildKey(StoragePrefixes.LicenseOfLicensee, license.Licensee, license.ID),
+			Data:   license.ID,
+			Exists: true,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	return license, nil
+}
+
+func (c *CopyrightLedger) getRoyaltySplit(certID []byte) (split copyrightData.RoyaltySplit, exists bool, err error) {
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.RoyaltySplit, certID))
+	if err != nil || !kv.Exists {
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &split)
+	exists = err == nil
+	return
+}
+
+func (c *CopyrightLedger) verifySetRoyaltySplit(actData []byte) (ret interface{}, err error) {
+	split := copyrightData.RoyaltySplit{}
+	err = json.Unmarshal(actData, &split)
+	if err != nil {
+		return nil, errors.New("invalid royalty split data: " + err.Error())
+	}
+
+	if len(split.Shares) == 0 {
+		return nil, errors.New("no royalty shares")
+	}
+
+	var totalPoints uint64 = 0
+	for _, share := range split.Shares {
+		if len(share.Payee) == 0 || share.Points == 0 {
+			return nil, errors.New("invalid royalty share")
+		}
+		totalPoints += uint64(share.Points)
+	}
+
+	if totalPoints != copyrightData.TotalRoyaltyPoints {
+		return nil, errors.New("royalty shares are not summed to 100 percent")
+	}
+
+	cert, exists, err := c.getCertificate(split.CertificateID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no such certificate")
+	}
+
+	if !bytes.Equal(split.OwnerSeal.SignerPublicKey, cert.Owner) {
+		return nil, errors.New("royalty split not set by the owner of the certificate")
+	}
+
+	current, exists, err := c.getRoyaltySplit(split.CertificateID)
+	if err != nil {
+		return
+	}
+
+	if split.Sequence == 0 || (exists && split.Sequence <= current.Sequence) {
+		return nil, fmt.Errorf("royalty split sequence must be greater than %d", current.Sequence)
+	}
+
+	splitData, _ := structSerializer.ToMFBytes(split.RoyaltySplitData)
+	_, err = split.OwnerSeal.Verify(splitData, c.CryptoTools.HashCalculator)
+	if err != nil {
+		return nil, errors.New("invalid seal of the owner: " + err.Error())
+	}
+
+	return split.OwnerSeal.Hash, nil
+}
+
+func (c *CopyrightLedger) setRoyaltySplit(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	split := copyrightData.RoyaltySplit{}
+	_ = json.Unmarshal(actData, &split)
+
+	split.Transaction = ctx.Transaction
+	split.Time = time.Unix(ctx.Time, 0).Format(common.BASIC_TIME_FORMAT)
+
+	data, _ := json.Marshal(split)
+	err = c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.RoyaltySplit, split.CertificateID),
+		Data:   data,
+		Exists: true,
+	})
+	if err != nil {
+		return
+	}
+
+	return split, nil
+}
+
+//shareAmounts splits the amount by the points of the shares, the remainder of the division goes to the first share
+func shareAmounts(amount *big.Int, shares []copyrightData.RoyaltyShare) (list []*big.Int, err error) {
+	total := big.NewInt(copyrightData.TotalRoyaltyPoints)
+	left := big.NewInt(0).Set(amount)
+
+	for _, share := range shares {
+		shareAmount := big.NewInt(0).Mul(amount, big.NewInt(int64(share.Points)))
+		shareAmount.Div(shareAmount, total)
+		if shareAmount.Sign() == 0 {
+			return nil, errors.New("amount is too small to split")
+		}
+
+		left.Sub(left, shareAmount)
+		list = append(list, shareAmount)
+	}
+
+	list[0].Add(list[0], left)
+	return
+}
+
+func (c *CopyrightLedger) verifyPayLicense(actData []byte) (ret interface{}, err error) {
+	payment := copyrightData.LicensePayment{}
+	err = json.Unmarshal(actData, &payment)
+	if err != nil {
+		return nil, errors.New("invalid license payment data: " + err.Error())
+	}
+
+	amount, valid := big.NewInt(0).SetString(payment.Amount, 10)
+	if !valid || amount.Sign() <= 0 {
+		return nil, errors.New("invalid payment amount")
+	}
+
+	license, exists, err := c.getLicense(payment.LicenseID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no such license")
+	}
+
+	if !bytes.Equal(payment.PayerSeal.SignerPublicKey, license.Licensee) {
+		return nil, errors.New("license not paid by the licensee")
+	}
+
+	paymentData, _ := structSerializer.ToMFBytes(payment.LicensePaymentData)
+	_, err = payment.PayerSeal.Verify(paymentData, c.CryptoTools.HashCalculator)
+	if err != nil {
+		return nil, errors.New("invalid seal of the payer: " + err.Error())
+	}
+
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.Payment, payment.PayerSeal.Hash))
+	if err != nil {
+		return
+	}
+
+	if kv.Exists {
+		return nil, errors.New("payment already recorded")
+	}
+
+	split, exists, err := c.getRoyaltySplit(license.CertificateID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no royalty split of the certificate")
+	}
+
+	if len(payment.Payments) != len(split.Shares) {
+		return nil, errors.New("payments not match the royalty shares")
+	}
+
+	amounts, err := shareAmounts(amount, split.Shares)
+	if err != nil {
+		return
+	}
+
+	for i, share := range split.Shares {
+		paid, paidErr := paidAmount(payment.Settlement, payment.Assets, payment.Payments[i], license.Licensee, share.Payee)
+		if paidErr != nil {
+			return nil, paidErr
+		}
+
+		if paid.Cmp(amounts[i]) != 0 {
+			return nil, errors.New("payment not pays the share of " + hex.EncodeToString(share.Payee))
+		}
+
+		err = c.verifySettlement(payment.Settlement, payment.Payments[i])
+		if err != nil {
+			return
+		}
+	}
+
+	return payment.PayerSeal.Hash, nil
+}
+
+func (c *CopyrightLedger) payLicense(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	payment := copyrightData.LicensePayment{}
+	_ = json.Unmarshal(actData, &payment)
+
+	payment.ID = payment.PayerSeal.Hash
+	payment.Transaction = ctx.Transaction
+	payment.Time = time.Unix(ctx.Time, 0).Format(common.BASIC_TIME_FORMAT)
+
+	for i, p := range payment.Payments {
+		err = c.settle(payment.Settlement, p, settlementUndoKey(payment.ID, i))
+		if err != nil {
+			return
+		}
+	}
+
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, ctx.Height)
+
+	data, _ := json.Marshal(payment)
+	err = c.KVStorage.BatchPut([]kvDatabase.KVItem{
+		{
+			Key:    c.buildKey(StoragePrefixes.Payment, payment.ID),
+			Data:   data,
+			Exists: true,
+		},
+		{
+			Key:    c.buildKey(StoragePrefixes.PaymentOfLicense, payment.LicenseID, heightBytes, payment.ID),
+			Data:   payment.ID,
+			Exists: true,
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	return payment, nil
+}
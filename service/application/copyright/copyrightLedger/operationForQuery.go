@@ -0,0 +1,215 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"strconv"
+)
+
+func hexFromParam(param []string) (data []byte, err error) {
+	if len(param) != 1 {
+		err = errors.New("invalid parameters")
+		return
+	}
+
+	return hex.DecodeString(param[0])
+}
+
+func (c *CopyrightLedger) queryCertificate(param []string) (ret interface{}, err error) {
+	id, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	cert, exists, err := c.getCertificate(id)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no such certificate")
+	}
+
+	return cert, nil
+}
+
+func (c *CopyrightLedger) queryCertificateByResource(param []string) (ret interface{}, err error) {
+	resourceHash, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.Resource, resourceHash))
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		return nil, errors.New("resource not registered")
+	}
+
+	cert, _, err := c.getCertificate(kv.Data)
+	return cert, err
+}
+
+func (c *CopyrightLedger) queryCertificatesOfOwner(param []string) (ret interface{}, err error) {
+	owner, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	certList := []copyrightData.CopyrightCertificate{}
+	for _, kv := range c.KVStorage.Traversal(c.buildKey(StoragePrefixes.Owner, owner)) {
+		cert, exists, _ := c.getCertificate(kv.Data)
+		if exists {
+			certList = append(certList, cert)
+		}
+	}
+
+	return certList, nil
+}
+
+func (c *CopyrightLedger) queryTradings(param []string) (ret interface{}, err error) {
+	certID, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	tradingList := []copyrightData.CopyrightTrading{}
+	for _, idx := range c.KVStorage.Traversal(c.buildKey(StoragePrefixes.TradingOfCertificate, certID)) {
+		kv, _ := c.KVStorage.Get(c.buildKey(StoragePrefixes.Trading, idx.Data))
+		if !kv.Exists {
+			continue
+		}
+
+		trading := copyrightData.CopyrightTrading{}
+		if json.Unmarshal(kv.Data, &trading) == nil {
+			tradingList = append(tradingList, trading)
+		}
+	}
+
+	return tradingList, nil
+}
+
+func (c *CopyrightLedger) queryLicensesOfCertificate(param []string) (ret interface{}, err error) {
+	certID, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	return c.licensesUnder(c.buildKey(StoragePrefixes.LicenseOfCertificate, certID)), nil
+}
+
+func (c *CopyrightLedger) queryLicensesOfLicensee(param []string) (ret interface{}, err error) {
+	licensee, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	return c.licensesUnder(c.buildKey(StoragePrefixes.LicenseOfLicensee, licensee)), nil
+}
+
+func (c *CopyrightLedger) queryRoyaltySplit(param []string) (ret interface{}, err error) {
+	certID, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	split, exists, err := c.getRoyaltySplit(certID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no royalty split of the certificate")
+	}
+
+	return split, nil
+}
+
+func (c *CopyrightLedger) queryLicensePayments(param []string) (ret interface{}, err error) {
+	licenseID, err := hexFromParam(param)
+	if err != nil {
+		return
+	}
+
+	paymentList := []copyrightData.LicensePayment{}
+	for _, idx := range c.KVStorage.Traversal(c.buildKey(StoragePrefixes.PaymentOfLicense, licenseID)) {
+		kv, _ := c.KVStorage.Get(c.buildKey(StoragePrefixes.Payment, idx.Data))
+		if !kv.Exists {
+			continue
+		}
+
+		payment := copyrightData.LicensePayment{}
+		if json.Unmarshal(kv.Data, &payment) == nil {
+			paymentList = append(paymentList, payment)
+		}
+	}
+
+	return paymentList, nil
+}
+
+//proveLicense takes the hex certificate id, the hex licensee, the scope, the territory and the unix time,
+//and returns the licenses of the licensee covering them
+func (c *CopyrightLedger) proveLicense(param []string) (ret interface{}, err error) {
+	if len(param) != 5 {
+		return nil, errors.New("invalid parameters")
+	}
+
+	certID, err := hex.DecodeString(param[0])
+	if err != nil {
+		return
+	}
+
+	licensee, err := hex.DecodeString(param[1])
+	if err != nil {
+		return
+	}
+
+	at, err := strconv.ParseInt(param[4], 10, 64)
+	if err != nil {
+		return
+	}
+
+	proof := copyrightData.LicenseProof{
+		Licenses: []copyrightData.LicenseGrant{},
+	}
+
+	for _, license := range c.licensesUnder(c.buildKey(StoragePrefixes.LicenseOfLicensee, licensee)) {
+		if !bytes.Equal(license.CertificateID, certID) {
+			continue
+		}
+
+		if !covers(license.Scope, param[2]) || !covers(license.Territory, param[3]) {
+			continue
+		}
+
+		if at < license.StartTime || (license.EndTime != 0 && at >= license.EndTime) {
+			continue
+		}
+
+		proof.Licenses = append(proof.Licenses, license)
+	}
+
+	proof.Licensed = len(proof.Licenses) > 0
+	return proof, nil
+}
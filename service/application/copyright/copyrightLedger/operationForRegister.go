@@ -0,0 +1,155 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/common"
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"time"
+)
+
+func (c *CopyrightLedger) getCertificate(id []byte) (cert copyrightData.CopyrightCertificate, exists bool, err error) {
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.Certificate, id))
+	if err != nil || !kv.Exists {
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &cert)
+	exists = err == nil
+	return
+}
+
+func (c *CopyrightLedger) storeCertificate(cert copyrightData.CopyrightCertificate) (err error) {
+	data, _ := json.Marshal(cert)
+	return c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.Certificate, cert.ID),
+		Data:   data,
+		Exists: true,
+	})
+}
+
+func (c *CopyrightLedger) storeOwnerIndex(owner []byte, id []byte) (err error) {
+	return c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.Owner, owner, id),
+		Data:   id,
+		Exists: true,
+	})
+}
+
+func (c *CopyrightLedger) removeOwnerIndex(owner []byte, id []byte) (err error) {
+	return c.KVStorage.Delete(c.buildKey(StoragePrefixes.Owner, owner, id))
+}
+
+func (c *CopyrightLedger) verifyRegister(actData []byte) (ret interface{}, err error) {
+	cert := copyrightData.CopyrightCertificate{}
+	err = json.Unmarshal(actData, &cert)
+	if err != nil {
+		return nil, errors.New("invalid certificate data: " + err.Error())
+	}
+
+	if len(cert.BasicHash) == 0 {
+		return nil, errors.New("no resource hash")
+	}
+
+	if !bytes.Equal(cert.ApplicantSeal.SignerPublicKey, cert.Applicant) {
+		return nil, errors.New("applicant seal not signed by the applicant")
+	}
+
+	if !bytes.Equal(cert.OwnSeal.SignerPublicKey, cert.Owner) {
+		return nil, errors.New("own seal not signed by the owner")
+	}
+
+	hashCalc := c.CryptoTools.HashCalculator
+	appData, _ := structSerializer.ToMFBytes(cert.ApplicationData())
+	_, err = cert.ApplicantSeal.Verify(appData, hashCalc)
+	if err != nil {
+		return nil, errors.New("invalid applicant seal: " + err.Error())
+	}
+
+	_, err = cert.OwnSeal.Verify(appData, hashCalc)
+	if err != nil {
+		return nil, errors.New("invalid own seal: " + err.Error())
+	}
+
+	if !c.isPlatform(cert.PlatformSeal.SignerPublicKey) {
+		return nil, errors.New("certificate not sealed by a known platform")
+	}
+
+	platformData, _ := structSerializer.ToMFBytes(cert.PlatformSignedData())
+	_, err = cert.PlatformSeal.Verify(platformData, hashCalc)
+	if err != nil {
+		return nil, errors.New("invalid platform seal: " + err.Error())
+	}
+
+	_, exists, err := c.getCertificate(cert.ApplicantSeal.Hash)
+	if err != nil {
+		return
+	}
+
+	if exists {
+		return nil, errors.New("certificate already registered")
+	}
+
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.Resource, cert.BasicHash))
+	if err != nil {
+		return
+	}
+
+	if kv.Exists {
+		return nil, errors.New("resource already registered")
+	}
+
+	return cert.ApplicantSeal.Hash, nil
+}
+
+func (c *CopyrightLedger) register(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	cert := copyrightData.CopyrightCertificate{}
+	_ = json.Unmarshal(actData, &cert)
+
+	cert.ID = cert.ApplicantSeal.Hash
+	cert.IssuanceTransaction = ctx.Transaction
+	cert.IssuanceTime = time.Unix(ctx.Time, 0).Format(common.BASIC_TIME_FORMAT)
+	cert.IssuanceType = copyrightData.CopyrightActionTypes.Register.String()
+	cert.Algorithm.Hash = c.CryptoTools.HashCalculator.Name()
+	cert.Algorithm.Sign = cert.PlatformSeal.SignerAlgorithm
+
+	err = c.storeCertificate(cert)
+	if err != nil {
+		return
+	}
+
+	err = c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.Resource, cert.BasicHash),
+		Data:   cert.ID,
+		Exists: true,
+	})
+	if err != nil {
+		return
+	}
+
+	err = c.storeOwnerIndex(cert.Owner, cert.ID)
+	if err != nil {
+		return
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SealSC/SealABC/service/application/basicAssets/basicAssetsLedger"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsLedger"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"math/big"
+)
+
+//settlementRecord is an executed settlement in another application, kept to roll it back
+type settlementRecord struct {
+	Application string
+	Request     chainStructure.InternalCallRequest
+}
+
+func (c *CopyrightLedger) internalCall(app string, req chainStructure.InternalCallRequest) (interface{}, error) {
+	if c.chain == nil {
+		return nil, errors.New("no chain for settlements")
+	}
+
+	return c.chain.InternalCall(copyrightData.APPName, app, req.Bytes())
+}
+
+func settlementAction(app string) (action string, err error) {
+	switch app {
+	case copyrightData.SettleInBasicAssets:
+		return basicAssetsLedger.TransactionTypes.Transfer.String(), nil
+
+	case copyrightData.SettleInSmartAssets:
+		return smartAssetsLedger.TxType.Transfer.String(), nil
+	}
+
+	return "", errors.New("unsupported settlement application: " + app)
+}
+
+//paidAmount returns the amount the signed transfer pays to the payee, the transfer must be signed by the payer
+func paidAmount(app string, assets []byte, payment []byte, payer []byte, payee []byte) (amount *big.Int, err error) {
+	amount = big.NewInt(0)
+
+	switch app {
+	case copyrightData.SettleInBasicAssets:
+		tx := basicAssetsLedger.Transaction{}
+		err = json.Unmarshal(payment, &tx)
+		if err != nil {
+			return
+		}
+
+		if !bytes.Equal(tx.Seal.SignerPublicKey, payer) || !bytes.Equal(tx.Assets.MetaSeal.Hash, assets) {
+			return nil, errors.New("payment not signed by the payer or not in the assets")
+		}
+
+		//outputs with locks can't be spent by the payee at once, so they are not counted as paid
+		for _, out := range tx.Output {
+			if !bytes.Equal(out.To, payee) || out.Lock.IsLocked() {
+				continue
+			}
+
+			value, parseErr := basicAssetsLedger.ParseAmount(out.Value)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			amount.Add(amount, value)
+		}
+
+	case copyrightData.SettleInSmartAssets:
+		tx := smartAssetsLedger.Transaction{}
+		err = json.Unmarshal(payment, &tx)
+		if err != nil {
+			return
+		}
+
+		if len(assets) != 0 {
+			return nil, errors.New("smart assets payments are paid in the base assets")
+		}
+
+		if !bytes.Equal(tx.DataSeal.SignerPublicKey, payer) {
+			return nil, errors.New("payment not signed by the payer")
+		}
+
+		if bytes.Equal(tx.To, payee) {
+			value, valid := big.NewInt(0).SetString(tx.Value, 10)
+			if !valid {
+				return nil, errors.New("invalid payment value")
+			}
+			amount = value
+		}
+
+	default:
+		return nil, errors.New("unsupported settlement application: " + app)
+	}
+
+	return
+}
+
+func (c *CopyrightLedger) verifySettlement(app string, payment []byte) (err error) {
+	action, err := settlementAction(app)
+	if err != nil {
+		return
+	}
+
+	_, err = c.internalCall(app, chainStructure.InternalCallRequest{
+		Type:   chainStructure.InternalCallVerify,
+		Action: action,
+		Data:   payment,
+	})
+	return
+}
+
+func settlementUndoKey(paymentID []byte, index int) []byte {
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, uint32(index))
+	return append(append([]byte{}, paymentID...), indexBytes...)
+}
+
+//settle executes the payment in the settlement application and records it to roll back with the executing actions
+func (c *CopyrightLedger) settle(app string, payment []byte, undoKey []byte) (err error) {
+	action, err := settlementAction(app)
+	if err != nil {
+		return
+	}
+
+	req := chainStructure.InternalCallRequest{
+		Type:    chainStructure.InternalCallExecute,
+		Action:  action,
+		Data:    payment,
+		UndoKey: undoKey,
+	}
+
+	_, err = c.internalCall(app, req)
+	if err != nil {
+		return fmt.Errorf("settle payment in %s failed: %s", app, err.Error())
+	}
+
+	c.settlements = append(c.settlements, settlementRecord{
+		Application: app,
+		Request:     req,
+	})
+	return
+}
+
+func (c *CopyrightLedger) rollbackSettlements(list []settlementRecord) (err error) {
+	for i := len(list) - 1; i >= 0; i-- {
+		req := list[i].Request
+		req.Type = chainStructure.InternalCallRollback
+
+		_, err = c.internalCall(list[i].Application, req)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightLedger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/common"
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"math/big"
+	"time"
+)
+
+func (c *CopyrightLedger) verifyTrading(actData []byte, tradingType string) (trading copyrightData.CopyrightTrading, cert copyrightData.CopyrightCertificate, err error) {
+	err = json.Unmarshal(actData, &trading)
+	if err != nil {
+		err = errors.New("invalid trading data: " + err.Error())
+		return
+	}
+
+	if len(trading.To) == 0 || bytes.Equal(trading.From, trading.To) {
+		err = errors.New("invalid trading target")
+		return
+	}
+
+	if trading.Price != "" {
+		price, ok := big.NewInt(0).SetString(trading.Price, 10)
+		if !ok || price.Sign() < 0 {
+			err = errors.New("invalid price")
+			return
+		}
+	}
+
+	if trading.ExpireTime < 0 {
+		err = errors.New("invalid expire time")
+		return
+	}
+
+	cert, exists, err := c.getCertificate(trading.CertificateID)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		err = errors.New("no such certificate")
+		return
+	}
+
+	if !bytes.Equal(cert.Owner, trading.From) || !bytes.Equal(trading.FromSeal.SignerPublicKey, trading.From) {
+		err = errors.New("trading not signed by the owner of the certificate")
+		return
+	}
+
+	hashCalc := c.CryptoTools.HashCalculator
+	tradingData, _ := structSerializer.ToMFBytes(trading.CopyrightTradingData)
+	_, err = trading.FromSeal.Verify(tradingData, hashCalc)
+	if err != nil {
+		err = errors.New("invalid seal of the owner: " + err.Error())
+		return
+	}
+
+	//transfer only needs the owner's seal, license and trade are agreed by both sides
+	if tradingType != copyrightData.CopyrightActionTypes.Transfer.String() {
+		if !bytes.Equal(trading.ToSeal.SignerPublicKey, trading.To) {
+			err = errors.New("trading not signed by the target")
+			return
+		}
+
+		_, err = trading.ToSeal.Verify(tradingData, hashCalc)
+		if err != nil {
+			err = errors.New("invalid seal of the target: " + err.Error())
+			return
+		}
+	}
+
+	kv, err := c.KVStorage.Get(c.buildKey(StoragePrefixes.Trading, trading.FromSeal.Hash))
+	if err != nil {
+		return
+	}
+
+	if kv.Exists {
+		err = errors.New("trading already recorded")
+	}
+
+	return
+}
+
+func (c *CopyrightLedger) verifyTransfer(actData []byte) (ret interface{}, err error) {
+	trading, _, err := c.verifyTrading(actData, copyrightData.CopyrightActionTypes.Transfer.String())
+	return trading.FromSeal.Hash, err
+}
+
+func (c *CopyrightLedger) verifyLicense(actData []byte) (ret interface{}, err error) {
+	trading, _, err := c.verifyTrading(actData, copyrightData.CopyrightActionTypes.License.String())
+	return trading.FromSeal.Hash, err
+}
+
+func (c *CopyrightLedger) verifyTrade(actData []byte) (ret interface{}, err error) {
+	trading, _, err := c.verifyTrading(actData, copyrightData.CopyrightActionTypes.Trade.String())
+	return trading.FromSeal.Hash, err
+}
+
+func (c *CopyrightLedger) buildTradingIndexKey(certID []byte, height uint64, tradingID []byte) []byte {
+	heightBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(heightBytes, height)
+	return c.buildKey(StoragePrefixes.TradingOfCertificate, certID, heightBytes, tradingID)
+}
+
+func (c *CopyrightLedger) recordTrading(ctx ActionContext, tradingType string, actData []byte) (result copyrightData.TradingResult, err error) {
+	trading := copyrightData.CopyrightTrading{}
+	_ = json.Unmarshal(actData, &trading)
+
+	cert, _, err := c.getCertificate(trading.CertificateID)
+	if err != nil {
+		return
+	}
+
+	trading.ID = trading.FromSeal.Hash
+	trading.Type = tradingType
+	trading.Transaction = ctx.Transaction
+	trading.Time = time.Unix(ctx.Time, 0).Format(common.BASIC_TIME_FORMAT)
+
+	data, _ := json.Marshal(trading)
+	err = c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildKey(StoragePrefixes.Trading, trading.ID),
+		Data:   data,
+		Exists: true,
+	})
+	if err != nil {
+		return
+	}
+
+	err = c.KVStorage.Put(kvDatabase.KVItem{
+		Key:    c.buildTradingIndexKey(cert.ID, ctx.Height, trading.ID),
+		Data:   trading.ID,
+		Exists: true,
+	})
+	if err != nil {
+		return
+	}
+
+	//a license keeps the owner, transfer and trade move the certificate to the target
+	if tradingType != copyrightData.CopyrightActionTypes.License.String() {
+		err = c.removeOwnerIndex(cert.Owner, cert.ID)
+		if err != nil {
+			return
+		}
+
+		cert.Owner = trading.To
+		err = c.storeCertificate(cert)
+		if err != nil {
+			return
+		}
+
+		err = c.storeOwnerIndex(cert.Owner, cert.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	result.Trading = trading
+	result.Certificate = cert
+	return
+}
+
+func (c *CopyrightLedger) transfer(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	return c.recordTrading(ctx, copyrightData.CopyrightActionTypes.Transfer.String(), actData)
+}
+
+func (c *CopyrightLedger) license(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	return c.recordTrading(ctx, copyrightData.CopyrightActionTypes.License.String(), actData)
+}
+
+func (c *CopyrightLedger) trade(ctx ActionContext, actData []byte) (ret interface{}, err error) {
+	return c.recordTrading(ctx, copyrightData.CopyrightActionTypes.Trade.String(), actData)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLStorage
+
+import (
+	"errors"
+	"github.com/SealSC/SealABC/metadata/httpJSONResult/rowsWithCount"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightSQLTables"
+)
+
+const rowsPerPage = 20
+
+func (s *Storage) pagedRows(rowType interface{}, table string, condition string, args []interface{}, page uint64) (ret interface{}, err error) {
+	count, err := s.Driver.RowCount(table, condition, args)
+	if err != nil {
+		return
+	}
+
+	pSQL := "select * from " +
+		"`" + table + "` " +
+		condition + " order by `c_id` desc limit ?,?"
+
+	rows, err := s.Driver.Query(rowType, pSQL, append(args, page * rowsPerPage, rowsPerPage))
+	if err != nil {
+		return
+	}
+
+	ret = rowsWithCount.Entity {
+		Rows:  rows,
+		Total: count,
+	}
+
+	return
+}
+
+func (s *Storage) GetCertificateList(p []string) (ret interface{}, err error) {
+	page, err := pageFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Certificates.Name()
+	return s.pagedRows(copyrightSQLTables.CertificatesRow{}, table, "", nil, page)
+}
+
+func (s *Storage) getCertificateBy(col string, p []string) (ret interface{}, err error) {
+	hash, err := hashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Certificates.Name()
+	rows, err := s.Driver.SimpleSelect(copyrightSQLTables.CertificatesRow{}, table, col, hash)
+	if err != nil {
+		return
+	}
+
+	if len(rows) != 1 {
+		err = errors.New("no such certificate")
+		return
+	}
+
+	ret = rows[0]
+	return
+}
+
+func (s *Storage) GetCertificate(p []string) (ret interface{}, err error) {
+	return s.getCertificateBy("c_certificate_id", p)
+}
+
+func (s *Storage) GetCertificateByResource(p []string) (ret interface{}, err error) {
+	return s.getCertificateBy("c_basic_hash", p)
+}
+
+func (s *Storage) GetCertificatesOfOwner(p []string) (ret interface{}, err error) {
+	page, owner, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Certificates.Name()
+	return s.pagedRows(copyrightSQLTables.CertificatesRow{}, table, "where `c_owner`=?", []interface{}{owner}, page)
+}
+
+func (s *Storage) GetTradingsOfCertificate(p []string) (ret interface{}, err error) {
+	page, certID, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Tradings.Name()
+	return s.pagedRows(copyrightSQLTables.TradingsRow{}, table, "where `c_certificate_id`=?", []interface{}{certID}, page)
+}
+
+func (s *Storage) GetTradingsOfAddress(p []string) (ret interface{}, err error) {
+	page, address, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Tradings.Name()
+	return s.pagedRows(copyrightSQLTables.TradingsRow{}, table, "where `c_from`=? or `c_to`=?", []interface{}{address, address}, page)
+}
+
+func (s *Storage) GetLicensesOfCertificate(p []string) (ret interface{}, err error) {
+	page, certID, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Licenses.Name()
+	return s.pagedRows(copyrightSQLTables.LicensesRow{}, table, "where `c_certificate_id`=?", []interface{}{certID}, page)
+}
+
+func (s *Storage) GetLicensesOfLicensee(p []string) (ret interface{}, err error) {
+	page, licensee, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.Licenses.Name()
+	return s.pagedRows(copyrightSQLTables.LicensesRow{}, table, "where `c_licensee`=?", []interface{}{licensee}, page)
+}
+
+func (s *Storage) GetPaymentsOfLicense(p []string) (ret interface{}, err error) {
+	page, licenseID, err := pageAndHashFromParam(p)
+	if err != nil {
+		return
+	}
+
+	table := copyrightSQLTables.LicensePayments.Name()
+	return s.pagedRows(copyrightSQLTables.LicensePaymentsRow{}, table, "where `c_license_id`=?", []interface{}{licenseID}, page)
+}
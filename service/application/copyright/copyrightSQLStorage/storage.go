@@ -0,0 +1,76 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLStorage
+
+import (
+	"errors"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+)
+
+var QueryTypes struct {
+	CertificateList       enum.Element
+	Certificate           enum.Element
+	CertificateByResource enum.Element
+	CertificatesOfOwner   enum.Element
+	TradingsOfCertificate enum.Element
+	TradingsOfAddress     enum.Element
+
+	LicensesOfCertificate enum.Element
+	LicensesOfLicensee    enum.Element
+	PaymentsOfLicense     enum.Element
+}
+
+type queryHandler func([]string) (interface{}, error)
+type Storage struct {
+	queryHandlers map[string] queryHandler
+	Driver        simpleSQLDatabase.IDriver
+}
+
+func Load() {
+	enum.SimpleBuild(&QueryTypes)
+}
+
+func NewStorage(sqlDriver simpleSQLDatabase.IDriver) (s *Storage) {
+	s = &Storage{
+		Driver: sqlDriver,
+	}
+
+	s.queryHandlers = map[string] queryHandler {
+		QueryTypes.CertificateList.String():       s.GetCertificateList,
+		QueryTypes.Certificate.String():           s.GetCertificate,
+		QueryTypes.CertificateByResource.String(): s.GetCertificateByResource,
+		QueryTypes.CertificatesOfOwner.String():   s.GetCertificatesOfOwner,
+		QueryTypes.TradingsOfCertificate.String(): s.GetTradingsOfCertificate,
+		QueryTypes.TradingsOfAddress.String():     s.GetTradingsOfAddress,
+
+		QueryTypes.LicensesOfCertificate.String(): s.GetLicensesOfCertificate,
+		QueryTypes.LicensesOfLicensee.String():    s.GetLicensesOfLicensee,
+		QueryTypes.PaymentsOfLicense.String():     s.GetPaymentsOfLicense,
+	}
+	return
+}
+
+func (s *Storage) DoQuery(queryReq copyrightData.QueryRequest) (result interface{}, err error) {
+	if handler, exists := s.queryHandlers[queryReq.QueryType]; !exists {
+		err = errors.New("no such query handler: " + queryReq.QueryType)
+		return
+	} else {
+		return handler(queryReq.Parameter)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLStorage
+
+import (
+	"encoding/hex"
+	"github.com/SealSC/SealABC/log"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightSQLTables"
+)
+
+func (s *Storage) StoreCertificate(height uint64, cert copyrightData.CopyrightCertificate) (err error) {
+	rows := copyrightSQLTables.Certificates.NewRows().(copyrightSQLTables.CertificatesRows)
+	rows.InsertCertificate(height, cert)
+	_, err = s.Driver.Insert(&rows, true)
+	if err != nil {
+		log.Log.Error("insert certificate to sql database failed: ", err.Error())
+	}
+
+	return
+}
+
+func (s *Storage) StoreTrading(height uint64, result copyrightData.TradingResult) (err error) {
+	rows := copyrightSQLTables.Tradings.NewRows().(copyrightSQLTables.TradingsRows)
+	rows.InsertTrading(height, result.Trading)
+	_, err = s.Driver.Insert(&rows, true)
+	if err != nil {
+		log.Log.Error("insert trading to sql database failed: ", err.Error())
+		return
+	}
+
+	if result.Trading.Type == copyrightData.CopyrightActionTypes.License.String() {
+		return
+	}
+
+	certRows := copyrightSQLTables.Certificates.NewRows().(copyrightSQLTables.CertificatesRows)
+	certRows.InsertCertificate(height, result.Certificate)
+
+	fields, condition := certRows.GetOwnerUpdateInfo()
+	_, err = s.Driver.Update(&certRows, fields, condition, []interface{}{hex.EncodeToString(result.Certificate.ID)})
+	if err != nil {
+		log.Log.Error("update certificate owner failed: ", err.Error())
+	}
+
+	return
+}
+
+func (s *Storage) StoreLicense(height uint64, license copyrightData.LicenseGrant) (err error) {
+	rows := copyrightSQLTables.Licenses.NewRows().(copyrightSQLTables.LicensesRows)
+	rows.InsertLicense(height, license)
+	_, err = s.Driver.Insert(&rows, true)
+	if err != nil {
+		log.Log.Error("insert license to sql database failed: ", err.Error())
+	}
+
+	return
+}
+
+func (s *Storage) StoreLicensePayment(height uint64, payment copyrightData.LicensePayment) (err error) {
+	rows := copyrightSQLTables.LicensePayments.NewRows().(copyrightSQLTables.LicensePaymentsRows)
+	rows.InsertPayment(height, payment)
+	_, err = s.Driver.Insert(&rows, true)
+	if err != nil {
+		log.Log.Error("insert license payment to sql database failed: ", err.Error())
+	}
+
+	return
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLTables
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+)
+
+type CertificatesTable struct {
+	ID            enum.Element `col:"c_id" ignoreInsert:"true"`
+	Height        enum.Element `col:"c_height"`
+	Transaction   enum.Element `col:"c_tx_hash"`
+	CertificateID enum.Element `col:"c_certificate_id"`
+	Applicant     enum.Element `col:"c_applicant"`
+	Owner         enum.Element `col:"c_owner"`
+	BasicHash     enum.Element `col:"c_basic_hash"`
+	ExtraHash     enum.Element `col:"c_extra_hash"`
+	Platform      enum.Element `col:"c_platform"`
+	Time          enum.Element `col:"c_time"`
+
+	simpleSQLDatabase.BasicTable
+}
+
+var Certificates CertificatesTable
+
+func (c CertificatesTable) NewRows() interface{} {
+	return simpleSQLDatabase.NewRowsInstance(CertificatesRows{})
+}
+
+func (c CertificatesTable) Name() (name string) {
+	return "t_copyright_certificates"
+}
+
+func (c *CertificatesTable) load() {
+	enum.SimpleBuild(c)
+	c.Instance = *c
+}
+
+type CertificatesRow struct {
+	ID            string
+	Height        string
+	Transaction   string
+	CertificateID string
+	Applicant     string
+	Owner         string
+	BasicHash     string
+	ExtraHash     string
+	Platform      string
+	Time          string
+}
+
+type CertificatesRows struct {
+	simpleSQLDatabase.BasicRows
+}
+
+func (c *CertificatesRows) InsertCertificate(height uint64, cert copyrightData.CopyrightCertificate) {
+	newRow := CertificatesRow{
+		Height:        fmt.Sprintf("%d", height),
+		Transaction:   hex.EncodeToString(cert.IssuanceTransaction),
+		CertificateID: hex.EncodeToString(cert.ID),
+		Applicant:     hex.EncodeToString(cert.Applicant),
+		Owner:         hex.EncodeToString(cert.Owner),
+		BasicHash:     hex.EncodeToString(cert.BasicHash),
+		ExtraHash:     hex.EncodeToString(cert.ExtraHash),
+		Platform:      cert.PlatformSeal.HexPublicKey(),
+		Time:          cert.IssuanceTime,
+	}
+
+	c.Rows = append(c.Rows, newRow)
+}
+
+//GetOwnerUpdateInfo is used when the certificate was transferred or traded
+func (c *CertificatesRows) GetOwnerUpdateInfo() ([]string, string) {
+	return []string{
+		"Owner",
+	},
+	"where c_certificate_id=?"
+}
+
+func (c *CertificatesRows) Table() simpleSQLDatabase.ITable {
+	return &Certificates
+}
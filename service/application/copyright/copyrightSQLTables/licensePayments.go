@@ -0,0 +1,92 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLTables
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+)
+
+type LicensePaymentsTable struct {
+	ID          enum.Element `col:"c_id" ignoreInsert:"true"`
+	Height      enum.Element `col:"c_height"`
+	Transaction enum.Element `col:"c_tx_hash"`
+	PaymentID   enum.Element `col:"c_payment_id"`
+	LicenseID   enum.Element `col:"c_license_id"`
+	Payer       enum.Element `col:"c_payer"`
+	Settlement  enum.Element `col:"c_settlement"`
+	Assets      enum.Element `col:"c_assets"`
+	Amount      enum.Element `col:"c_amount"`
+	Time        enum.Element `col:"c_time"`
+
+	simpleSQLDatabase.BasicTable
+}
+
+var LicensePayments LicensePaymentsTable
+
+func (l LicensePaymentsTable) NewRows() interface{} {
+	return simpleSQLDatabase.NewRowsInstance(LicensePaymentsRows{})
+}
+
+func (l LicensePaymentsTable) Name() (name string) {
+	return "t_copyright_license_payments"
+}
+
+func (l *LicensePaymentsTable) load() {
+	enum.SimpleBuild(l)
+	l.Instance = *l
+}
+
+type LicensePaymentsRow struct {
+	ID          string
+	Height      string
+	Transaction string
+	PaymentID   string
+	LicenseID   string
+	Payer       string
+	Settlement  string
+	Assets      string
+	Amount      string
+	Time        string
+}
+
+type LicensePaymentsRows struct {
+	simpleSQLDatabase.BasicRows
+}
+
+func (l *LicensePaymentsRows) InsertPayment(height uint64, payment copyrightData.LicensePayment) {
+	newRow := LicensePaymentsRow{
+		Height:      fmt.Sprintf("%d", height),
+		Transaction: hex.EncodeToString(payment.Transaction),
+		PaymentID:   hex.EncodeToString(payment.ID),
+		LicenseID:   hex.EncodeToString(payment.LicenseID),
+		Payer:       payment.PayerSeal.HexPublicKey(),
+		Settlement:  payment.Settlement,
+		Assets:      hex.EncodeToString(payment.Assets),
+		Amount:      payment.Amount,
+		Time:        payment.Time,
+	}
+
+	l.Rows = append(l.Rows, newRow)
+}
+
+func (l *LicensePaymentsRows) Table() simpleSQLDatabase.ITable {
+	return &LicensePayments
+}
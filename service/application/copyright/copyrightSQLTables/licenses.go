@@ -0,0 +1,101 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLTables
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+)
+
+type LicensesTable struct {
+	ID            enum.Element `col:"c_id" ignoreInsert:"true"`
+	Height        enum.Element `col:"c_height"`
+	Transaction   enum.Element `col:"c_tx_hash"`
+	LicenseID     enum.Element `col:"c_license_id"`
+	CertificateID enum.Element `col:"c_certificate_id"`
+	Owner         enum.Element `col:"c_owner"`
+	Licensee      enum.Element `col:"c_licensee"`
+	Scope         enum.Element `col:"c_scope"`
+	Territory     enum.Element `col:"c_territory"`
+	StartTime     enum.Element `col:"c_start_time"`
+	EndTime       enum.Element `col:"c_end_time"`
+	Exclusive     enum.Element `col:"c_exclusive"`
+	Time          enum.Element `col:"c_time"`
+
+	simpleSQLDatabase.BasicTable
+}
+
+var Licenses LicensesTable
+
+func (l LicensesTable) NewRows() interface{} {
+	return simpleSQLDatabase.NewRowsInstance(LicensesRows{})
+}
+
+func (l LicensesTable) Name() (name string) {
+	return "t_copyright_licenses"
+}
+
+func (l *LicensesTable) load() {
+	enum.SimpleBuild(l)
+	l.Instance = *l
+}
+
+type LicensesRow struct {
+	ID            string
+	Height        string
+	Transaction   string
+	LicenseID     string
+	CertificateID string
+	Owner         string
+	Licensee      string
+	Scope         string
+	Territory     string
+	StartTime     string
+	EndTime       string
+	Exclusive     string
+	Time          string
+}
+
+type LicensesRows struct {
+	simpleSQLDatabase.BasicRows
+}
+
+func (l *LicensesRows) InsertLicense(height uint64, license copyrightData.LicenseGrant) {
+	newRow := LicensesRow{
+		Height:        fmt.Sprintf("%d", height),
+		Transaction:   hex.EncodeToString(license.Transaction),
+		LicenseID:     hex.EncodeToString(license.ID),
+		CertificateID: hex.EncodeToString(license.CertificateID),
+		Owner:         hex.EncodeToString(license.Owner),
+		Licensee:      hex.EncodeToString(license.Licensee),
+		Scope:         license.Scope,
+		Territory:     license.Territory,
+		StartTime:     fmt.Sprintf("%d", license.StartTime),
+		EndTime:       fmt.Sprintf("%d", license.EndTime),
+		Exclusive:     fmt.Sprintf("%t", license.Exclusive),
+		Time:          license.Time,
+	}
+
+	l.Rows = append(l.Rows, newRow)
+}
+
+func (l *LicensesRows) Table() simpleSQLDatabase.ITable {
+	return &Licenses
+}
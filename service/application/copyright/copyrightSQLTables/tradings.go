@@ -0,0 +1,95 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package copyrightSQLTables
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightData"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+)
+
+type TradingsTable struct {
+	ID            enum.Element `col:"c_id" ignoreInsert:"true"`
+	Height        enum.Element `col:"c_height"`
+	Transaction   enum.Element `col:"c_tx_hash"`
+	TradingID     enum.Element `col:"c_trading_id"`
+	CertificateID enum.Element `col:"c_certificate_id"`
+	Type          enum.Element `col:"c_type"`
+	From          enum.Element `col:"c_from"`
+	To            enum.Element `col:"c_to"`
+	Price         enum.Element `col:"c_price"`
+	ExpireTime    enum.Element `col:"c_expire_time"`
+	Time          enum.Element `col:"c_time"`
+
+	simpleSQLDatabase.BasicTable
+}
+
+var Tradings TradingsTable
+
+func (t TradingsTable) NewRows() interface{} {
+	return simpleSQLDatabase.NewRowsInstance(TradingsRows{})
+}
+
+func (t TradingsTable) Name() (name string) {
+	return "t_copyright_tradings"
+}
+
+func (t *TradingsTable) load() {
+	enum.SimpleBuild(t)
+	t.Instance = *t
+}
+
+type TradingsRow struct {
+	ID            string
+	Height        string
+	Transaction   string
+	TradingID     string
+	CertificateID string
+	Type          string
+	From          string
+	To            string
+	Price         string
+	ExpireTime    string
+	Time          string
+}
+
+type TradingsRows struct {
+	simpleSQLDatabase.BasicRows
+}
+
+func (t *TradingsRows) InsertTrading(height uint64, trading copyrightData.CopyrightTrading) {
+	newRow := TradingsRow{
+		Height:        fmt.Sprintf("%d", height),
+		Transaction:   hex.EncodeToString(trading.Transaction),
+		TradingID:     hex.EncodeToString(trading.ID),
+		CertificateID: hex.EncodeToString(trading.CertificateID),
+		Type:          trading.Type,
+		From:          hex.EncodeToString(trading.From),
+		To:            hex.EncodeToString(trading.To),
+		Price:         trading.Price,
+		ExpireTime:    fmt.Sprintf("%d", trading.ExpireTime),
+		Time:          trading.Time,
+	}
+
+	t.Rows = append(t.Rows, newRow)
+}
+
+func (t *TradingsRows) Table() simpleSQLDatabase.ITable {
+	return &Tradings
+}
@@ -18,29 +18,35 @@
 package copyright
 
 import (
+	"github.com/SealSC/SealABC/log"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightInterface"
+	"github.com/SealSC/SealABC/service/application/copyright/copyrightSQLTables"
 	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"github.com/SealSC/SealABC/storage/db"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
 )
 
 func Load() {
-
+	copyrightInterface.Load()
+	copyrightSQLTables.Load()
 }
 
 func NewCopyrightApplication(config *Config) (app chainStructure.IBlockchainExternalApplication, err error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	//
-	//kvDriver, err := db.NewKVDatabaseDriver(config.KVDBName, config.KVDBConfig)
-	//if err != nil {
-	//	log.Log.Error("can't load basic assets app for now: ", err.Error())
-	//	return
-	//}
-	//
-	//var sqlDriver simpleSQLDatabase.IDriver = nil
-	//if config.EnableSQLDB {
-	//	sqlDriver = config.SQLStorage
-	//}
 
-	//app, err = smartAssetsInterface.NewApplicationInterface(kvDriver, sqlDriver, config.CryptoTools, config.BaseAssets)
+	kvDriver, err := db.NewKVDatabaseDriver(config.KVDBName, config.KVDBConfig)
+	if err != nil {
+		log.Log.Error("can't load copyright app for now: ", err.Error())
+		return
+	}
+
+	var sqlDriver simpleSQLDatabase.IDriver = nil
+	if config.EnableSQLDB {
+		sqlDriver = config.SQLStorage
+	}
+
+	app = copyrightInterface.NewApplicationInterface(kvDriver, sqlDriver, config.CryptoTools, config.Platforms)
 	return
 }
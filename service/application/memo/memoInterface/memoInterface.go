@@ -68,8 +68,19 @@ func (m *MemoApplication) kvQuery(req memoSpace.QueryRequest) (result interface{
     return
 }
 
+//verifyClientReq verifies a memo request from client, it must be sealed by the signer of the memo
+func (m *MemoApplication) verifyClientReq(req blockchainRequest.Entity) (memo memoSpace.Memo, err error) {
+    _, memo, err = m.VerifyReq(req)
+    if err != nil {
+        return
+    }
+
+    err = req.RequireSigner(memo.Seal.SignerPublicKey)
+    return
+}
+
 func (m *MemoApplication) doRecord(req blockchainRequest.Entity) (result string, err error) {
-    _, _, err = m.VerifyReq(req)
+    _, err = m.verifyClientReq(req)
     if err != nil {
         log.Log.Error("verify memo failed: ", err.Error())
         return
@@ -108,7 +119,7 @@ func (m *MemoApplication) Query(req []byte) (result interface{}, err error) {
 }
 
 func (m *MemoApplication) PreExecute(req blockchainRequest.Entity, _ block.Entity) (result []byte, err error) {
-    _, _, err = m.VerifyReq(req)
+    _, err = m.verifyClientReq(req)
     return
 }
 
@@ -152,6 +163,72 @@ func (m *MemoApplication) Execute(
     return
 }
 
+func (m *MemoApplication) Rollback(
+        req blockchainRequest.Entity,
+        _ block.Entity,
+        _ uint32,
+    ) (err error) {
+
+    memo := memoSpace.Memo{}
+    err = json.Unmarshal(req.Data, &memo)
+    if err != nil {
+        return
+    }
+
+    m.operateLock.Lock()
+    defer m.operateLock.Unlock()
+
+    return m.kvStorage.Delete(memo.Seal.Hash)
+}
+
+//ApplicationInternalCall lets other applications query memos or verify, record and roll back a signed memo
+func (m *MemoApplication) ApplicationInternalCall(_ string, callData []byte) (ret interface{}, err error) {
+    req, err := chainStructure.ParseInternalCall(callData)
+    if err != nil {
+        return
+    }
+
+    if req.Type == chainStructure.InternalCallQuery {
+        return m.Query(req.Data)
+    }
+
+    memoReq := blockchainRequest.Entity{}
+    memoReq.RequestApplication = m.Name()
+    memoReq.RequestAction = applicationActions.Record.String()
+    memoReq.Data = req.Data
+
+    _, memo, err := m.VerifyReq(memoReq)
+    if err != nil {
+        return
+    }
+
+    switch req.Type {
+    case chainStructure.InternalCallVerify:
+        return memo.Seal.HexHash(), nil
+
+    case chainStructure.InternalCallExecute:
+        memoJson, _ := json.Marshal(memo)
+
+        m.operateLock.Lock()
+        defer m.operateLock.Unlock()
+
+        err = m.kvStorage.Put(kvDatabase.KVItem{
+            Key: memo.Seal.Hash,
+            Data: memoJson,
+        })
+        return memo.Seal.HexHash(), err
+
+    case chainStructure.InternalCallRollback:
+        m.operateLock.Lock()
+        defer m.operateLock.Unlock()
+
+        err = m.kvStorage.Delete(memo.Seal.Hash)
+        return
+    }
+
+    return nil, chainStructure.UnsupportedInternalCall(m.Name(), req)
+}
+
 func (m *MemoApplication) RequestsForBlock(_ block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
     m.operateLock.Lock()
     defer m.operateLock.Unlock()
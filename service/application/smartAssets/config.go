@@ -30,6 +30,14 @@ import (
 type Config struct {
 	commonCfg.Config
 	BaseAssets smartAssetsLedger.BaseAssetsData
+
+	//chain id of the signed ethereum transactions accepted by the application, zero disables them
+	EthChainID uint64
+
+	Gas        smartAssetsLedger.GasConfig
+
+	//admins and threshold of the contract upgrade approvals
+	Upgrade    smartAssetsLedger.UpgradeConfig
 }
 
 func DefaultConfig() *Config {
@@ -56,5 +64,9 @@ func DefaultConfig() *Config {
 			Increasable: false,
 			Owner:     "",
 		},
+
+		EthChainID: 0,
+		Gas:        smartAssetsLedger.DefaultGasConfig(),
+		Upgrade:    smartAssetsLedger.DefaultUpgradeConfig(),
 	}
 }
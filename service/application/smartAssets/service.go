@@ -51,6 +51,6 @@ func NewSmartAssetsApplication(config *Config) (app chainStructure.IBlockchainEx
 		sqlDriver = config.SQLStorage
 	}
 
-	app, err = smartAssetsInterface.NewApplicationInterface(kvDriver, sqlDriver, config.CryptoTools, config.BaseAssets)
+	app, err = smartAssetsInterface.NewApplicationInterface(kvDriver, sqlDriver, config.CryptoTools, config.BaseAssets, config.EthChainID, config.Gas, config.Upgrade)
 	return
 }
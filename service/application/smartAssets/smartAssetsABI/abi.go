@@ -0,0 +1,368 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsABI
+
+import (
+	"github.com/SealSC/SealABC/crypto/hashes/sha3"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const selectorLen = 4
+
+type Argument struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Indexed    bool       `json:"indexed,omitempty"`
+	Components []Argument `json:"components,omitempty"`
+}
+
+type entry struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	Inputs    []Argument `json:"inputs"`
+	Outputs   []Argument `json:"outputs"`
+	Anonymous bool       `json:"anonymous"`
+}
+
+//Method is a function or a custom error of a contract, custom errors are encoded the same as function calls
+type Method struct {
+	Name      string
+	Signature string
+	Selector  []byte
+
+	inputs      []Type
+	inputNames  []string
+	outputs     []Type
+	outputNames []string
+}
+
+type Event struct {
+	Name      string
+	Signature string
+	ID        []byte
+	Anonymous bool
+
+	inputs  []Type
+	names   []string
+	indexed []bool
+}
+
+type ABI struct {
+	Methods map[string] Method
+	Events  map[string] Event
+	Errors  map[string] Method
+}
+
+//NamedValue is a decoded value with its abi name and type
+type NamedValue struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+type DecodedCall struct {
+	Method string
+	Inputs []NamedValue
+}
+
+type DecodedLog struct {
+	Event  string
+	Inputs []NamedValue
+}
+
+func keccak256(data []byte) []byte {
+	return sha3.Keccak256.Sum(data)
+}
+
+func parseArguments(args []Argument) (types []Type, names []string, signature string, err error) {
+	var canonicals []string
+	for _, arg := range args {
+		t, typeErr := NewType(arg.Type, arg.Components)
+		if typeErr != nil {
+			return nil, nil, "", typeErr
+		}
+
+		types = append(types, t)
+		names = append(names, arg.Name)
+		canonicals = append(canonicals, t.canonical)
+	}
+
+	return types, names, "(" + strings.Join(canonicals, ",") + ")", nil
+}
+
+func newMethod(e entry) (m Method, err error) {
+	inputs, inputNames, sig, err := parseArguments(e.Inputs)
+	if err != nil {
+		return
+	}
+
+	outputs, outputNames, _, err := parseArguments(e.Outputs)
+	if err != nil {
+		return
+	}
+
+	m = Method{
+		Name:        e.Name,
+		Signature:   e.Name + sig,
+		inputs:      inputs,
+		inputNames:  inputNames,
+		outputs:     outputs,
+		outputNames: outputNames,
+	}
+
+	m.Selector = keccak256([]byte(m.Signature))[:selectorLen]
+	return
+}
+
+func newEvent(e entry) (ev Event, err error) {
+	inputs, names, sig, err := parseArguments(e.Inputs)
+	if err != nil {
+		return
+	}
+
+	ev = Event{
+		Name:      e.Name,
+		Signature: e.Name + sig,
+		Anonymous: e.Anonymous,
+		inputs:    inputs,
+		names:     names,
+	}
+
+	for _, in := range e.Inputs {
+		ev.indexed = append(ev.indexed, in.Indexed)
+	}
+
+	ev.ID = keccak256([]byte(ev.Signature))
+	return
+}
+
+//Parse parses the solidity abi json of a contract, overloaded functions are keyed by their signatures
+func Parse(abiJson []byte) (abi ABI, err error) {
+	var entries []entry
+	err = json.Unmarshal(abiJson, &entries)
+	if err != nil {
+		return
+	}
+
+	abi = ABI{
+		Methods: map[string] Method{},
+		Events:  map[string] Event{},
+		Errors:  map[string] Method{},
+	}
+
+	for _, e := range entries {
+		switch e.Type {
+		case "function", "":
+			m, mErr := newMethod(e)
+			if mErr != nil {
+				return abi, fmt.Errorf("function %s: %s", e.Name, mErr.Error())
+			}
+
+			if _, exists := abi.Methods[m.Name]; exists {
+				abi.Methods[m.Signature] = m
+			} else {
+				abi.Methods[m.Name] = m
+			}
+
+		case "event":
+			ev, evErr := newEvent(e)
+			if evErr != nil {
+				return abi, fmt.Errorf("event %s: %s", e.Name, evErr.Error())
+			}
+			abi.Events[ev.Signature] = ev
+
+		case "error":
+			m, mErr := newMethod(e)
+			if mErr != nil {
+				return abi, fmt.Errorf("error %s: %s", e.Name, mErr.Error())
+			}
+			abi.Errors[m.Signature] = m
+		}
+	}
+
+	return
+}
+
+func (a ABI) methodByName(name string) (Method, error) {
+	if m, exists := a.Methods[name]; exists {
+		return m, nil
+	}
+
+	for _, m := range a.Methods {
+		if m.Signature == name {
+			return m, nil
+		}
+	}
+
+	return Method{}, errors.New("no method named " + name)
+}
+
+func (a ABI) methodBySelector(data []byte) (Method, error) {
+	if len(data) < selectorLen {
+		return Method{}, errors.New("call data too short")
+	}
+
+	for _, m := range a.Methods {
+		if bytes.Equal(m.Selector, data[:selectorLen]) {
+			return m, nil
+		}
+	}
+
+	return Method{}, errors.New("no method for selector " + hex.EncodeToString(data[:selectorLen]))
+}
+
+//parseArgs parses a json array of arguments, numbers are kept as json numbers to not lose precision
+func parseArgs(argsJson []byte) (args []interface{}, err error) {
+	if len(bytes.TrimSpace(argsJson)) == 0 {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(argsJson))
+	decoder.UseNumber()
+	err = decoder.Decode(&args)
+	return
+}
+
+//EncodeCall encodes the call data of a method with its arguments given as a json array
+func (a ABI) EncodeCall(method string, argsJson []byte) (data []byte, err error) {
+	m, err := a.methodByName(method)
+	if err != nil {
+		return
+	}
+
+	args, err := parseArgs(argsJson)
+	if err != nil {
+		return
+	}
+
+	enc, err := encodeTuple(m.inputs, args)
+	if err != nil {
+		return
+	}
+
+	return append(append([]byte{}, m.Selector...), enc...), nil
+}
+
+func namedList(types []Type, names []string, values []interface{}) (list []NamedValue) {
+	list = []NamedValue{}
+	for i, v := range values {
+		list = append(list, NamedValue{
+			Name:  names[i],
+			Type:  types[i].canonical,
+			Value: v,
+		})
+	}
+
+	return
+}
+
+//DecodeCall decodes the method and arguments of call data
+func (a ABI) DecodeCall(data []byte) (call DecodedCall, err error) {
+	m, err := a.methodBySelector(data)
+	if err != nil {
+		return
+	}
+
+	values, err := decodeTuple(m.inputs, data[selectorLen:])
+	if err != nil {
+		return
+	}
+
+	return DecodedCall{
+		Method: m.Signature,
+		Inputs: namedList(m.inputs, m.inputNames, values),
+	}, nil
+}
+
+//DecodeOutputs decodes the return data of a method call, the method is found by the selector of call data
+func (a ABI) DecodeOutputs(callData []byte, ret []byte) (outputs []NamedValue, err error) {
+	m, err := a.methodBySelector(callData)
+	if err != nil {
+		return
+	}
+
+	values, err := decodeTuple(m.outputs, ret)
+	if err != nil {
+		return
+	}
+
+	return namedList(m.outputs, m.outputNames, values), nil
+}
+
+//DecodeLog decodes an event log, indexed values of dynamic types only have their hashes in topics
+func (a ABI) DecodeLog(topics [][]byte, data []byte) (decoded DecodedLog, err error) {
+	var ev Event
+	found := false
+	for _, e := range a.Events {
+		if !e.Anonymous && len(topics) > 0 && bytes.Equal(e.ID, topics[0]) {
+			ev, found = e, true
+			break
+		}
+	}
+
+	if !found {
+		return decoded, errors.New("no event for the log")
+	}
+
+	var dataTypes []Type
+	for i, t := range ev.inputs {
+		if !ev.indexed[i] {
+			dataTypes = append(dataTypes, t)
+		}
+	}
+
+	dataValues, err := decodeTuple(dataTypes, data)
+	if err != nil {
+		return
+	}
+
+	values := make([]interface{}, len(ev.inputs))
+	topicIdx, dataIdx := 1, 0
+	for i, t := range ev.inputs {
+		if !ev.indexed[i] {
+			values[i] = dataValues[dataIdx]
+			dataIdx++
+			continue
+		}
+
+		if topicIdx >= len(topics) {
+			return decoded, errors.New("log has less topics than indexed arguments")
+		}
+
+		topic := topics[topicIdx]
+		topicIdx++
+		if t.isDynamic() || t.kind == kindTuple || t.kind == kindArray {
+			values[i] = "0x" + hex.EncodeToString(topic)
+			continue
+		}
+
+		values[i], err = decodeValue(t, topic, 0)
+		if err != nil {
+			return
+		}
+	}
+
+	return DecodedLog{
+		Event:  ev.Signature,
+		Inputs: namedList(ev.inputs, ev.names, values),
+	}, nil
+}
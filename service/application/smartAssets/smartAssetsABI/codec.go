@@ -0,0 +1,399 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsABI
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	bigOne = big.NewInt(1)
+	two256 = big.NewInt(0).Lsh(bigOne, 256)
+)
+
+//ethereum addresses are 20 bytes, longer native addresses are kept when decoding
+const minAddressLen = 20
+
+func decodeHexString(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) % 2 == 1 {
+		s = "0" + s
+	}
+
+	return hex.DecodeString(s)
+}
+
+func toBigInt(v interface{}) (*big.Int, error) {
+	switch val := v.(type) {
+	case json.Number:
+		return toBigInt(string(val))
+
+	case float64:
+		ret, accuracy := big.NewFloat(val).Int(nil)
+		if accuracy != big.Exact {
+			return nil, fmt.Errorf("%v is not an integer", val)
+		}
+		return ret, nil
+
+	case string:
+		base := 10
+		if strings.HasPrefix(val, "0x") || strings.HasPrefix(val, "0X") {
+			base = 16
+			val = val[2:]
+		}
+
+		ret, ok := big.NewInt(0).SetString(val, base)
+		if !ok {
+			return nil, errors.New("invalid integer " + val)
+		}
+		return ret, nil
+	}
+
+	return nil, fmt.Errorf("integer expected but got %v", v)
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("hex string expected but got %v", v)
+	}
+
+	return decodeHexString(str)
+}
+
+func toList(v interface{}) ([]interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("list expected but got %v", v)
+	}
+
+	return list, nil
+}
+
+func leftPad(b []byte) []byte {
+	word := make([]byte, wordSize)
+	copy(word[wordSize - len(b):], b)
+	return word
+}
+
+func rightPad(b []byte) []byte {
+	size := (len(b) + wordSize - 1) / wordSize * wordSize
+	padded := make([]byte, size)
+	copy(padded, b)
+	return padded
+}
+
+func encodeInteger(t Type, v interface{}) ([]byte, error) {
+	val, err := toBigInt(v)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := big.NewInt(0).Lsh(bigOne, uint(t.size))
+	if t.kind == kindUint {
+		if val.Sign() < 0 || val.Cmp(limit) >= 0 {
+			return nil, fmt.Errorf("%s out of %s range", val.String(), t.canonical)
+		}
+		return leftPad(val.Bytes()), nil
+	}
+
+	half := big.NewInt(0).Rsh(limit, 1)
+	if val.Cmp(half) >= 0 || val.Cmp(big.NewInt(0).Neg(half)) < 0 {
+		return nil, fmt.Errorf("%s out of %s range", val.String(), t.canonical)
+	}
+
+	if val.Sign() < 0 {
+		val = big.NewInt(0).Add(two256, val)
+	}
+	return leftPad(val.Bytes()), nil
+}
+
+//encodeTuple encodes values of the types as a tuple, dynamic values are put in the tail after their offsets
+func encodeTuple(types []Type, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("%d values expected but got %d", len(types), len(values))
+	}
+
+	headSize := 0
+	for _, t := range types {
+		headSize += t.headSize()
+	}
+
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := encodeValue(t, values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if t.isDynamic() {
+			head = append(head, leftPad(big.NewInt(int64(headSize + len(tail))).Bytes())...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+
+	return append(head, tail...), nil
+}
+
+func repeatType(t Type, n int) []Type {
+	types := make([]Type, n)
+	for i := range types {
+		types[i] = t
+	}
+	return types
+}
+
+func tupleValues(t Type, v interface{}) ([]interface{}, error) {
+	if named, ok := v.(map[string]interface{}); ok {
+		values := make([]interface{}, len(t.names))
+		for i, name := range t.names {
+			val, exists := named[name]
+			if !exists {
+				return nil, errors.New("missing tuple field " + name)
+			}
+			values[i] = val
+		}
+		return values, nil
+	}
+
+	return toList(v)
+}
+
+func encodeValue(t Type, v interface{}) ([]byte, error) {
+	switch t.kind {
+	case kindUint, kindInt:
+		return encodeInteger(t, v)
+
+	case kindAddress:
+		addr, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(addr) > wordSize {
+			return nil, errors.New("address too long")
+		}
+		return leftPad(addr), nil
+
+	case kindBool:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("bool expected but got %v", v)
+		}
+		if b {
+			return leftPad([]byte{1}), nil
+		}
+		return leftPad(nil), nil
+
+	case kindFixedBytes:
+		b, err := toBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.size {
+			return nil, fmt.Errorf("%d bytes expected for %s", t.size, t.canonical)
+		}
+		return rightPad(b), nil
+
+	case kindBytes, kindString:
+		var b []byte
+		if t.kind == kindString {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("string expected but got %v", v)
+			}
+			b = []byte(str)
+		} else {
+			var err error
+			if b, err = toBytes(v); err != nil {
+				return nil, err
+			}
+		}
+		return append(leftPad(big.NewInt(int64(len(b))).Bytes()), rightPad(b)...), nil
+
+	case kindSlice:
+		list, err := toList(v)
+		if err != nil {
+			return nil, err
+		}
+		enc, err := encodeTuple(repeatType(*t.elem, len(list)), list)
+		if err != nil {
+			return nil, err
+		}
+		return append(leftPad(big.NewInt(int64(len(list))).Bytes()), enc...), nil
+
+	case kindArray:
+		list, err := toList(v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTuple(repeatType(*t.elem, t.length), list)
+
+	case kindTuple:
+		values, err := tupleValues(t, v)
+		if err != nil {
+			return nil, err
+		}
+		return encodeTuple(t.components, values)
+	}
+
+	return nil, errors.New("unsupported abi type " + t.canonical)
+}
+
+func readWord(data []byte, at int) ([]byte, error) {
+	if at < 0 || at + wordSize > len(data) {
+		return nil, errors.New("abi data too short")
+	}
+
+	return data[at:at + wordSize], nil
+}
+
+func readLength(data []byte, at int) (int, error) {
+	word, err := readWord(data, at)
+	if err != nil {
+		return 0, err
+	}
+
+	l := big.NewInt(0).SetBytes(word)
+	if !l.IsInt64() || l.Int64() > int64(len(data)) {
+		return 0, errors.New("abi length or offset out of range")
+	}
+
+	return int(l.Int64()), nil
+}
+
+//decodeTuple decodes values of the types from a tuple encoding
+func decodeTuple(types []Type, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	pos := 0
+	for i, t := range types {
+		at := pos
+		if t.isDynamic() {
+			offset, err := readLength(data, pos)
+			if err != nil {
+				return nil, err
+			}
+			at = offset
+		}
+
+		if at > len(data) {
+			return nil, errors.New("abi data too short")
+		}
+
+		val, err := decodeValue(t, data, at)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = val
+		pos += t.headSize()
+	}
+
+	return values, nil
+}
+
+func decodeValue(t Type, data []byte, at int) (interface{}, error) {
+	switch t.kind {
+	case kindUint, kindInt:
+		word, err := readWord(data, at)
+		if err != nil {
+			return nil, err
+		}
+		val := big.NewInt(0).SetBytes(word)
+		if t.kind == kindInt && word[0] & 0x80 != 0 {
+			val.Sub(val, two256)
+		}
+		return val.String(), nil
+
+	case kindAddress:
+		word, err := readWord(data, at)
+		if err != nil {
+			return nil, err
+		}
+		addr := word
+		for len(addr) > minAddressLen && addr[0] == 0 {
+			addr = addr[1:]
+		}
+		return "0x" + hex.EncodeToString(addr), nil
+
+	case kindBool:
+		word, err := readWord(data, at)
+		if err != nil {
+			return nil, err
+		}
+		return word[wordSize - 1] == 1, nil
+
+	case kindFixedBytes:
+		word, err := readWord(data, at)
+		if err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(word[:t.size]), nil
+
+	case kindBytes, kindString:
+		l, err := readLength(data, at)
+		if err != nil {
+			return nil, err
+		}
+		start := at + wordSize
+		if start + l > len(data) {
+			return nil, errors.New("abi data too short")
+		}
+		if t.kind == kindString {
+			return string(data[start:start + l]), nil
+		}
+		return "0x" + hex.EncodeToString(data[start:start + l]), nil
+
+	case kindSlice:
+		l, err := readLength(data, at)
+		if err != nil {
+			return nil, err
+		}
+		return decodeTuple(repeatType(*t.elem, l), data[at + wordSize:])
+
+	case kindArray:
+		return decodeTuple(repeatType(*t.elem, t.length), data[at:])
+
+	case kindTuple:
+		values, err := decodeTuple(t.components, data[at:])
+		if err != nil {
+			return nil, err
+		}
+		return namedValues(t.names, values), nil
+	}
+
+	return nil, errors.New("unsupported abi type " + t.canonical)
+}
+
+//namedValues returns the values as an object when all of them have names, as a list otherwise
+func namedValues(names []string, values []interface{}) interface{} {
+	named := map[string]interface{}{}
+	for i, name := range names {
+		if name == "" {
+			return values
+		}
+		named[name] = values[i]
+	}
+
+	return named
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsABI
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} //Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} //Panic(uint256)
+)
+
+var panicReasons = map[string] string {
+	"0":  "generic compiler inserted panic",
+	"1":  "assertion failed",
+	"17": "arithmetic overflow or underflow",
+	"18": "division or modulo by zero",
+	"33": "invalid enum value",
+	"34": "invalid storage byte array encoding",
+	"49": "pop on empty array",
+	"50": "array index out of bounds",
+	"65": "too much memory allocated",
+	"81": "call to zero initialized function",
+}
+
+const (
+	RevertKindError  = "Error"
+	RevertKindPanic  = "Panic"
+	RevertKindCustom = "Custom"
+)
+
+//RevertReason is the decoded return data of a reverted execution
+type RevertReason struct {
+	Kind    string
+	Message string
+	Code    string       `json:",omitempty"`
+	Error   string       `json:",omitempty"`
+	Inputs  []NamedValue `json:",omitempty"`
+}
+
+func (r RevertReason) String() string {
+	switch r.Kind {
+	case RevertKindPanic:
+		return fmt.Sprintf("panic %s: %s", r.Code, r.Message)
+
+	case RevertKindCustom:
+		var args []string
+		for _, in := range r.Inputs {
+			args = append(args, fmt.Sprintf("%v", in.Value))
+		}
+		return fmt.Sprintf("%s(%s)", r.Message, strings.Join(args, ", "))
+	}
+
+	return r.Message
+}
+
+//DecodeRevert decodes the Error(string) and Panic(uint256) reverts of solidity, nil if the data is neither of them
+func DecodeRevert(data []byte) *RevertReason {
+	if len(data) < selectorLen {
+		return nil
+	}
+
+	selector, payload := data[:selectorLen], data[selectorLen:]
+	if bytes.Equal(selector, errorSelector) {
+		strType, _ := NewType("string", nil)
+		values, err := decodeTuple([]Type{strType}, payload)
+		if err != nil {
+			return nil
+		}
+
+		return &RevertReason{
+			Kind:    RevertKindError,
+			Message: values[0].(string),
+		}
+	}
+
+	if bytes.Equal(selector, panicSelector) {
+		uintType, _ := NewType("uint256", nil)
+		values, err := decodeTuple([]Type{uintType}, payload)
+		if err != nil {
+			return nil
+		}
+
+		code := values[0].(string)
+		message, known := panicReasons[code]
+		if !known {
+			message = "unknown panic"
+		}
+
+		codeNum, _ := toBigInt(code)
+		return &RevertReason{
+			Kind:    RevertKindPanic,
+			Message: message,
+			Code:    fmt.Sprintf("0x%02x", codeNum),
+		}
+	}
+
+	return nil
+}
+
+//DecodeRevert decodes the builtin reverts of solidity and the custom errors of the abi, nil if nothing matches
+func (a ABI) DecodeRevert(data []byte) *RevertReason {
+	if reason := DecodeRevert(data); reason != nil {
+		return reason
+	}
+
+	if len(data) < selectorLen {
+		return nil
+	}
+
+	for _, e := range a.Errors {
+		if !bytes.Equal(e.Selector, data[:selectorLen]) {
+			continue
+		}
+
+		values, err := decodeTuple(e.inputs, data[selectorLen:])
+		if err != nil {
+			return nil
+		}
+
+		return &RevertReason{
+			Kind:    RevertKindCustom,
+			Message: e.Name,
+			Error:   e.Signature,
+			Inputs:  namedList(e.inputs, e.inputNames, values),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsABI
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const wordSize = 32
+
+const (
+	kindUint = iota + 1
+	kindInt
+	kindAddress
+	kindBool
+	kindFixedBytes
+	kindBytes
+	kindString
+	kindSlice
+	kindArray
+	kindTuple
+)
+
+var arraySuffix = regexp.MustCompile(`\[(\d*)\]$`)
+
+//Type is a parsed solidity abi type
+type Type struct {
+	kind       int
+	size       int
+	length     int
+	elem       *Type
+	components []Type
+	names      []string
+	canonical  string
+}
+
+func (t Type) String() string {
+	return t.canonical
+}
+
+func (t Type) isDynamic() bool {
+	switch t.kind {
+	case kindBytes, kindString, kindSlice:
+		return true
+
+	case kindArray:
+		return t.elem.isDynamic()
+
+	case kindTuple:
+		for _, c := range t.components {
+			if c.isDynamic() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//headSize is the size the type takes in the head part of an encoding
+func (t Type) headSize() int {
+	if t.isDynamic() {
+		return wordSize
+	}
+
+	switch t.kind {
+	case kindArray:
+		return t.length * t.elem.headSize()
+
+	case kindTuple:
+		size := 0
+		for _, c := range t.components {
+			size += c.headSize()
+		}
+		return size
+	}
+
+	return wordSize
+}
+
+func parseBitSize(s string, defaultSize int) (int, error) {
+	if s == "" {
+		return defaultSize, nil
+	}
+
+	size, err := strconv.Atoi(s)
+	if err != nil || size <= 0 || size > 256 || size % 8 != 0 {
+		return 0, errors.New("invalid integer size " + s)
+	}
+
+	return size, nil
+}
+
+//NewType parses a type of an abi argument, components are used by tuple types
+func NewType(typeStr string, components []Argument) (t Type, err error) {
+	if loc := arraySuffix.FindStringSubmatchIndex(typeStr); loc != nil {
+		elem, elemErr := NewType(typeStr[:loc[0]], components)
+		if elemErr != nil {
+			return t, elemErr
+		}
+
+		t.elem = &elem
+		lenStr := typeStr[loc[2]:loc[3]]
+		if lenStr == "" {
+			t.kind = kindSlice
+			t.canonical = elem.canonical + "[]"
+		} else {
+			t.kind = kindArray
+			t.length, err = strconv.Atoi(lenStr)
+			if err != nil || t.length == 0 {
+				return t, errors.New("invalid array length in " + typeStr)
+			}
+			t.canonical = elem.canonical + "[" + lenStr + "]"
+		}
+		return
+	}
+
+	switch {
+	case typeStr == "tuple":
+		t.kind = kindTuple
+		var canonicals []string
+		for _, c := range components {
+			ct, cErr := NewType(c.Type, c.Components)
+			if cErr != nil {
+				return t, cErr
+			}
+			t.components = append(t.components, ct)
+			t.names = append(t.names, c.Name)
+			canonicals = append(canonicals, ct.canonical)
+		}
+		t.canonical = "(" + strings.Join(canonicals, ",") + ")"
+
+	case strings.HasPrefix(typeStr, "uint"):
+		t.kind = kindUint
+		t.size, err = parseBitSize(strings.TrimPrefix(typeStr, "uint"), 256)
+		t.canonical = fmt.Sprintf("uint%d", t.size)
+
+	case strings.HasPrefix(typeStr, "int"):
+		t.kind = kindInt
+		t.size, err = parseBitSize(strings.TrimPrefix(typeStr, "int"), 256)
+		t.canonical = fmt.Sprintf("int%d", t.size)
+
+	case typeStr == "address":
+		t.kind = kindAddress
+		t.canonical = typeStr
+
+	case typeStr == "bool":
+		t.kind = kindBool
+		t.canonical = typeStr
+
+	case typeStr == "string":
+		t.kind = kindString
+		t.canonical = typeStr
+
+	case typeStr == "bytes":
+		t.kind = kindBytes
+		t.canonical = typeStr
+
+	case strings.HasPrefix(typeStr, "bytes"):
+		t.kind = kindFixedBytes
+		t.size, err = strconv.Atoi(strings.TrimPrefix(typeStr, "bytes"))
+		if err != nil || t.size <= 0 || t.size > wordSize {
+			err = errors.New("invalid fixed bytes type " + typeStr)
+		}
+		t.canonical = typeStr
+
+	default:
+		err = errors.New("unsupported abi type " + typeStr)
+	}
+
+	return
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsInterface
+
+import (
+	"github.com/SealSC/SealABC/metadata/blockchainRequest"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainApi/ethRPC"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+func (s *SmartAssetsApplication) EthChainID() uint64 {
+	return s.ledger.EthChainID
+}
+
+func (s *SmartAssetsApplication) EthGasPrice() *big.Int {
+	return s.ledger.MinGasPrice()
+}
+
+func (s *SmartAssetsApplication) EthGetBalance(address []byte, height uint64) (*big.Int, error) {
+	return s.ledger.BalanceAt(address, height)
+}
+
+func (s *SmartAssetsApplication) EthGetCode(address []byte, height uint64) ([]byte, error) {
+	return s.ledger.CodeAt(address, height)
+}
+
+func (s *SmartAssetsApplication) EthGetTransactionCount(address []byte, height uint64, pending bool) (uint64, error) {
+	if pending {
+		return s.ledger.PendingNonce(address)
+	}
+
+	return s.ledger.NonceAt(address, height)
+}
+
+func (s *SmartAssetsApplication) EthCall(from []byte, to []byte, data []byte, value *big.Int, height uint64) ([]byte, uint64, error) {
+	return s.ledger.EthCallAt(from, to, data, value, height)
+}
+
+func (s *SmartAssetsApplication) EthRequestFromRawTransaction(raw []byte, chainID string) (req blockchainRequest.Entity, txHash []byte, err error) {
+	tx, err := s.ledger.TransactionFromEthereum(raw, chainID)
+	if err != nil {
+		return
+	}
+
+	req.RequestApplication = s.Name()
+	req.RequestAction = tx.Type
+	req.ChainID = tx.ChainID
+	req.Data, err = json.Marshal(tx)
+
+	return req, tx.DataSeal.Hash, err
+}
+
+func (s *SmartAssetsApplication) EthGetTransaction(txHash []byte) (tx ethRPC.Transaction, err error) {
+	saTx, exists, err := s.ledger.GetTransaction(txHash)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		err = errors.New("no such transaction")
+		return
+	}
+
+	tx.Hash = saTx.DataSeal.Hash
+	tx.From = saTx.From
+	tx.To = saTx.To
+	tx.ContractAddress = saTx.NewAddress
+	return
+}
+
+func (s *SmartAssetsApplication) EthTraceTransactionCalls(txHash []byte) (interface{}, error) {
+	return s.ledger.TraceTransactionCalls(txHash)
+}
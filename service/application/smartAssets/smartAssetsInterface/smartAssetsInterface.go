@@ -33,6 +33,7 @@ import (
 	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 )
 
 type SmartAssetsApplication struct {
@@ -57,11 +58,18 @@ func (s *SmartAssetsApplication) Query(req []byte) (result interface{}, err erro
 		return
 	}
 
-	baseAssetsQuery := smartAssetsLedger.QueryTypes.BaseAssets.String()
-	offChainCallQuery := smartAssetsLedger.QueryTypes.OffChainCall.String()
-	if queryReq.QueryType == baseAssetsQuery || queryReq.QueryType == offChainCallQuery {
+	switch queryReq.QueryType {
+	case smartAssetsLedger.QueryTypes.BaseAssets.String(),
+		smartAssetsLedger.QueryTypes.Balance.String(),
+		smartAssetsLedger.QueryTypes.OffChainCall.String(),
+		smartAssetsLedger.QueryTypes.ABI.String(),
+		smartAssetsLedger.QueryTypes.EncodeCall.String(),
+		smartAssetsLedger.QueryTypes.CallTrace.String(),
+		smartAssetsLedger.QueryTypes.UpgradeHistory.String(),
+		smartAssetsLedger.QueryTypes.Nonce.String():
 		return s.ledger.DoQuery(queryReq)
-	} else {
+
+	default:
 		if s.sqlStorage != nil {
 			return s.sqlStorage.DoQuery(queryReq)
 		}
@@ -102,6 +110,80 @@ func (s *SmartAssetsApplication) Execute(
 	return
 }
 
+func (s *SmartAssetsApplication) BuildReceipts(
+	req blockchainRequest.Entity,
+	_ applicationResult.Entity,
+) (receipts []chainStructure.Receipt, err error) {
+	txList := smartAssetsLedger.TransactionList{}
+	err = structSerializer.FromMFBytes(req.Data, &txList)
+	if err != nil {
+		return
+	}
+
+	return s.ledger.BuildReceipts(txList), nil
+}
+
+func (s *SmartAssetsApplication) Rollback(
+	req blockchainRequest.Entity,
+	blk block.Entity,
+	actIndex uint32,
+) (err error) {
+	txList := smartAssetsLedger.TransactionList{}
+	err = structSerializer.FromMFBytes(req.Data, &txList)
+	if err != nil {
+		return
+	}
+
+	return s.ledger.Rollback(txList, blk)
+}
+
+//ApplicationInternalCall lets other applications query the ledger and settle a signed transfer or contract call,
+//the data of the other calls is the json of the transaction and the settlement is kept under the undo key
+func (s *SmartAssetsApplication) ApplicationInternalCall(_ string, callData []byte) (ret interface{}, err error) {
+	req, err := chainStructure.ParseInternalCall(callData)
+	if err != nil {
+		return
+	}
+
+	switch req.Type {
+	case chainStructure.InternalCallQuery:
+		return s.Query(req.Data)
+
+	case chainStructure.InternalCallRollback:
+		err = s.ledger.RollbackSettlement(req.UndoKey)
+		return
+	}
+
+	tx := smartAssetsLedger.Transaction{}
+	err = json.Unmarshal(req.Data, &tx)
+	if err != nil {
+		return
+	}
+
+	if tx.Type != req.Action {
+		err = errors.New("transaction type is not equal to call action")
+		return
+	}
+
+	if req.Type == chainStructure.InternalCallVerify {
+		err = s.ledger.VerifySettlement(tx)
+	} else {
+		err = s.ledger.ExecuteSettlement(tx, req.UndoKey)
+	}
+
+	return hex.EncodeToString(tx.DataSeal.Hash), err
+}
+
+func (s *SmartAssetsApplication) ApplyGenesis(config []byte, _ block.Entity) (err error) {
+	cfg := smartAssetsLedger.GenesisConfig{}
+	err = json.Unmarshal(config, &cfg)
+	if err != nil {
+		return
+	}
+
+	return s.ledger.ApplyGenesis(cfg)
+}
+
 func (s *SmartAssetsApplication) RequestsForBlock(blk block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
 	txList, cnt, txRoot := s.ledger.GetTransactionsFromPool(blk)
 	if cnt == 0 {
@@ -161,6 +243,8 @@ func (s *SmartAssetsApplication) UnpackingActionsAsRequests(req blockchainReques
 		newReq.Seal = tx.DataSeal
 		newReq.RequestApplication = s.Name()
 		newReq.RequestAction = tx.Type
+		newReq.ChainID = tx.ChainID
+		newReq.ExpiryHeight = tx.ExpiryHeight
 		newReq.Data, _ = json.Marshal(tx)
 
 		list = append(list, newReq)
@@ -177,6 +261,8 @@ func (s *SmartAssetsApplication) GetActionAsRequest(req blockchainRequest.Entity
 	newReq.Seal = tx.DataSeal
 	newReq.RequestApplication = s.Name()
 	newReq.RequestAction = tx.Type
+	newReq.ChainID = tx.ChainID
+	newReq.ExpiryHeight = tx.ExpiryHeight
 	newReq.Data = req.Data
 
 	return newReq
@@ -189,13 +275,28 @@ func NewApplicationInterface(
 	sqlDriver simpleSQLDatabase.IDriver,
 	tools crypto.Tools,
 	assets smartAssetsLedger.BaseAssetsData,
+	ethChainID uint64,
+	gas smartAssetsLedger.GasConfig,
+	upgrade smartAssetsLedger.UpgradeConfig,
 	) (app chainStructure.IBlockchainExternalApplication, err error) {
 	sa := SmartAssetsApplication{}
 
 	sa.ledger = smartAssetsLedger.NewLedger(tools, kvDriver)
+	sa.ledger.ApplicationName = sa.Name()
+	sa.ledger.EthChainID = ethChainID
+	err = sa.ledger.SetGasConfig(gas)
+	if err != nil {
+		return
+	}
+
+	err = sa.ledger.SetUpgradeConfig(upgrade)
+	if err != nil {
+		return
+	}
 
 	if sqlDriver != nil {
 		sa.sqlStorage = smartAssetsSQLStorage.NewStorage(sqlDriver)
+		sa.sqlStorage.CallDecoder = sa.ledger.DecodeTransaction
 	}
 
 	ownerBytes, err := hex.DecodeString(assets.Owner)
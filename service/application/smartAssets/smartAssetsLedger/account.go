@@ -0,0 +1,219 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/metadata/seal"
+	"github.com/SealSC/SealEVM/evmInt256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+//AddressLen is the length of account and contract addresses, the same as ethereum
+const AddressLen = 20
+
+const nonceLen = 8
+
+//AddressOfPublicKey returns the address of a public key, the last 20 bytes of its keccak256 hash
+func AddressOfPublicKey(pubKey []byte) []byte {
+	hash := keccak256(pubKey)
+	return hash[len(hash) - AddressLen:]
+}
+
+//senderAddress returns the address of the signer of a transaction seal,
+//seal of an ethereum transaction already holds the address recovered from the signature
+func senderAddress(s seal.Entity) []byte {
+	if s.SignerAlgorithm == EthereumSignerAlgorithm {
+		return s.SignerPublicKey
+	}
+
+	return AddressOfPublicKey(s.SignerPublicKey)
+}
+
+//ContractAddress returns the address of a contract created by the account with the nonce, the same as ethereum CREATE
+func ContractAddress(creator []byte, nonce uint64) []byte {
+	hash := keccak256(rlpEncodeList(rlpEncodeBytes(creator), rlpEncodeUint64(nonce)))
+	return hash[len(hash) - AddressLen:]
+}
+
+//evmAddress returns the address in an evm int, leading zero bytes are dropped by the int so they're padded back
+func evmAddress(i *evmInt256.Int) []byte {
+	addr := i.Bytes()
+	if len(addr) >= AddressLen {
+		return addr
+	}
+
+	padded := make([]byte, AddressLen)
+	copy(padded[AddressLen - len(addr):], addr)
+	return padded
+}
+
+func nonceBytes(nonce uint64) []byte {
+	data := make([]byte, nonceLen)
+	binary.BigEndian.PutUint64(data, nonce)
+	return data
+}
+
+//NonceOf returns the nonce of the next transaction of an account, or the next contract created by a contract
+func (l *Ledger) NonceOf(address []byte) (uint64, error) {
+	kv, err := l.Storage.Get(BuildKey(StoragePrefixes.Nonce, address))
+	if err != nil {
+		return 0, err
+	}
+
+	if !kv.Exists || len(kv.Data) != nonceLen {
+		return 0, nil
+	}
+
+	return binary.BigEndian.Uint64(kv.Data), nil
+}
+
+//PendingNonce returns the nonce for the next transaction of an account, transactions in pool counted
+func (l *Ledger) PendingNonce(address []byte) (uint64, error) {
+	nonce, err := l.NonceOf(address)
+	if err != nil {
+		return 0, err
+	}
+
+	l.poolLock.Lock()
+	defer l.poolLock.Unlock()
+
+	var pending []uint64
+	for _, tx := range l.txPool {
+		if string(tx.From) == string(address) && tx.Nonce >= nonce {
+			pending = append(pending, tx.Nonce)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i] < pending[j]
+	})
+
+	for _, n := range pending {
+		if n == nonce {
+			nonce++
+		}
+	}
+
+	return nonce, nil
+}
+
+//nonces are kept in the result cache as values so the changes of a failed transaction are restored with balances
+func nonceCacheKey(address []byte) string {
+	return string(BuildKey(StoragePrefixes.Nonce, address))
+}
+
+func (l *Ledger) getNonce(address []byte, cache txResultCache) (uint64, error) {
+	key := nonceCacheKey(address)
+	if cache[key] != nil {
+		return cache[key].val.Uint64(), nil
+	}
+
+	nonce, err := l.NonceOf(address)
+	if err != nil {
+		return 0, err
+	}
+
+	cache[key] = &txResultCacheData{
+		val: big.NewInt(0).SetUint64(nonce),
+	}
+	return nonce, nil
+}
+
+func (l *Ledger) increaseNonce(address []byte, cache txResultCache) (StateData, error) {
+	nonce, err := l.getNonce(address, cache)
+	if err != nil {
+		return StateData{}, err
+	}
+
+	cache[nonceCacheKey(address)].val.SetUint64(nonce + 1)
+
+	var orgVal []byte
+	if nonce != 0 {
+		orgVal = nonceBytes(nonce)
+	}
+
+	return StateData{
+		Key:    BuildKey(StoragePrefixes.Nonce, address),
+		NewVal: nonceBytes(nonce + 1),
+		OrgVal: orgVal,
+	}, nil
+}
+
+//checkNonce checks the transaction is the next one of its sender
+func (l *Ledger) checkNonce(tx Transaction, cache txResultCache) error {
+	nonce, err := l.getNonce(tx.From, cache)
+	if err != nil {
+		return Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if tx.Nonce != nonce {
+		return Errors.InvalidNonce.NewErrorWithNewMessage(fmt.Sprintf("nonce of the transaction is %d, %d expected", tx.Nonce, nonce))
+	}
+
+	return nil
+}
+
+//nonceStateOfCreations returns the new nonces of the contracts which created contracts in an evm execution
+func (l *Ledger) nonceStateOfCreations(cache txResultCache, changes map[string] []byte) (state []StateData) {
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		state = append(state, StateData{
+			Key:    []byte(k),
+			NewVal: nonceBytes(cache[k].val.Uint64()),
+			OrgVal: changes[k],
+		})
+	}
+
+	return
+}
+
+func (l *Ledger) queryNonce(req QueryRequest) (interface{}, error) {
+	hexStr := req.Parameter[QueryParameterFields.Address.String()]
+	if hexStr == "" {
+		return nil, Errors.InvalidParameter
+	}
+
+	addr, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+	}
+
+	if len(addr) != AddressLen {
+		return nil, Errors.InvalidParameter.NewErrorWithNewMessage("invalid address length")
+	}
+
+	hl, _, err := l.queryLedger(req)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := hl.NonceOf(addr)
+	if err != nil {
+		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	return nonce, nil
+}
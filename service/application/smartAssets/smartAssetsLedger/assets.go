@@ -30,7 +30,7 @@ type BaseAssetsData struct {
 	Supply      string
 	Precision   byte
 	Increasable bool
-	Owner       string
+	Owner       string //hex address of the owner, see AddressOfPublicKey
 }
 
 type BaseAssets struct {
@@ -0,0 +1,242 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsABI"
+	"bytes"
+	"math/big"
+)
+
+//DecodedContractCall is a contract call decoded by the abi registered for the contract
+type DecodedContractCall struct {
+	Call    *smartAssetsABI.DecodedCall
+	Outputs []smartAssetsABI.NamedValue
+	Logs    []smartAssetsABI.DecodedLog
+}
+
+func (l *Ledger) getContractCreator(address []byte) ([]byte, error) {
+	creatorKV, err := l.Storage.Get(BuildKey(StoragePrefixes.ContractCreator, address))
+	if err != nil {
+		return nil, err
+	}
+
+	if !creatorKV.Exists {
+		return nil, nil
+	}
+
+	return creatorKV.Data, nil
+}
+
+//GetABI returns the abi json registered for a contract, nil if there's none
+func (l *Ledger) GetABI(address []byte) ([]byte, error) {
+	abiKV, err := l.Storage.Get(BuildKey(StoragePrefixes.ContractABI, address))
+	if err != nil {
+		return nil, err
+	}
+
+	if !abiKV.Exists {
+		return nil, nil
+	}
+
+	return abiKV.Data, nil
+}
+
+func (l *Ledger) getParsedABI(address []byte) (abi smartAssetsABI.ABI, exists bool, err error) {
+	abiJson, err := l.GetABI(address)
+	if err != nil || abiJson == nil {
+		return
+	}
+
+	abi, err = smartAssetsABI.Parse(abiJson)
+	return abi, err == nil, err
+}
+
+//preRegisterABI registers the abi json in tx data for the contract at tx.To, only the creator of the contract can do it
+func (l *Ledger) preRegisterABI(tx Transaction, cache txResultCache, _ block.Entity) ([]StateData, txResultCache, error) {
+	if tx.Type != TxType.RegisterABI.String() {
+		return nil, cache, Errors.InvalidTransactionType
+	}
+
+	if value, valid := big.NewInt(0).SetString(tx.Value, 10); tx.Value != "" && (!valid || value.Sign() != 0) {
+		return nil, cache, Errors.InvalidTransferValue
+	}
+
+	code, err := l.GetCode(tx.To)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if len(code) == 0 {
+		return nil, cache, Errors.ContractNotFound
+	}
+
+	creator, err := l.getContractCreator(tx.To)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if !bytes.Equal(creator, tx.From) {
+		return nil, cache, Errors.NotContractCreator
+	}
+
+	_, err = smartAssetsABI.Parse(tx.Data)
+	if err != nil {
+		return nil, cache, Errors.InvalidContractABI.NewErrorWithNewMessage(err.Error())
+	}
+
+	orgABI, err := l.GetABI(tx.To)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	return []StateData{
+		{
+			Key:    BuildKey(StoragePrefixes.ContractABI, tx.To),
+			NewVal: tx.Data,
+			OrgVal: orgABI,
+		},
+	}, cache, Errors.Success
+}
+
+//decodeRevert decodes the revert data of a contract, custom errors are decoded by the abi registered for it
+func (l *Ledger) decodeRevert(contract []byte, data []byte) *smartAssetsABI.RevertReason {
+	if len(contract) != 0 {
+		abi, exists, _ := l.getParsedABI(contract)
+		if exists {
+			return abi.DecodeRevert(data)
+		}
+	}
+
+	return smartAssetsABI.DecodeRevert(data)
+}
+
+func (l *Ledger) setTxRevertReason(tx *Transaction) {
+	tx.RevertReason = ""
+	if tx.ErrorCode != Errors.ContractExecuteRevert.Code() {
+		return
+	}
+
+	if reason := l.decodeRevert(tx.To, tx.ReturnData); reason != nil {
+		tx.RevertReason = reason.String()
+	}
+}
+
+//revertError is the revert error with the decoded reason as its message, other errors are returned as they are
+func (l *Ledger) revertError(err error, contract []byte, data []byte) (error, *smartAssetsABI.RevertReason) {
+	if errEl, isEl := err.(enum.ErrorElement); !isEl || errEl.Code() != Errors.ContractExecuteRevert.Code() {
+		return err, nil
+	}
+
+	reason := l.decodeRevert(contract, data)
+	if reason == nil {
+		return err, nil
+	}
+
+	return Errors.ContractExecuteRevert.NewErrorWithNewMessage("execution reverted: " + reason.String()), reason
+}
+
+//EncodeCall encodes the call data of a contract method with its arguments in a json array by the registered abi
+func (l *Ledger) EncodeCall(address []byte, method string, argsJson string) ([]byte, error) {
+	abi, exists, err := l.getParsedABI(address)
+	if err != nil {
+		return nil, Errors.InvalidContractABI.NewErrorWithNewMessage(err.Error())
+	}
+
+	if !exists {
+		return nil, Errors.InvalidContractABI.NewErrorWithNewMessage("no abi registered for the contract")
+	}
+
+	data, err := abi.EncodeCall(method, []byte(argsJson))
+	if err != nil {
+		return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+	}
+
+	return data, nil
+}
+
+//decodeContractCall decodes the input, return data and logs of a call as far as the registered abi can,
+//logs of other contracts are decoded by their own abi.
+func (l *Ledger) decodeContractCall(tx Transaction) (decoded *DecodedContractCall, err error) {
+	abiCache := map[string] *smartAssetsABI.ABI{}
+	abiOf := func(address []byte) (*smartAssetsABI.ABI, error) {
+		if abi, cached := abiCache[string(address)]; cached {
+			return abi, nil
+		}
+
+		abi, exists, abiErr := l.getParsedABI(address)
+		if abiErr != nil {
+			return nil, abiErr
+		}
+
+		if !exists {
+			abiCache[string(address)] = nil
+			return nil, nil
+		}
+
+		abiCache[string(address)] = &abi
+		return &abi, nil
+	}
+
+	abi, err := abiOf(tx.To)
+	if err != nil {
+		return
+	}
+
+	decoded = &DecodedContractCall{}
+	if abi != nil {
+		if call, callErr := abi.DecodeCall(tx.Data); callErr == nil {
+			decoded.Call = &call
+			decoded.Outputs, _ = abi.DecodeOutputs(tx.Data, tx.ReturnData)
+		}
+	}
+
+	for _, log := range TransactionLogs(tx) {
+		logABI, logErr := abiOf(log.Address)
+		if logErr != nil {
+			return nil, logErr
+		}
+
+		if logABI == nil {
+			continue
+		}
+
+		decodedLog, logErr := logABI.DecodeLog(log.Topics, log.Data)
+		if logErr != nil {
+			continue
+		}
+		decoded.Logs = append(decoded.Logs, decodedLog)
+	}
+
+	return
+}
+
+//DecodeTransaction decodes a contract call transaction, nil if the transaction is not a contract call
+func (l *Ledger) DecodeTransaction(tx Transaction) (*DecodedContractCall, error) {
+	if tx.Type != TxType.ContractCall.String() {
+		return nil, nil
+	}
+
+	decoded, err := l.decodeContractCall(tx)
+	if err != nil {
+		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	return decoded, nil
+}
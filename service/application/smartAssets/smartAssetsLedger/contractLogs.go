@@ -0,0 +1,271 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	maxIndexedTopics   = 4
+	maxLogQueryBlocks  = 1000
+	maxLogQueryResults = 10000
+
+	//height, transaction index and log index, big endian to keep the logs ordered in storage
+	logPositionLen = 16
+)
+
+type LogRecord struct {
+	Address     []byte
+	Topics      [][]byte
+	Data        []byte
+	BlockHeight uint64
+	TxHash      []byte
+	TxIndex     uint32
+	LogIndex    uint32
+}
+
+//LogFilter selects logs in a block range, every non-empty address or topic list must have one value matched.
+//topics are matched by position, a zero ToHeight means the current height.
+type LogFilter struct {
+	Addresses  [][]byte
+	Topics     [][][]byte
+	FromHeight uint64
+	ToHeight   uint64
+}
+
+func logPosition(height uint64, txIndex uint32, logIndex uint32) []byte {
+	pos := make([]byte, logPositionLen)
+	binary.BigEndian.PutUint64(pos, height)
+	binary.BigEndian.PutUint32(pos[8:], txIndex)
+	binary.BigEndian.PutUint32(pos[12:], logIndex)
+	return pos
+}
+
+func heightOfLogKey(key []byte) uint64 {
+	if len(key) < logPositionLen {
+		return 0
+	}
+
+	pos := key[len(key) - logPositionLen:]
+	return binary.BigEndian.Uint64(pos)
+}
+
+func heightPrefix(height uint64) []byte {
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, height)
+	return prefix
+}
+
+func logAddressKey(address []byte, pos []byte) []byte {
+	return BuildKey(StoragePrefixes.LogAddressIndex, []byte{byte(len(address))}, address, pos)
+}
+
+func logTopicKey(topicPos int, topic []byte, pos []byte) []byte {
+	return BuildKey(StoragePrefixes.LogTopicIndex, []byte{byte(topicPos)}, topic, pos)
+}
+
+//logIndexItems builds the log records of a block and their address and topic indexes
+func logIndexItems(txList TransactionList, height uint64) (kvList []kvDatabase.KVItem) {
+	var logIndex uint32
+	for txIndex, tx := range txList.Transactions {
+		for _, txLog := range TransactionLogs(tx) {
+			pos := logPosition(height, uint32(txIndex), logIndex)
+			record := LogRecord{
+				Address:     txLog.Address,
+				Topics:      txLog.Topics,
+				Data:        txLog.Data,
+				BlockHeight: height,
+				TxHash:      tx.DataSeal.Hash,
+				TxIndex:     uint32(txIndex),
+				LogIndex:    logIndex,
+			}
+
+			recordKey := BuildKey(StoragePrefixes.LogIndex, pos)
+			recordData, _ := json.Marshal(record)
+			kvList = append(kvList,
+				kvDatabase.KVItem{Key: recordKey, Data: recordData, Exists: true},
+				kvDatabase.KVItem{Key: logAddressKey(txLog.Address, pos), Data: recordKey, Exists: true},
+			)
+
+			for i, topic := range txLog.Topics {
+				if i >= maxIndexedTopics {
+					break
+				}
+
+				kvList = append(kvList, kvDatabase.KVItem{
+					Key:    logTopicKey(i, topic, pos),
+					Data:   recordKey,
+					Exists: true,
+				})
+			}
+
+			logIndex++
+		}
+	}
+
+	return
+}
+
+func logIndexKeys(txList TransactionList, height uint64) (keys [][]byte) {
+	for _, item := range logIndexItems(txList, height) {
+		keys = append(keys, item.Key)
+	}
+
+	return
+}
+
+func matchLogValue(val []byte, candidates [][]byte) bool {
+	if len(candidates) == 0 {
+		return true
+	}
+
+	for _, c := range candidates {
+		if bytes.Equal(val, c) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f LogFilter) match(record LogRecord) bool {
+	if record.BlockHeight < f.FromHeight || record.BlockHeight > f.ToHeight {
+		return false
+	}
+
+	if !matchLogValue(record.Address, f.Addresses) {
+		return false
+	}
+
+	for i, candidates := range f.Topics {
+		if len(candidates) == 0 {
+			continue
+		}
+
+		if i >= len(record.Topics) || !matchLogValue(record.Topics[i], candidates) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//indexedRecordKeys returns keys of the records referenced by the index entries under the prefixes
+func (l *Ledger) indexedRecordKeys(prefixes [][]byte, filter LogFilter) (keys [][]byte) {
+	added := map[string] bool{}
+	for _, prefix := range prefixes {
+		for _, item := range l.Storage.Traversal(prefix) {
+			height := heightOfLogKey(item.Key)
+			if height < filter.FromHeight || height > filter.ToHeight || added[string(item.Data)] {
+				continue
+			}
+
+			added[string(item.Data)] = true
+			keys = append(keys, item.Data)
+		}
+	}
+
+	return
+}
+
+//QueryLogs returns the logs selected by the filter, ordered by height, transaction index and log index.
+//the most selective index is used: addresses first, then the first topic position has values, then block range.
+func (l *Ledger) QueryLogs(filter LogFilter) (logs []LogRecord, err error) {
+	current := l.chain.CurrentHeight()
+	if filter.ToHeight == 0 || filter.ToHeight > current {
+		filter.ToHeight = current
+	}
+
+	logs = []LogRecord{}
+	if filter.FromHeight > filter.ToHeight {
+		return
+	}
+
+	var recordKeys [][]byte
+	var records []kvDatabase.KVItem
+	var topicPos = -1
+	for i, t := range filter.Topics {
+		if len(t) != 0 && i < maxIndexedTopics {
+			topicPos = i
+			break
+		}
+	}
+
+	if len(filter.Addresses) != 0 {
+		var prefixes [][]byte
+		for _, addr := range filter.Addresses {
+			prefixes = append(prefixes, logAddressKey(addr, nil))
+		}
+		recordKeys = l.indexedRecordKeys(prefixes, filter)
+	} else if topicPos >= 0 {
+		var prefixes [][]byte
+		for _, topic := range filter.Topics[topicPos] {
+			prefixes = append(prefixes, logTopicKey(topicPos, topic, nil))
+		}
+		recordKeys = l.indexedRecordKeys(prefixes, filter)
+	} else {
+		if filter.ToHeight - filter.FromHeight >= maxLogQueryBlocks {
+			return nil, fmt.Errorf("block range of a log query without address or topic must be less than %d", maxLogQueryBlocks)
+		}
+
+		for h := filter.FromHeight; h <= filter.ToHeight; h++ {
+			records = append(records, l.Storage.Traversal(BuildKey(StoragePrefixes.LogIndex, heightPrefix(h)))...)
+		}
+	}
+
+	if len(recordKeys) > maxLogQueryResults {
+		return nil, errors.New("too many logs, narrow the query")
+	}
+
+	if len(recordKeys) != 0 {
+		records, err = l.Storage.BatchGet(recordKeys)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, item := range records {
+		if !item.Exists {
+			continue
+		}
+
+		record := LogRecord{}
+		if json.Unmarshal(item.Data, &record) != nil || !filter.match(record) {
+			continue
+		}
+
+		logs = append(logs, record)
+		if len(logs) > maxLogQueryResults {
+			return nil, errors.New("too many logs, narrow the query")
+		}
+	}
+
+	sort.SliceStable(logs, func(i, j int) bool {
+		return bytes.Compare(
+			logPosition(logs[i].BlockHeight, logs[i].TxIndex, logs[i].LogIndex),
+			logPosition(logs[j].BlockHeight, logs[j].TxIndex, logs[j].LogIndex)) < 0
+	})
+
+	return
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/metadata/block"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+//UpgradeConfig sets the admins who approve contract upgrades, an upgrade is done when Threshold admins
+//approved the same new code for a contract. no admins means contracts can't be upgraded.
+type UpgradeConfig struct {
+	Admins    []string
+	Threshold int
+}
+
+func DefaultUpgradeConfig() UpgradeConfig {
+	return UpgradeConfig{
+		Admins:    []string{},
+		Threshold: 0,
+	}
+}
+
+//UpgradeRecord is kept for every upgrade of a contract, the old code hash is kept for audit
+type UpgradeRecord struct {
+	OldCodeHash []byte
+	NewCodeHash []byte
+	Height      uint64
+	TxHash      []byte
+	Approvers   [][]byte
+}
+
+func (l *Ledger) SetUpgradeConfig(cfg UpgradeConfig) error {
+	admins := map[string] bool{}
+	for _, a := range cfg.Admins {
+		admin, err := hex.DecodeString(a)
+		if err != nil || len(admin) == 0 {
+			return errors.New("invalid upgrade admin " + a)
+		}
+		admins[string(admin)] = true
+	}
+
+	if len(admins) != 0 && (cfg.Threshold <= 0 || cfg.Threshold > len(admins)) {
+		return errors.New("upgrade threshold must be between one and the count of admins")
+	}
+
+	l.upgradeAdmins = admins
+	l.upgradeThreshold = cfg.Threshold
+	return nil
+}
+
+func (l *Ledger) getKVWithCache(key []byte, cache txResultCache) ([]byte, error) {
+	if cached := cache[string(key)]; cached != nil {
+		return cached.Data, nil
+	}
+
+	kv, err := l.Storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return kv.Data, nil
+}
+
+//GetUpgradeHistory returns the upgrades of a contract from the oldest
+func (l *Ledger) GetUpgradeHistory(address []byte) (records []UpgradeRecord, err error) {
+	kv, err := l.Storage.Get(BuildKey(StoragePrefixes.ContractUpgrades, address))
+	if err != nil || len(kv.Data) == 0 {
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &records)
+	return
+}
+
+//preUpgradeContract approves the runtime code in tx data for the contract at tx.To, the code is replaced
+//by the approval which reaches the threshold. approvals of the same block are counted through the cache.
+func (l *Ledger) preUpgradeContract(tx Transaction, cache txResultCache, blk block.Entity) ([]StateData, txResultCache, error) {
+	if tx.Type != TxType.UpgradeContract.String() {
+		return nil, cache, Errors.InvalidTransactionType
+	}
+
+	if value, valid := big.NewInt(0).SetString(tx.Value, 10); tx.Value != "" && (!valid || value.Sign() != 0) {
+		return nil, cache, Errors.InvalidTransferValue
+	}
+
+	if l.upgradeThreshold == 0 {
+		return nil, cache, Errors.UpgradeNotAllowed
+	}
+
+	if !l.upgradeAdmins[string(tx.From)] {
+		return nil, cache, Errors.NotUpgradeAdmin
+	}
+
+	if len(tx.Data) == 0 {
+		return nil, cache, Errors.InvalidParameter.NewErrorWithNewMessage("no code to upgrade to")
+	}
+
+	codeKey := BuildKey(StoragePrefixes.ContractCode, tx.To)
+	oldCode, err := l.getKVWithCache(codeKey, cache)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if len(oldCode) == 0 {
+		return nil, cache, Errors.ContractNotFound
+	}
+
+	newCodeHash := l.CryptoTools.HashCalculator.Sum(tx.Data)
+	approvalKey := BuildKey(StoragePrefixes.UpgradeApproval, tx.To, newCodeHash)
+	orgApprovals, err := l.getKVWithCache(approvalKey, cache)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	var approvers [][]byte
+	if len(orgApprovals) != 0 {
+		_ = json.Unmarshal(orgApprovals, &approvers)
+	}
+
+	for _, a := range approvers {
+		if bytes.Equal(a, tx.From) {
+			return nil, cache, Errors.DuplicateUpgradeApproval
+		}
+	}
+	approvers = append(approvers, tx.From)
+
+	if len(approvers) < l.upgradeThreshold {
+		approvals, _ := json.Marshal(approvers)
+		cache[string(approvalKey)] = &txResultCacheData{Data: approvals}
+
+		return []StateData{
+			{
+				Key:    approvalKey,
+				NewVal: approvals,
+				OrgVal: orgApprovals,
+			},
+		}, cache, Errors.Success
+	}
+
+	hashKey := BuildKey(StoragePrefixes.ContractHash, tx.To)
+	oldCodeHash, err := l.getKVWithCache(hashKey, cache)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	historyKey := BuildKey(StoragePrefixes.ContractUpgrades, tx.To)
+	orgHistory, err := l.getKVWithCache(historyKey, cache)
+	if err != nil {
+		return nil, cache, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	var history []UpgradeRecord
+	if len(orgHistory) != 0 {
+		_ = json.Unmarshal(orgHistory, &history)
+	}
+
+	history = append(history, UpgradeRecord{
+		OldCodeHash: oldCodeHash,
+		NewCodeHash: newCodeHash,
+		Height:      blk.Header.Height,
+		TxHash:      tx.DataSeal.Hash,
+		Approvers:   approvers,
+	})
+	newHistory, _ := json.Marshal(history)
+
+	cache[string(codeKey)] = &txResultCacheData{Data: tx.Data}
+	cache[string(hashKey)] = &txResultCacheData{Data: newCodeHash}
+	cache[string(approvalKey)] = &txResultCacheData{Data: nil}
+	cache[string(historyKey)] = &txResultCacheData{Data: newHistory}
+
+	return []StateData{
+		{
+			Key:    codeKey,
+			NewVal: tx.Data,
+			OrgVal: oldCode,
+		},
+		{
+			Key:    hashKey,
+			NewVal: newCodeHash,
+			OrgVal: oldCodeHash,
+		},
+		{
+			Key:    approvalKey,
+			NewVal: nil,
+			OrgVal: orgApprovals,
+		},
+		{
+			Key:    historyKey,
+			NewVal: newHistory,
+			OrgVal: orgHistory,
+		},
+	}, cache, Errors.Success
+}
@@ -35,4 +35,21 @@ var Errors struct {
 
 	InvalidQuery     enum.ErrorElement
 	InvalidParameter enum.ErrorElement
+
+	InvalidGasPrice       enum.ErrorElement
+	InvalidGasLimit       enum.ErrorElement
+	BlockGasLimitExceeded enum.ErrorElement
+
+	InvalidContractABI enum.ErrorElement
+	NotContractCreator enum.ErrorElement
+
+	UpgradeNotAllowed        enum.ErrorElement
+	NotUpgradeAdmin          enum.ErrorElement
+	DuplicateUpgradeApproval enum.ErrorElement
+
+	SystemCallFailed enum.ErrorElement
+
+	InvalidNonce enum.ErrorElement
+
+	StateNotAvailable enum.ErrorElement
 }
\ No newline at end of file
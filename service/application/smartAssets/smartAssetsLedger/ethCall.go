@@ -0,0 +1,109 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/metadata/seal"
+	"math/big"
+)
+
+//GetCode returns the code of a contract, nil if there's no contract at the address
+func (l *Ledger) GetCode(address []byte) ([]byte, error) {
+	if isSystemContract(address) {
+		return systemContractCode, nil
+	}
+
+	key := BuildKey(StoragePrefixes.ContractCode, address)
+	codeKV, err := l.Storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !codeKV.Exists {
+		return nil, nil
+	}
+
+	return codeKV.Data, nil
+}
+
+//EthCall executes a message on the state of the last block without changing it, and returns the result
+//and the gas it used, intrinsic gas included. a message to an address without code is a plain transfer,
+//except the system contracts.
+func (l *Ledger) EthCall(from []byte, to []byte, data []byte, value *big.Int) (ret []byte, gasUsed uint64, err error) {
+	return l.ethCallOnBlock(from, to, data, value, *l.chain.GetLastBlock())
+}
+
+//EthCallAt executes a message like EthCall on the state after the block at height
+func (l *Ledger) EthCallAt(from []byte, to []byte, data []byte, value *big.Int, height uint64) (ret []byte, gasUsed uint64, err error) {
+	hl, blk, err := l.ledgerAtHeight(height)
+	if err != nil {
+		return
+	}
+
+	return hl.ethCallOnBlock(from, to, data, value, blk)
+}
+
+func (l *Ledger) ethCallOnBlock(from []byte, to []byte, data []byte, value *big.Int, blk block.Entity) (ret []byte, gasUsed uint64, err error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	tx := Transaction{
+		TransactionData: TransactionData{
+			From:  from,
+			To:    to,
+			Value: value.String(),
+			Data:  data,
+		},
+
+		DataSeal: seal.Entity{
+			SignerPublicKey: from,
+		},
+	}
+
+	var preExec txPreActuator
+	if len(to) == 0 {
+		tx.Type = TxType.CreateContract.String()
+		preExec = l.preContractCreation
+	} else {
+		code, codeErr := l.GetCode(to)
+		if codeErr != nil {
+			return nil, 0, Errors.DBError.NewErrorWithNewMessage(codeErr.Error())
+		}
+
+		if len(code) == 0 && !isSystemContract(to) {
+			return nil, txIntrinsicGas, nil
+		}
+
+		tx.Type = TxType.ContractCall.String()
+		preExec = l.preContractCall
+	}
+
+	resultCache := l.newTxResultCache()
+	resultCache[CachedTxGasKey].gasLeft = l.blockGasLimit - txIntrinsicGas
+
+	_, _, execErr := preExec(tx, resultCache, blk)
+
+	gasUsed = l.blockGasLimit - resultCache[CachedTxGasKey].gasLeft
+	ret = resultCache[CachedContractReturnData].Data
+	if execErr != Errors.Success {
+		err, _ = l.revertError(execErr, to, ret)
+	}
+
+	return
+}
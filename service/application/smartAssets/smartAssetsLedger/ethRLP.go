@@ -0,0 +1,187 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"errors"
+	"math/big"
+)
+
+//rlpItem is a decoded recursive length prefix item, raw holds the whole encoding of the item
+type rlpItem struct {
+	isList  bool
+	content []byte
+	raw     []byte
+	list    []rlpItem
+}
+
+func rlpReadLength(data []byte, lenOfLen int) (uint64, error) {
+	if len(data) < lenOfLen || lenOfLen > 8 {
+		return 0, errors.New("rlp: length out of range")
+	}
+
+	if data[0] == 0 {
+		return 0, errors.New("rlp: non-canonical length")
+	}
+
+	var l uint64
+	for _, b := range data[:lenOfLen] {
+		l = l << 8 | uint64(b)
+	}
+
+	return l, nil
+}
+
+func rlpDecode(data []byte) (item rlpItem, rest []byte, err error) {
+	if len(data) == 0 {
+		err = errors.New("rlp: empty input")
+		return
+	}
+
+	prefix := data[0]
+	var headerLen, contentLen uint64
+	switch {
+	case prefix < 0x80:
+		headerLen, contentLen = 0, 1
+
+	case prefix < 0xb8:
+		headerLen, contentLen = 1, uint64(prefix - 0x80)
+
+	case prefix < 0xc0:
+		lenOfLen := int(prefix - 0xb7)
+		contentLen, err = rlpReadLength(data[1:], lenOfLen)
+		headerLen = uint64(1 + lenOfLen)
+
+	case prefix < 0xf8:
+		item.isList = true
+		headerLen, contentLen = 1, uint64(prefix - 0xc0)
+
+	default:
+		item.isList = true
+		lenOfLen := int(prefix - 0xf7)
+		contentLen, err = rlpReadLength(data[1:], lenOfLen)
+		headerLen = uint64(1 + lenOfLen)
+	}
+
+	if err != nil {
+		return
+	}
+
+	total := headerLen + contentLen
+	if total < headerLen || total > uint64(len(data)) {
+		err = errors.New("rlp: value size exceeds available input")
+		return
+	}
+
+	item.raw = data[:total]
+	item.content = data[headerLen:total]
+	rest = data[total:]
+
+	if !item.isList {
+		return
+	}
+
+	for remain := item.content; len(remain) > 0; {
+		var sub rlpItem
+		sub, remain, err = rlpDecode(remain)
+		if err != nil {
+			return
+		}
+		item.list = append(item.list, sub)
+	}
+
+	return
+}
+
+func (r rlpItem) bytes() ([]byte, error) {
+	if r.isList {
+		return nil, errors.New("rlp: expected bytes but got list")
+	}
+
+	return r.content, nil
+}
+
+func (r rlpItem) uint64() (uint64, error) {
+	b, err := r.bytes()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) > 8 {
+		return 0, errors.New("rlp: integer too large")
+	}
+
+	var v uint64
+	for _, c := range b {
+		v = v << 8 | uint64(c)
+	}
+
+	return v, nil
+}
+
+func (r rlpItem) bigInt() (*big.Int, error) {
+	b, err := r.bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) > 32 {
+		return nil, errors.New("rlp: integer too large")
+	}
+
+	return big.NewInt(0).SetBytes(b), nil
+}
+
+func rlpEncodeLength(l int, offset byte) []byte {
+	if l < 56 {
+		return []byte{offset + byte(l)}
+	}
+
+	var lenBytes []byte
+	for v := uint64(l); v > 0; v >>= 8 {
+		lenBytes = append([]byte{byte(v)}, lenBytes...)
+	}
+
+	return append([]byte{offset + 55 + byte(len(lenBytes))}, lenBytes...)
+}
+
+func rlpEncodeBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+
+	return append(rlpEncodeLength(len(b), 0x80), b...)
+}
+
+func rlpEncodeUint64(v uint64) []byte {
+	var b []byte
+	for ; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+
+	return rlpEncodeBytes(b)
+}
+
+//rlpEncodeList wraps already encoded items into a list
+func rlpEncodeList(encodedItems ...[]byte) []byte {
+	var content []byte
+	for _, it := range encodedItems {
+		content = append(content, it...)
+	}
+
+	return append(rlpEncodeLength(len(content), 0xc0), content...)
+}
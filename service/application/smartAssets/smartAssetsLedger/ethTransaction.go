@@ -0,0 +1,294 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/crypto/hashes/sha3"
+	"github.com/SealSC/SealABC/metadata/seal"
+	"github.com/btcsuite/btcd/btcec"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+//EthereumSignerAlgorithm marks a transaction seal holding a signed raw ethereum transaction
+const EthereumSignerAlgorithm = "ethereum"
+
+const (
+	ethLegacyTxType     = 0
+	ethAccessListTxType = 1
+	ethDynamicFeeTxType = 2
+
+	ethAddressLen = 20
+)
+
+var secp256k1HalfN = big.NewInt(0).Rsh(btcec.S256().N, 1)
+
+//EthTransaction is the decoded content of a signed raw ethereum transaction
+type EthTransaction struct {
+	Type     byte
+	ChainID  uint64
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       []byte
+	Value    *big.Int
+	Data     []byte
+
+	From     []byte
+	Hash     []byte
+}
+
+func keccak256(data ...[]byte) []byte {
+	return sha3.Keccak256.Sum(bytes.Join(data, nil))
+}
+
+//ethFieldIndex is the position of the fields in the rlp list of each ethereum transaction type
+type ethFieldIndex struct {
+	fieldCount int
+	chainID    int
+	nonce      int
+	gasPrice   int
+	gasLimit   int
+	to         int
+	value      int
+	data       int
+	v          int
+}
+
+var ethFieldIndexes = map[byte]ethFieldIndex {
+	ethLegacyTxType:     {9, -1, 0, 1, 2, 3, 4, 5, 6},
+	ethAccessListTxType: {11, 0, 1, 2, 3, 4, 5, 6, 8},
+	ethDynamicFeeTxType: {12, 0, 1, 3, 4, 5, 6, 7, 9},
+}
+
+func recoverEthSender(sigHash []byte, recID byte, r []byte, s []byte) ([]byte, error) {
+	if len(r) > 32 || len(s) > 32 || recID > 1 {
+		return nil, errors.New("invalid ethereum signature")
+	}
+
+	sVal := big.NewInt(0).SetBytes(s)
+	if sVal.Sign() == 0 || sVal.Cmp(secp256k1HalfN) > 0 {
+		return nil, errors.New("invalid ethereum signature s value")
+	}
+
+	compactSig := make([]byte, 65)
+	compactSig[0] = 27 + recID
+	copy(compactSig[33-len(r):33], r)
+	copy(compactSig[65-len(s):], s)
+
+	pub, _, err := btcec.RecoverCompact(btcec.S256(), compactSig, sigHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return EthAddressOfPublicKey(pub.SerializeUncompressed()), nil
+}
+
+//EthAddressOfPublicKey returns the ethereum address of an uncompressed secp256k1 public key
+func EthAddressOfPublicKey(uncompressed []byte) []byte {
+	hash := keccak256(uncompressed[1:])
+	return hash[len(hash) - ethAddressLen:]
+}
+
+//DecodeEthTransaction decodes a signed raw ethereum transaction and recovers its sender,
+//legacy (EIP-155 protected only), EIP-2930 and EIP-1559 transactions are supported.
+func DecodeEthTransaction(raw []byte) (tx EthTransaction, err error) {
+	if len(raw) == 0 {
+		err = errors.New("empty ethereum transaction")
+		return
+	}
+
+	payload := raw
+	if raw[0] <= 0x7f {
+		tx.Type = raw[0]
+		payload = raw[1:]
+	}
+
+	idx, supported := ethFieldIndexes[tx.Type]
+	if !supported {
+		err = fmt.Errorf("unsupported ethereum transaction type %d", tx.Type)
+		return
+	}
+
+	item, rest, err := rlpDecode(payload)
+	if err != nil {
+		return
+	}
+
+	if !item.isList || len(rest) != 0 || len(item.list) != idx.fieldCount {
+		err = errors.New("malformed ethereum transaction")
+		return
+	}
+
+	fields := item.list
+	if tx.Nonce, err = fields[idx.nonce].uint64(); err != nil {
+		return
+	}
+	if tx.GasPrice, err = fields[idx.gasPrice].bigInt(); err != nil {
+		return
+	}
+	if tx.GasLimit, err = fields[idx.gasLimit].uint64(); err != nil {
+		return
+	}
+	if tx.To, err = fields[idx.to].bytes(); err != nil {
+		return
+	}
+	if tx.Value, err = fields[idx.value].bigInt(); err != nil {
+		return
+	}
+	if tx.Data, err = fields[idx.data].bytes(); err != nil {
+		return
+	}
+
+	if len(tx.To) != 0 && len(tx.To) != ethAddressLen {
+		err = errors.New("invalid ethereum transaction recipient")
+		return
+	}
+
+	v, err := fields[idx.v].uint64()
+	if err != nil {
+		return
+	}
+
+	r, err := fields[idx.v + 1].bytes()
+	if err != nil {
+		return
+	}
+
+	s, err := fields[idx.v + 2].bytes()
+	if err != nil {
+		return
+	}
+
+	//rebuild the signing payload from the raw encoding of the unsigned fields
+	var unsigned [][]byte
+	for _, f := range fields[:idx.v] {
+		unsigned = append(unsigned, f.raw)
+	}
+
+	var sigHash []byte
+	var recID byte
+	if tx.Type == ethLegacyTxType {
+		if v < 35 {
+			err = errors.New("only replay protected (EIP-155) legacy transactions are supported")
+			return
+		}
+
+		tx.ChainID = (v - 35) / 2
+		recID = byte((v - 35) % 2)
+		unsigned = append(unsigned, rlpEncodeUint64(tx.ChainID), rlpEncodeUint64(0), rlpEncodeUint64(0))
+		sigHash = keccak256(rlpEncodeList(unsigned...))
+		tx.Hash = keccak256(raw)
+	} else {
+		if tx.ChainID, err = fields[idx.chainID].uint64(); err != nil {
+			return
+		}
+
+		if v > 1 {
+			err = errors.New("invalid ethereum signature y parity")
+			return
+		}
+
+		recID = byte(v)
+		sigHash = keccak256([]byte{tx.Type}, rlpEncodeList(unsigned...))
+		tx.Hash = keccak256(raw)
+	}
+
+	tx.From, err = recoverEthSender(sigHash, recID, r, s)
+	return
+}
+
+//verifyEthTransaction checks the transaction is the same as the signed raw ethereum transaction in its seal
+func (t *Transaction) verifyEthTransaction(ethChainID uint64) (passed bool, err error) {
+	ethTx, err := DecodeEthTransaction(t.DataSeal.Signature)
+	if err != nil {
+		return
+	}
+
+	if ethTx.ChainID != ethChainID {
+		return false, fmt.Errorf("ethereum chain id %d is not %d", ethTx.ChainID, ethChainID)
+	}
+
+	if !bytes.Equal(ethTx.Hash, t.DataSeal.Hash) ||
+	   !bytes.Equal(ethTx.From, t.DataSeal.SignerPublicKey) ||
+	   !bytes.Equal(ethTx.To, t.To) ||
+	   !bytes.Equal(ethTx.Data, t.Data) ||
+	   ethTx.Value.String() != t.Value ||
+	   ethTx.GasPrice.String() != t.GasPrice ||
+	   ethTx.GasLimit != t.GasLimit ||
+	   ethTx.Nonce != t.Nonce {
+		return false, errors.New("transaction is not equal to the signed ethereum transaction")
+	}
+
+	isCreation := t.Type == TxType.CreateContract.String()
+	if isCreation != (len(ethTx.To) == 0) {
+		return false, errors.New("transaction type is not match the ethereum transaction")
+	}
+
+	return true, nil
+}
+
+//TransactionFromEthereum builds a smart assets transaction from a signed raw ethereum transaction,
+//a call to an address without code is a transfer.
+func (l *Ledger) TransactionFromEthereum(raw []byte, chainID string) (tx Transaction, err error) {
+	if l.EthChainID == 0 {
+		err = errors.New("ethereum transaction is not enabled")
+		return
+	}
+
+	ethTx, err := DecodeEthTransaction(raw)
+	if err != nil {
+		return
+	}
+
+	if ethTx.ChainID != l.EthChainID {
+		err = fmt.Errorf("ethereum chain id %d is not %d", ethTx.ChainID, l.EthChainID)
+		return
+	}
+
+	txType := TxType.ContractCall.String()
+	if len(ethTx.To) == 0 {
+		txType = TxType.CreateContract.String()
+	} else if code, _ := l.GetCode(ethTx.To); len(code) == 0 && !isSystemContract(ethTx.To) {
+		txType = TxType.Transfer.String()
+	}
+
+	tx = Transaction{
+		TransactionData: TransactionData{
+			Type:     txType,
+			From:     ethTx.From,
+			To:       ethTx.To,
+			Value:    ethTx.Value.String(),
+			Data:     ethTx.Data,
+			GasPrice: ethTx.GasPrice.String(),
+			GasLimit: ethTx.GasLimit,
+			ChainID:  chainID,
+			Nonce:    ethTx.Nonce,
+		},
+
+		DataSeal: seal.Entity{
+			Hash:            ethTx.Hash,
+			Signature:       raw,
+			SignerPublicKey: ethTx.From,
+			SignerAlgorithm: EthereumSignerAlgorithm,
+		},
+	}
+
+	return
+}
@@ -31,30 +31,34 @@ import (
 )
 
 const defaultStackDepth = 1000
-func constTransactionGasPrice() *evmInt256.Int {
-	return evmInt256.New(1)
-}
-func constTransactionGasLimit() *evmInt256.Int {
-	return evmInt256.New(100000000)
+
+func (l Ledger) txGasPrice(tx Transaction) *evmInt256.Int {
+	if price, valid := big.NewInt(0).SetString(tx.GasPrice, 10); valid {
+		return evmInt256.FromBigInt(price)
+	}
+
+	return evmInt256.FromBigInt(l.minGasPrice)
 }
 
+//newEVM builds an evm for the transaction, gasLimit is the gas left for the execution.
+//contracts created by the evm change nonces in the result cache of the store, which is built for this execution.
 func (l Ledger)newEVM(tx Transaction, callback SealEVM.EVMResultCallback,
-	blk block.Entity, blockGasLimit *evmInt256.Int) (*SealEVM.EVM, *environment.Contract, error) {
+	blk block.Entity, gasLimit *evmInt256.Int, store *contractStorage) (*SealEVM.EVM, *environment.Contract, error) {
 
 	evmTransaction := environment.Transaction{
 		TxHash:   tx.DataSeal.Hash,
 		Origin:   common.BytesDataToEVMIntHash(tx.DataSeal.Hash),
-		GasPrice: constTransactionGasPrice(),
-		GasLimit: constTransactionGasLimit(),
+		GasPrice: l.txGasPrice(tx),
+		GasLimit: gasLimit,
 	}
 
 	hashByte := l.CryptoTools.HashCalculator.Sum(tx.Data)
 	contractHash := common.BytesDataToEVMIntHash(hashByte)
-	caller := common.BytesDataToEVMIntHash(tx.DataSeal.SignerPublicKey)
+	caller := common.BytesDataToEVMIntHash(tx.From)
 	var contractAddress *evmInt256.Int
 	var contractCode []byte
 	if len(tx.To) == 0 {
-		contractAddress = l.storageForEVM.CreateAddress(caller, evmTransaction)
+		contractAddress = common.BytesDataToEVMIntHash(ContractAddress(tx.From, tx.Nonce))
 		contractCode = tx.Data
 	} else {
 		contractAddress = common.BytesDataToEVMIntHash(tx.To)
@@ -75,7 +79,7 @@ func (l Ledger)newEVM(tx Transaction, callback SealEVM.EVMResultCallback,
 
 	return SealEVM.New(SealEVM.EVMParam{
 		MaxStackDepth:  defaultStackDepth,
-		ExternalStore:  &l.storageForEVM,
+		ExternalStore:  store,
 		ResultCallback: callback,
 		Context:        &environment.Context{
 			Block:       environment.Block{
@@ -83,7 +87,7 @@ func (l Ledger)newEVM(tx Transaction, callback SealEVM.EVMResultCallback,
 				Timestamp:  evmInt256.New(int64(blk.Header.Timestamp)),
 				Number:     evmInt256.New(int64(blk.Header.Height)),
 				Difficulty: evmInt256.New(0),
-				GasLimit:   blockGasLimit,
+				GasLimit:   gasLimit,
 				Hash:       common.BytesDataToEVMIntHash(blk.BlankSeal.Hash),
 			},
 			Contract:    contract,
@@ -106,7 +110,7 @@ func (l Ledger) processEVMBalanceCache(cache storage.BalanceCache, resultCache t
 
 	balanceToChange := *newState
 	for _, k := range keys {
-		addr := cache[k].Address.Bytes()
+		addr := evmAddress(cache[k].Address)
 		val := cache[k].Balance.Int
 
 		var localBalance *big.Int
@@ -161,6 +165,10 @@ func (l Ledger) processEVMNamedStateCache(ns string, cache storage.Cache, org st
 			NewVal: cacheData.Bytes(),
 			OrgVal: orgVal,
 		})
+
+		if l.storageForEVM.tracer != nil {
+			l.storageForEVM.tracer.storageWritten(ns, k, cacheData.Bytes())
+		}
 	}
 
 	*newState = state
@@ -231,7 +239,7 @@ func (l Ledger) processEVMDestructs(cache storage.Cache, newState *[]StateData)
 	*newState = state
 }
 
-func (l Ledger) newStateFromEVMResult(evmRet SealEVM.ExecuteResult, cache txResultCache) []StateData {
+func (l Ledger) newStateFromEVMResult(evmRet SealEVM.ExecuteResult, cache txResultCache, store *contractStorage) []StateData {
 	evmCache := evmRet.StorageCache
 	var newState []StateData
 
@@ -239,6 +247,7 @@ func (l Ledger) newStateFromEVMResult(evmRet SealEVM.ExecuteResult, cache txResu
 	l.processEVMStateCache(evmCache.CachedData, evmCache.OriginalData, &newState)
 	l.processEVMLogCache(evmCache.Logs, &newState)
 	l.processEVMDestructs(evmCache.Destructs, &newState)
+	newState = append(newState, l.nonceStateOfCreations(cache, store.nonceChanges)...)
 
 	return newState
 }
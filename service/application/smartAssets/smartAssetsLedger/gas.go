@@ -0,0 +1,291 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/metadata/block"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+//gas charged for every transaction before its execution
+const txIntrinsicGas = 21000
+
+const defaultBlockGasLimit = 100000000
+
+type GasConfig struct {
+	BlockGasLimit uint64
+	MinGasPrice   string
+
+	//hex address receives the fees, fees are burned when it's empty
+	FeeCollector  string
+}
+
+func DefaultGasConfig() GasConfig {
+	return GasConfig{
+		BlockGasLimit: defaultBlockGasLimit,
+		MinGasPrice:   "1",
+		FeeCollector:  "",
+	}
+}
+
+func (l *Ledger) MinGasPrice() *big.Int {
+	return big.NewInt(0).Set(l.minGasPrice)
+}
+
+func (l *Ledger) SetGasConfig(cfg GasConfig) (err error) {
+	if cfg.BlockGasLimit < txIntrinsicGas {
+		return errors.New("block gas limit is less than the intrinsic gas of a transaction")
+	}
+
+	minPrice, valid := big.NewInt(0).SetString(cfg.MinGasPrice, 10)
+	if !valid || minPrice.Sign() < 0 {
+		return errors.New("invalid minimum gas price")
+	}
+
+	collector, err := hex.DecodeString(cfg.FeeCollector)
+	if err != nil {
+		return errors.New("invalid fee collector: " + err.Error())
+	}
+
+	l.blockGasLimit = cfg.BlockGasLimit
+	l.minGasPrice = minPrice
+	l.feeCollector = collector
+	return
+}
+
+func (l *Ledger) checkTxGas(tx Transaction) (price *big.Int, err error) {
+	price, valid := big.NewInt(0).SetString(tx.GasPrice, 10)
+	if !valid {
+		return nil, Errors.InvalidGasPrice
+	}
+
+	if price.Cmp(l.minGasPrice) < 0 {
+		return nil, Errors.InvalidGasPrice.NewErrorWithNewMessage(fmt.Sprintf("gas price is lower than %s", l.minGasPrice.String()))
+	}
+
+	if tx.GasLimit < txIntrinsicGas {
+		return nil, Errors.InvalidGasLimit.NewErrorWithNewMessage(fmt.Sprintf("gas limit is lower than %d", txIntrinsicGas))
+	}
+
+	if tx.GasLimit > l.blockGasLimit {
+		return nil, Errors.InvalidGasLimit.NewErrorWithNewMessage(fmt.Sprintf("gas limit is higher than block gas limit %d", l.blockGasLimit))
+	}
+
+	return
+}
+
+//maxTxCost is the balance sender needs to pay the value and all the gas of a transaction
+func maxTxCost(tx Transaction, price *big.Int) *big.Int {
+	cost := big.NewInt(0).Mul(price, big.NewInt(0).SetUint64(tx.GasLimit))
+	if value, valid := big.NewInt(0).SetString(tx.Value, 10); valid && value.Sign() > 0 {
+		cost.Add(cost, value)
+	}
+
+	return cost
+}
+
+func (c txResultCache) snapshotBalances() map[string] *big.Int {
+	snapshot := map[string] *big.Int{}
+	for k, data := range c {
+		if data.val != nil {
+			snapshot[k] = big.NewInt(0).Set(data.val)
+		}
+	}
+
+	return snapshot
+}
+
+func (c txResultCache) restoreBalances(snapshot map[string] *big.Int) {
+	for k, data := range c {
+		if data.val == nil {
+			continue
+		}
+
+		if val, exists := snapshot[k]; exists {
+			data.val = val
+		} else {
+			delete(c, k)
+		}
+	}
+}
+
+func (l *Ledger) chargeFee(payer []byte, fee *big.Int, cache txResultCache) (state []StateData, err error) {
+	if fee.Sign() == 0 {
+		return
+	}
+
+	payerBalance, err := l.getBalance(payer, cache)
+	if err != nil {
+		return
+	}
+
+	if payerBalance.Cmp(fee) < 0 {
+		fee = big.NewInt(0).Set(payerBalance)
+	}
+
+	orgPayerBalance := payerBalance.Bytes()
+	payerBalance.Sub(payerBalance, fee)
+	state = append(state, StateData{
+		Key:    BuildKey(StoragePrefixes.Balance, payer),
+		NewVal: payerBalance.Bytes(),
+		OrgVal: orgPayerBalance,
+	})
+
+	if len(l.feeCollector) == 0 {
+		return
+	}
+
+	collectorBalance, err := l.getBalance(l.feeCollector, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	orgCollectorBalance := collectorBalance.Bytes()
+	collectorBalance.Add(collectorBalance, fee)
+	state = append(state, StateData{
+		Key:    BuildKey(StoragePrefixes.Balance, l.feeCollector),
+		NewVal: collectorBalance.Bytes(),
+		OrgVal: orgCollectorBalance,
+	})
+
+	return
+}
+
+//verifyTxPayable checks the type and gas settings of a transaction and that its sender can pay the value and all the gas.
+//a transaction fails these checks can't be charged, so it must never be packed into a block.
+func (l *Ledger) verifyTxPayable(tx Transaction, cache txResultCache) (price *big.Int, err error) {
+	if _, exists := l.preActuators[tx.Type]; !exists {
+		return nil, Errors.InvalidTransactionType
+	}
+
+	price, err = l.checkTxGas(tx)
+	if err != nil {
+		return
+	}
+
+	balance, err := l.getBalance(tx.From, cache)
+	if err != nil {
+		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if balance.Cmp(maxTxCost(tx, price)) < 0 {
+		return nil, Errors.InsufficientBalance
+	}
+
+	return
+}
+
+//isTxNeverPayable returns true if the transaction failed verifyTxPayable for a reason later blocks can't change
+func isTxNeverPayable(err error) bool {
+	errEl, isEl := err.(enum.ErrorElement)
+	if !isEl {
+		return false
+	}
+
+	switch errEl.Code() {
+	case Errors.InvalidTransactionType.Code(), Errors.InvalidGasPrice.Code(), Errors.InvalidGasLimit.Code():
+		return true
+	default:
+		return false
+	}
+}
+
+//executeTransaction runs a transaction within its gas limit and charges the fee of the used gas from the sender,
+//state changes of a failed transaction are dropped but its fee is still charged and the nonce of the sender increased.
+//the transaction must have passed verifyTxPayable with the same cache.
+func (l *Ledger) executeTransaction(tx Transaction, cache txResultCache, blk block.Entity) (newState []StateData, gasUsed uint64, err error) {
+	price, err := l.verifyTxPayable(tx, cache)
+	if err != nil {
+		return
+	}
+	preExec := l.preActuators[tx.Type]
+
+	snapshot := cache.snapshotBalances()
+	cache[CachedTxGasKey].gasLeft = tx.GasLimit - txIntrinsicGas
+
+	execState, _, err := preExec(tx, cache, blk)
+	gasUsed = tx.GasLimit - cache[CachedTxGasKey].gasLeft
+	if err != Errors.Success {
+		cache.restoreBalances(snapshot)
+		cache.releaseSystemCalls(tx)
+		execState = nil
+	}
+
+	fee := big.NewInt(0).Mul(price, big.NewInt(0).SetUint64(gasUsed))
+	feeState, feeErr := l.chargeFee(tx.From, fee, cache)
+	if feeErr != nil {
+		cache.restoreBalances(snapshot)
+		cache.releaseSystemCalls(tx)
+		return nil, 0, Errors.DBError.NewErrorWithNewMessage(feeErr.Error())
+	}
+
+	nonceState, nonceErr := l.increaseNonce(tx.From, cache)
+	if nonceErr != nil {
+		cache.restoreBalances(snapshot)
+		cache.releaseSystemCalls(tx)
+		return nil, 0, Errors.DBError.NewErrorWithNewMessage(nonceErr.Error())
+	}
+
+	newState = append(execState, feeState...)
+	newState = append(newState, nonceState)
+	return
+}
+
+//poolRecordByFee returns the pending transactions ordered by gas price from high to low, arrival order is kept for same price
+func (l *Ledger) poolRecordByFee() []string {
+	prices := map[string] *big.Int{}
+	for _, txHash := range l.txPoolRecord {
+		price, valid := big.NewInt(0).SetString(l.txPool[txHash].GasPrice, 10)
+		if !valid {
+			price = big.NewInt(0)
+		}
+		prices[txHash] = price
+	}
+
+	sorted := make([]string, len(l.txPoolRecord))
+	copy(sorted, l.txPoolRecord)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return prices[sorted[i]].Cmp(prices[sorted[j]]) > 0
+	})
+
+	return sorted
+}
+
+func (l *Ledger) newTxResultCache() txResultCache {
+	return txResultCache {
+		CachedBlockGasKey: &txResultCacheData{
+			gasLeft: l.blockGasLimit,
+		},
+
+		CachedTxGasKey: &txResultCacheData{
+			gasLeft: l.blockGasLimit,
+		},
+
+		CachedContractReturnData: &txResultCacheData{
+			Data: nil,
+		},
+
+		CachedContractCreationAddress: &txResultCacheData{
+			Data: nil,
+		},
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsABI"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"math/big"
+)
+
+type GenesisContract struct {
+	Address string
+	Code    string
+	ABI     string
+}
+
+//GenesisConfig is the smart assets part of the genesis file.
+//initial balances are allocated from the supply of the system assets owner,
+//initial contracts are deployed with their runtime code, no constructor is executed,
+//the system assets owner is taken as their creator.
+type GenesisConfig struct {
+	Balances  map[string]string
+	Contracts []GenesisContract
+}
+
+func (l *Ledger) ApplyGenesis(cfg GenesisConfig) (err error) {
+	sysAssets, exists, err := l.getSystemAssets()
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return errors.New("no system assets")
+	}
+
+	owner, err := hex.DecodeString(sysAssets.Owner)
+	if err != nil {
+		return
+	}
+
+	ownerBalance, err := l.BalanceOf(owner)
+	if err != nil {
+		return
+	}
+
+	var kvList []kvDatabase.KVItem
+	for addr, amount := range cfg.Balances {
+		addrBytes, decodeErr := hex.DecodeString(addr)
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		val, valid := big.NewInt(0).SetString(amount, 10)
+		if !valid || val.Sign() < 0 {
+			return errors.New("invalid genesis balance of " + addr)
+		}
+
+		if ownerBalance.Cmp(val) < 0 {
+			return errors.New("genesis balances exceed the supply of system assets")
+		}
+
+		ownerBalance.Sub(ownerBalance, val)
+
+		balance, getErr := l.BalanceOf(addrBytes)
+		if getErr != nil {
+			return getErr
+		}
+
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:    BuildKey(StoragePrefixes.Balance, addrBytes),
+			Data:   balance.Add(balance, val).Bytes(),
+			Exists: true,
+		})
+	}
+
+	kvList = append(kvList, kvDatabase.KVItem{
+		Key:    BuildKey(StoragePrefixes.Balance, owner),
+		Data:   ownerBalance.Bytes(),
+		Exists: true,
+	})
+
+	for _, c := range cfg.Contracts {
+		addrBytes, decodeErr := hex.DecodeString(c.Address)
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		code, decodeErr := hex.DecodeString(c.Code)
+		if decodeErr != nil {
+			return decodeErr
+		}
+
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:    BuildKey(StoragePrefixes.ContractCode, addrBytes),
+			Data:   code,
+			Exists: true,
+		}, kvDatabase.KVItem{
+			Key:    BuildKey(StoragePrefixes.ContractHash, addrBytes),
+			Data:   l.CryptoTools.HashCalculator.Sum(code),
+			Exists: true,
+		}, kvDatabase.KVItem{
+			Key:    BuildKey(StoragePrefixes.ContractCreator, addrBytes),
+			Data:   owner,
+			Exists: true,
+		})
+
+		if c.ABI != "" {
+			if _, abiErr := smartAssetsABI.Parse([]byte(c.ABI)); abiErr != nil {
+				return errors.New("invalid abi of genesis contract " + c.Address + ": " + abiErr.Error())
+			}
+
+			kvList = append(kvList, kvDatabase.KVItem{
+				Key:    BuildKey(StoragePrefixes.ContractABI, addrBytes),
+				Data:   []byte(c.ABI),
+				Exists: true,
+			})
+		}
+	}
+
+	return l.Storage.BatchPut(kvList)
+}
@@ -54,6 +54,19 @@ type Ledger struct {
 	CryptoTools   crypto.Tools
 	Storage       kvDatabase.IDriver
 
+	//name of the application whose requests in blocks hold the transactions of the ledger
+	ApplicationName string
+
+	//chain id of the signed ethereum transactions accepted by the ledger, zero means not accepted
+	EthChainID    uint64
+
+	blockGasLimit uint64
+	minGasPrice   *big.Int
+	feeCollector  []byte
+
+	upgradeAdmins    map[string] bool
+	upgradeThreshold int
+
 	storageForEVM contractStorage
 }
 
@@ -87,25 +100,16 @@ func (l *Ledger) LoadGenesisAssets(owner []byte, assets BaseAssetsData) error  {
 		return errors.New("no owner for system assets")
 	}
 
-	signer, err := l.CryptoTools.SignerGenerator.NewSigner(nil)
-	if err != nil {
-		return err
-	}
-
-	pk := signer.PrivateKeyBytes()
+	//system assets has no issuer to sign it, seals only hold the hash so every node gets the same assets
 	metaBytes, _ := structSerializer.ToMFBytes(assets)
-	metaSeal := seal.Entity{}
-	err = metaSeal.Sign(metaBytes, l.CryptoTools, pk)
-	if err != nil {
-		return err
+	metaSeal := seal.Entity{
+		Hash: l.CryptoTools.HashCalculator.Sum(metaBytes),
 	}
 
 	assets.Supply = supply
 	issuedBytes, _ := structSerializer.ToMFBytes(assets)
-	issuedSeal := seal.Entity{}
-	err = issuedSeal.Sign(issuedBytes, l.CryptoTools, pk)
-	if err != nil {
-		return err
+	issuedSeal := seal.Entity{
+		Hash: l.CryptoTools.HashCalculator.Sum(issuedBytes),
 	}
 
 	sysAssets := BaseAssets{
@@ -148,16 +152,37 @@ func (l *Ledger) AddTx(req blockchainRequest.Entity) error {
 		return errors.New("transaction type is not equal to block request action")
 	}
 
-	if !bytes.Equal(tx.From, tx.DataSeal.SignerPublicKey) {
-		return errors.New("invalid sender")
+	if tx.ChainID != req.ChainID || tx.ExpiryHeight != req.ExpiryHeight {
+		return errors.New("transaction chain id or expiry height is not equal to block request")
 	}
 
-	valid, err := tx.verify(l.CryptoTools.HashCalculator)
+	valid, err := tx.verify(l.CryptoTools.HashCalculator, l.EthChainID)
 	if !valid {
 		return err
 	}
 
-	//todo: check balance to ensure client has enough base-assets to pay transaction basic fee
+	nonce, err := l.NonceOf(tx.From)
+	if err != nil {
+		return err
+	}
+
+	if tx.Nonce < nonce {
+		return Errors.InvalidNonce.NewErrorWithNewMessage(fmt.Sprintf("nonce %d is lower than the next nonce %d", tx.Nonce, nonce))
+	}
+
+	price, err := l.checkTxGas(tx)
+	if err != nil {
+		return err
+	}
+
+	balance, err := l.BalanceOf(tx.From)
+	if err != nil {
+		return err
+	}
+
+	if balance.Cmp(maxTxCost(tx, price)) < 0 {
+		return Errors.InsufficientBalance
+	}
 
 	l.poolLock.Lock()
 	defer l.poolLock.Unlock()
@@ -182,6 +207,12 @@ func (l *Ledger) AddTx(req blockchainRequest.Entity) error {
 		return errors.New("duplicate pending transaction")
 	}
 
+	for _, pending := range l.txPool {
+		if bytes.Equal(pending.From, tx.From) && pending.Nonce == tx.Nonce {
+			return errors.New("duplicate pending nonce")
+		}
+	}
+
 	l.txPool[txHash] = &tx
 	l.txPoolRecord = append(l.txPoolRecord, txHash)
 	l.clientTxCount[client] = clientTxCount + 1
@@ -194,10 +225,18 @@ func (l Ledger) txResultCheck(orgResult TransactionResult, execResult Transactio
 		return errors.New(fmt.Sprintf("transaction %x verify failed", txHash))
 	}
 
+	if orgResult.GasUsed != execResult.GasUsed {
+		return errors.New(fmt.Sprintf("transaction %x has different gas used", txHash))
+	}
+
 	if orgResult.ErrorCode != execResult.ErrorCode {
 		return errors.New(fmt.Sprintf("transaction %x has different error code", txHash))
 	}
 
+	if orgResult.RevertReason != execResult.RevertReason {
+		return errors.New(fmt.Sprintf("transaction %x has different revert reason", txHash))
+	}
+
 	if len(orgResult.NewState) != len(execResult.NewState) {
 		return errors.New(fmt.Sprintf("transaction %x has different count state to change", txHash))
 	}
@@ -218,19 +257,7 @@ func (l Ledger) PreExecute(txList TransactionList, blk block.Entity) (result []b
 	defer l.poolLock.Unlock()
 
 	txHash := map[string] bool{}
-	resultCache := txResultCache {
-		CachedBlockGasKey: &txResultCacheData{
-			gasLeft: constTransactionGasLimit().Uint64(),
-		},
-
-		CachedContractReturnData: &txResultCacheData{
-			Data: nil,
-		},
-
-		CachedContractCreationAddress: &txResultCacheData{
-			Data: nil,
-		},
-	}
+	resultCache := l.newTxResultCache()
 
 	for _, tx := range txList.Transactions {
 		hash := string(tx.DataSeal.Hash)
@@ -241,21 +268,43 @@ func (l Ledger) PreExecute(txList TransactionList, blk block.Entity) (result []b
 			break
 		}
 
-		_, err = tx.verify(l.CryptoTools.HashCalculator)
+		_, err = tx.verify(l.CryptoTools.HashCalculator, l.EthChainID)
+		if err != nil {
+			break
+		}
+
+		blockGas := resultCache[CachedBlockGasKey]
+		if tx.GasLimit > blockGas.gasLeft {
+			err = Errors.BlockGasLimitExceeded
+			break
+		}
+
+		err = l.checkNonce(tx, resultCache)
 		if err != nil {
 			break
 		}
 
-		if preExec, exists := l.preActuators[tx.Type]; exists {
-			newState, _, execErr := preExec(tx, resultCache, blk)
-			txForCheck := Transaction{}
-			l.setTxNewState(execErr, newState, &txForCheck)
+		//a transaction can't be charged is never valid in a block
+		_, err = l.verifyTxPayable(tx, resultCache)
+		if err != nil {
+			break
+		}
 
-			checkErr := l.txResultCheck(tx.TransactionResult, txForCheck.TransactionResult, tx.getHash())
-			if checkErr != nil {
-				err = checkErr
-				break
-			}
+		resultCache[CachedContractReturnData].Data = nil
+		resultCache[CachedContractCreationAddress].address = nil
+
+		newState, gasUsed, execErr := l.executeTransaction(tx, resultCache, blk)
+		blockGas.gasLeft -= gasUsed
+
+		txForCheck := Transaction{TransactionData: tx.TransactionData}
+		l.setTxNewState(execErr, newState, gasUsed, &txForCheck)
+		txForCheck.ReturnData = resultCache[CachedContractReturnData].Data
+		l.setTxRevertReason(&txForCheck)
+
+		checkErr := l.txResultCheck(tx.TransactionResult, txForCheck.TransactionResult, tx.getHash())
+		if checkErr != nil {
+			err = checkErr
+			break
 		}
 	}
 
@@ -311,8 +360,18 @@ func (l *Ledger) Execute(txList TransactionList, blk block.Entity) (result []byt
 		}
 	}
 
+	kvList = append(kvList, logIndexItems(txList, blk.Header.Height)...)
+	kvList = append(kvList, l.historyItems(txList, blk.Header.Height)...)
+
+	err = l.executeSystemCalls(txList)
+	if err != nil {
+		return
+	}
+
 	err = l.Storage.BatchPut(kvList)
 	if err != nil {
+		callKeys, callRecords := systemCallsOfTxList(txList)
+		_ = l.rollbackSystemCalls(callKeys, callRecords)
 		return 
 	}
 
@@ -320,19 +379,66 @@ func (l *Ledger) Execute(txList TransactionList, blk block.Entity) (result []byt
 	return
 }
 
-func (l Ledger) setTxNewState(err error, newState []StateData, tx *Transaction) {
+func (l *Ledger) Rollback(txList TransactionList, blk block.Entity) (err error) {
+	l.poolLock.Lock()
+	defer l.poolLock.Unlock()
+
+	err = l.rollbackSystemCalls(systemCallsOfTxList(txList))
+	if err != nil {
+		return
+	}
+
+	//the value to restore for each key is the original value recorded by its first change in the list
+	restored := map[string] bool{}
+	var restoreList []kvDatabase.KVItem
+	deleteList := logIndexKeys(txList, blk.Header.Height)
+	historyList, _ := historyKeys(txList, blk.Header.Height)
+	deleteList = append(deleteList, historyList...)
+	for _, tx := range txList.Transactions {
+		deleteList = append(deleteList, BuildKey(StoragePrefixes.Transaction, tx.DataSeal.Hash))
+
+		for _, s := range tx.TransactionResult.NewState {
+			if restored[string(s.Key)] {
+				continue
+			}
+			restored[string(s.Key)] = true
+
+			if len(s.OrgVal) == 0 {
+				deleteList = append(deleteList, s.Key)
+				continue
+			}
+
+			restoreList = append(restoreList, kvDatabase.KVItem {
+				Key:    s.Key,
+				Data:   s.OrgVal,
+				Exists: true,
+			})
+		}
+	}
+
+	err = l.Storage.BatchDelete(deleteList)
+	if err != nil {
+		return
+	}
+
+	return l.Storage.BatchPut(restoreList)
+}
+
+func (l Ledger) setTxNewState(err error, newState []StateData, gasUsed uint64, tx *Transaction) {
 	errEl := err.(enum.ErrorElement)
 
+	//a failed transaction still has the fee charged in its new state
+	tx.TransactionResult.NewState = newState
+	tx.TransactionResult.GasUsed = gasUsed
 	if errEl != Errors.Success {
 		tx.TransactionResult.Success = false
 		tx.TransactionResult.ErrorCode = errEl.Code()
 	} else {
 		tx.TransactionResult.Success = true
-		tx.TransactionResult.NewState = newState
 	}
 }
 
-func (l Ledger) GetTransactionsFromPool(blk block.Entity) (txList TransactionList, count uint32, txRoot []byte) {
+func (l *Ledger) GetTransactionsFromPool(blk block.Entity) (txList TransactionList, count uint32, txRoot []byte) {
 	l.poolLock.Lock()
 	defer l.poolLock.Unlock()
 
@@ -341,37 +447,81 @@ func (l Ledger) GetTransactionsFromPool(blk block.Entity) (txList TransactionLis
 		return
 	}
 
-	resultCache := txResultCache {
-		CachedBlockGasKey: &txResultCacheData{
-			gasLeft: constTransactionGasLimit().Uint64(),
-		},
+	resultCache := l.newTxResultCache()
 
-		CachedContractReturnData: &txResultCacheData{
-			Data: nil,
-		},
+	mt := merkleTree.Tree{}
 
-		CachedContractCreationAddress: &txResultCacheData{
-			Data: nil,
-		},
-	}
+	var expiredTx []Transaction
+	blockGas := resultCache[CachedBlockGasKey]
 
-	mt := merkleTree.Tree{}
+	//a transaction waits for the earlier ones of its sender, so the pool is walked again while any transaction was packed
+	pending := l.poolRecordByFee()
+	for packed := true; packed && len(pending) > 0; {
+		packed = false
+		var waiting []string
+
+		for _, txHashStr := range pending {
+			tx := l.txPool[txHashStr]
+			if tx.ExpiryHeight != 0 && blk.Header.Height > tx.ExpiryHeight {
+				expiredTx = append(expiredTx, *tx)
+				continue
+			}
+
+			nonce, nonceErr := l.getNonce(tx.From, resultCache)
+			if nonceErr != nil {
+				continue
+			}
+
+			//a nonce already used will never be valid again
+			if tx.Nonce < nonce {
+				expiredTx = append(expiredTx, *tx)
+				continue
+			}
+
+			if tx.Nonce > nonce {
+				waiting = append(waiting, txHashStr)
+				continue
+			}
+
+			//transactions can't fit in the gas left are kept in pool for later blocks
+			if tx.GasLimit > blockGas.gasLeft {
+				continue
+			}
+
+			//sender may get enough balance in later blocks, other unpayable transactions are dropped
+			if _, payErr := l.verifyTxPayable(*tx, resultCache); payErr != nil {
+				if isTxNeverPayable(payErr) {
+					expiredTx = append(expiredTx, *tx)
+				}
+				continue
+			}
 
-	for idx, txHashStr := range l.txPoolRecord {
-		tx := l.txPool[txHashStr]
+			mt.AddHash(tx.DataSeal.Hash)
 
-		mt.AddHash(tx.DataSeal.Hash)
+			resultCache[CachedContractReturnData].Data = nil
+			resultCache[CachedContractCreationAddress].address = nil
 
-		if preExec, exists := l.preActuators[tx.Type]; exists {
-			newState, _, err := preExec(*tx, resultCache, blk)
-			l.setTxNewState(err, newState, tx)
-			tx.SequenceNumber = uint32(idx)
+			newState, gasUsed, err := l.executeTransaction(*tx, resultCache, blk)
+			blockGas.gasLeft -= gasUsed
 
+			l.setTxNewState(err, newState, gasUsed, tx)
+			tx.SequenceNumber = uint32(len(txList.Transactions))
 			tx.TransactionResult.ReturnData = resultCache[CachedContractReturnData].Data
 			tx.TransactionResult.NewAddress = resultCache[CachedContractCreationAddress].address
+			l.setTxRevertReason(tx)
+
+			txList.Transactions = append(txList.Transactions, *tx)
+			packed = true
 		}
 
-		txList.Transactions = append(txList.Transactions, *tx)
+		pending = waiting
+	}
+
+	l.removeTransactionsFromPool(expiredTx)
+
+	count = uint32(len(txList.Transactions))
+	if count == 0 {
+		return
 	}
 
 	txRoot, _ = mt.Calculate()
@@ -399,6 +549,9 @@ func NewLedger(tools crypto.Tools, driver kvDatabase.IDriver) *Ledger {
 		genesisAssets: BaseAssets{},
 		CryptoTools:   tools,
 		Storage:       driver,
+		blockGasLimit: defaultBlockGasLimit,
+		minGasPrice:   big.NewInt(1),
+		upgradeAdmins: map[string] bool{},
 	}
 
 	l.storageForEVM.basedLedger = l
@@ -406,6 +559,8 @@ func NewLedger(tools crypto.Tools, driver kvDatabase.IDriver) *Ledger {
 		TxType.Transfer.String(): l.preTransfer,
 		TxType.CreateContract.String(): l.preContractCreation,
 		TxType.ContractCall.String(): l.preContractCall,
+		TxType.RegisterABI.String(): l.preRegisterABI,
+		TxType.UpgradeContract.String(): l.preUpgradeContract,
 	}
 
 	l.queryActuators = map[string]queryActuator{
@@ -413,6 +568,12 @@ func NewLedger(tools crypto.Tools, driver kvDatabase.IDriver) *Ledger {
 		QueryTypes.Balance.String(): l.queryBalance,
 		QueryTypes.Transaction.String(): l.queryTransaction,
 		QueryTypes.OffChainCall.String(): l.contractOffChainCall,
+		QueryTypes.Logs.String(): l.queryLogs,
+		QueryTypes.ABI.String(): l.queryABI,
+		QueryTypes.EncodeCall.String(): l.queryEncodeCall,
+		QueryTypes.CallTrace.String(): l.queryCallTrace,
+		QueryTypes.UpgradeHistory.String(): l.queryUpgradeHistory,
+		QueryTypes.Nonce.String(): l.queryNonce,
 	}
 
 	return l
@@ -18,6 +18,7 @@
 package smartAssetsLedger
 
 import (
+	"github.com/SealSC/SealABC/dataStructure/enum"
 	"github.com/SealSC/SealABC/metadata/block"
 	"github.com/SealSC/SealEVM/evmInt256"
 	"github.com/SealSC/SealEVM/opcodes"
@@ -32,8 +33,13 @@ func (l *Ledger) preContractCall(tx Transaction, cache txResultCache, blk block.
 		return nil, nil, Errors.InvalidContractCreationAddress
 	}
 
-	initGas := cache[CachedBlockGasKey].gasLeft
-	evm, _, err := l.newEVM(tx, nil, blk, evmInt256.New(int64(initGas)))
+	if app, exists := systemContracts[string(tx.To)]; exists {
+		return l.preSystemContractCall(tx, app, cache)
+	}
+
+	initGas := cache[CachedTxGasKey].gasLeft
+	store := l.newContractStorage(cache)
+	evm, _, err := l.newEVM(tx, nil, blk, evmInt256.New(int64(initGas)), store)
 	if err != nil {
 		return nil, cache, err
 	}
@@ -47,10 +53,27 @@ func (l *Ledger) preContractCall(tx Transaction, cache txResultCache, blk block.
 			execErr = Errors.ContractExecuteRevert
 		}
 	}
-	newState := l.newStateFromEVMResult(ret, cache)
+	if store.usedCreate2 && execErr == Errors.Success {
+		execErr = Errors.ContractExecuteFailed.NewErrorWithNewMessage("CREATE2 is not supported")
+	}
+	newState := l.newStateFromEVMResult(ret, cache, store)
 
 	gasCost := initGas - ret.GasLeft
-	cache[CachedBlockGasKey].gasLeft -= gasCost
+	cache[CachedTxGasKey].gasLeft -= gasCost
+
+	gasErr := chargeSystemCallGas(cache, store.systemCallGas)
+	if gasErr != nil && execErr == Errors.Success {
+		execErr = gasErr.(enum.ErrorElement)
+	}
+
+	if execErr == Errors.Success {
+		callState, callErr := l.systemCallsOfLogs(tx, newState, cache)
+		if callErr != nil {
+			execErr = callErr.(enum.ErrorElement)
+		} else {
+			newState = append(newState, callState...)
+		}
+	}
 	cache[CachedContractReturnData].Data = ret.ResultData
 	return newState, cache, execErr
 }
@@ -32,22 +32,32 @@ func (l *Ledger) preContractCreation(tx Transaction, cache txResultCache, blk bl
 		return nil, nil, Errors.InvalidContractCreationAddress
 	}
 
-	initGas := cache[CachedBlockGasKey].gasLeft
-	evm, contract, _ := l.newEVM(tx, nil, blk, evmInt256.New(int64(initGas)))
+	initGas := cache[CachedTxGasKey].gasLeft
+	store := l.newContractStorage(cache)
+	evm, contract, _ := l.newEVM(tx, nil, blk, evmInt256.New(int64(initGas)), store)
 
 	ret, err := evm.ExecuteContract(true)
-	newState := l.newStateFromEVMResult(ret, cache)
+	newState := l.newStateFromEVMResult(ret, cache, store)
 
 	gasCost := initGas - ret.GasLeft
-	cache[CachedBlockGasKey].gasLeft -= gasCost
+	cache[CachedTxGasKey].gasLeft -= gasCost
+
+	if err == nil && store.usedCreate2 {
+		return nil, cache, Errors.ContractCreationFailed.NewErrorWithNewMessage("CREATE2 is not supported")
+	}
 
 	if err == nil {
+		err = chargeSystemCallGas(cache, store.systemCallGas)
+		if err != nil {
+			return nil, cache, err
+		}
+
 		if ret.ExitOpCode == opcodes.REVERT {
-			err = Errors.ContractExecuteRevert
-			return nil, nil, err
+			cache[CachedContractReturnData].Data = ret.ResultData
+			return nil, cache, Errors.ContractExecuteRevert
 		}
 
-		contractAddr := contract.Namespace.Bytes()
+		contractAddr := evmAddress(contract.Namespace)
 		newState = append(newState,
 			StateData {
 				Key:    BuildKey(StoragePrefixes.ContractCode, contractAddr),
@@ -57,12 +67,22 @@ func (l *Ledger) preContractCreation(tx Transaction, cache txResultCache, blk bl
 				Key:    BuildKey(StoragePrefixes.ContractHash, contractAddr),
 				NewVal: contract.Hash.Bytes(),
 			},
+			StateData {
+				Key:    BuildKey(StoragePrefixes.ContractCreator, contractAddr),
+				NewVal: tx.From,
+			},
 		)
 	} else {
 		return nil, nil, Errors.ContractCreationFailed.NewErrorWithNewMessage(err.Error())
 	}
 
-	cache[CachedContractCreationAddress].address = contract.Namespace.Bytes()
+	callState, err := l.systemCallsOfLogs(tx, newState, cache)
+	if err != nil {
+		return nil, cache, err
+	}
+	newState = append(newState, callState...)
+
+	cache[CachedContractCreationAddress].address = evmAddress(contract.Namespace)
 	cache[CachedContractReturnData].Data = ret.ResultData
 	return newState, cache, Errors.Success
 }
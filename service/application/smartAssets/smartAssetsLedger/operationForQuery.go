@@ -18,10 +18,35 @@
 package smartAssetsLedger
 
 import (
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsABI"
 	"encoding/hex"
 	"encoding/json"
+	"strconv"
+	"strings"
 )
 
+//queryLedger returns the ledger on the state of the optional height parameter and the block of the state
+func (l *Ledger) queryLedger(req QueryRequest) (*Ledger, block.Entity, error) {
+	heightStr := req.Parameter[QueryParameterFields.Height.String()]
+	if heightStr == "" {
+		return l, *l.chain.GetLastBlock(), nil
+	}
+
+	height, err := strconv.ParseUint(heightStr, 10, 64)
+	if err != nil {
+		return nil, block.Entity{}, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+	}
+
+	hl, blk, err := l.ledgerAtHeight(height)
+	if err != nil {
+		return nil, blk, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+	}
+
+	return hl, blk, nil
+}
+
 func (l *Ledger) queryBaseAssets(_ QueryRequest) (interface{}, error) {
 	return l.genesisAssets, nil
 }
@@ -37,7 +62,12 @@ func (l *Ledger) queryBalance(req QueryRequest) (interface{}, error) {
 		return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
 	}
 
-	balance, err := l.BalanceOf(addr)
+	hl, _, err := l.queryLedger(req)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := hl.BalanceOf(addr)
 	if err != nil {
 		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
 	}
@@ -61,38 +91,198 @@ func (l *Ledger) queryTransaction(req QueryRequest) (interface{}, error) {
 	return tx, err
 }
 
+type OffChainCallResult struct {
+	ReturnData []byte
+	GasUsed    uint64
+	Decoded    *DecodedContractCall
+
+	RevertReason *smartAssetsABI.RevertReason
+}
+
+func (l *Ledger) queryCallTrace(req QueryRequest) (interface{}, error) {
+	txHash, err := hex.DecodeString(req.Parameter[QueryParameterFields.TxHash.String()])
+	if err != nil || len(txHash) == 0 {
+		return nil, Errors.InvalidParameter
+	}
+
+	trace, err := l.TraceTransactionCalls(txHash)
+	if errEl, isEl := err.(enum.ErrorElement); isEl && errEl.Code() == Errors.StateNotAvailable.Code() {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, Errors.InvalidQuery.NewErrorWithNewMessage(err.Error())
+	}
+
+	return trace, nil
+}
+
+//contractOffChainCall parameters: a json transaction, its data can be left empty and encoded from a method name
+//and a json array of arguments by the abi registered for the contract, and an optional height of the state to call on
 func (l *Ledger) contractOffChainCall(req QueryRequest) (interface{}, error) {
 	txJson := req.Parameter[QueryParameterFields.Data.String()]
 	if txJson == "" {
 		return nil, Errors.InvalidParameter
 	}
 
-	blk := l.chain.GetLastBlock()
+	hl, blk, err := l.queryLedger(req)
+	if err != nil {
+		return nil, err
+	}
+
 	tx := Transaction{}
-	err := json.Unmarshal([]byte(txJson), &tx)
+	err = json.Unmarshal([]byte(txJson), &tx)
 	if err != nil {
 		return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
 	}
 
-	resultCache := txResultCache {
-		CachedBlockGasKey: &txResultCacheData{
-			gasLeft: constTransactionGasLimit().Uint64(),
-		},
+	if method := req.Parameter[QueryParameterFields.Method.String()]; method != "" && len(tx.Data) == 0 {
+		tx.Data, err = hl.EncodeCall(tx.To, method, req.Parameter[QueryParameterFields.Args.String()])
+		if err != nil {
+			return nil, err
+		}
+	}
 
-		CachedContractReturnData: &txResultCacheData{
-			Data: nil,
-		},
+	if tx.GasLimit < txIntrinsicGas {
+		tx.GasLimit = l.blockGasLimit
+	}
 
-		CachedContractCreationAddress: &txResultCacheData{
-			Data: nil,
-		},
+	resultCache := hl.newTxResultCache()
+	resultCache[CachedTxGasKey].gasLeft = tx.GasLimit - txIntrinsicGas
+
+	newState, cache, err := hl.preContractCall(tx, resultCache, blk)
+	if cache == nil {
+		return nil, err
 	}
 
-	_, cache, err := l.preContractCall(tx, resultCache, *blk)
+	tx.ReturnData = cache[CachedContractReturnData].Data
+	tx.NewState = newState
+	result := OffChainCallResult{
+		ReturnData: tx.ReturnData,
+		GasUsed:    tx.GasLimit - cache[CachedTxGasKey].gasLeft,
+	}
+
+	result.Decoded, _ = hl.decodeContractCall(tx)
 
 	if err == Errors.Success {
-		return cache, nil
+		return result, nil
 	} else {
-		return cache, err
+		err, result.RevertReason = hl.revertError(err, tx.To, tx.ReturnData)
+		return result, err
+	}
+}
+
+func (l *Ledger) queryABI(req QueryRequest) (interface{}, error) {
+	addr, err := hex.DecodeString(req.Parameter[QueryParameterFields.Address.String()])
+	if err != nil || len(addr) == 0 {
+		return nil, Errors.InvalidParameter
 	}
+
+	abiJson, err := l.GetABI(addr)
+	if err != nil {
+		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	if abiJson == nil {
+		return nil, nil
+	}
+
+	return json.RawMessage(abiJson), nil
+}
+
+//queryEncodeCall parameters: the hex contract address, the method name or signature and a json array of arguments
+func (l *Ledger) queryEncodeCall(req QueryRequest) (interface{}, error) {
+	addr, err := hex.DecodeString(req.Parameter[QueryParameterFields.Address.String()])
+	if err != nil || len(addr) == 0 {
+		return nil, Errors.InvalidParameter
+	}
+
+	method := req.Parameter[QueryParameterFields.Method.String()]
+	if method == "" {
+		return nil, Errors.InvalidParameter
+	}
+
+	data, err := l.EncodeCall(addr, method, req.Parameter[QueryParameterFields.Args.String()])
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+func (l *Ledger) queryUpgradeHistory(req QueryRequest) (interface{}, error) {
+	addr, err := hex.DecodeString(req.Parameter[QueryParameterFields.Address.String()])
+	if err != nil || len(addr) == 0 {
+		return nil, Errors.InvalidParameter
+	}
+
+	history, err := l.GetUpgradeHistory(addr)
+	if err != nil {
+		return nil, Errors.DBError.NewErrorWithNewMessage(err.Error())
+	}
+
+	return history, nil
+}
+
+func decodeHexList(list string) (ret [][]byte, err error) {
+	for _, hexStr := range strings.Split(list, ",") {
+		hexStr = strings.TrimSpace(hexStr)
+		if hexStr == "" {
+			continue
+		}
+
+		b, decErr := hex.DecodeString(hexStr)
+		if decErr != nil {
+			return nil, decErr
+		}
+		ret = append(ret, b)
+	}
+
+	return
+}
+
+//queryLogs parameters: comma separated hex addresses, json array of hex topic lists by position and a height range
+func (l *Ledger) queryLogs(req QueryRequest) (interface{}, error) {
+	filter := LogFilter{}
+
+	addresses, err := decodeHexList(req.Parameter[QueryParameterFields.Address.String()])
+	if err != nil {
+		return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+	}
+	filter.Addresses = addresses
+
+	if topicsJson := req.Parameter[QueryParameterFields.Topics.String()]; topicsJson != "" {
+		var topics [][]string
+		err = json.Unmarshal([]byte(topicsJson), &topics)
+		if err != nil {
+			return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+		}
+
+		for _, t := range topics {
+			topicList, decErr := decodeHexList(strings.Join(t, ","))
+			if decErr != nil {
+				return nil, Errors.InvalidParameter.NewErrorWithNewMessage(decErr.Error())
+			}
+			filter.Topics = append(filter.Topics, topicList)
+		}
+	}
+
+	for field, height := range map[string] *uint64 {
+		QueryParameterFields.FromHeight.String(): &filter.FromHeight,
+		QueryParameterFields.ToHeight.String():   &filter.ToHeight,
+	} {
+		if val := req.Parameter[field]; val != "" {
+			*height, err = strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return nil, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
+			}
+		}
+	}
+
+	logs, err := l.QueryLogs(filter)
+	if err != nil {
+		return nil, Errors.InvalidQuery.NewErrorWithNewMessage(err.Error())
+	}
+
+	return logs, nil
 }
@@ -45,7 +45,7 @@ func (l *Ledger) preTransfer(tx Transaction, cache txResultCache, _ block.Entity
 		return nil, cache, Errors.InvalidTransactionType
 	}
 
-	_, err := tx.DataSeal.Verify(tx.getData(), l.CryptoTools.HashCalculator)
+	_, err := tx.verifySeal(l.CryptoTools.HashCalculator, l.EthChainID)
 	if err != nil {
 		return nil, cache, Errors.InvalidParameter.NewErrorWithNewMessage(err.Error())
 	}
@@ -24,12 +24,29 @@ var QueryTypes struct {
 	Balance      enum.Element
 	Transaction  enum.Element
 	OffChainCall enum.Element
+	Logs         enum.Element
+	ABI          enum.Element
+	EncodeCall   enum.Element
+	CallTrace    enum.Element
+
+	UpgradeHistory enum.Element
+
+	Nonce enum.Element
 }
 
 var QueryParameterFields struct{
 	Address enum.Element
 	TxHash  enum.Element
 	Data    enum.Element
+
+	Topics     enum.Element
+	FromHeight enum.Element
+	ToHeight   enum.Element
+
+	Method enum.Element
+	Args   enum.Element
+
+	Height enum.Element
 }
 
 type QueryRequest struct {
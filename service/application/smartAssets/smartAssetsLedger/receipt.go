@@ -0,0 +1,113 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"reflect"
+	"strings"
+)
+
+const contractLogTopicLen = 32
+
+func errorNameForCode(code int64) string {
+	errList := reflect.ValueOf(Errors)
+	for i := 0; i < errList.NumField(); i++ {
+		if el, ok := errList.Field(i).Interface().(enum.ErrorElement); ok && el.Code() == code {
+			return el.Name()
+		}
+	}
+
+	return ""
+}
+
+func decodeContractLog(logBytes []byte) (topics [][]byte, data []byte, valid bool) {
+	if len(logBytes) == 0 {
+		return
+	}
+
+	topicsCnt := int(logBytes[0])
+	topicsEnd := 1 + topicsCnt*contractLogTopicLen
+	if len(logBytes) < topicsEnd {
+		return
+	}
+
+	for i := 0; i < topicsCnt; i++ {
+		start := 1 + i*contractLogTopicLen
+		topics = append(topics, logBytes[start:start+contractLogTopicLen])
+	}
+
+	return topics, logBytes[topicsEnd:], true
+}
+
+//TransactionLogs returns the contract logs emitted by a transaction, in the order of its new state
+func TransactionLogs(tx Transaction) (logs []chainStructure.ReceiptLog) {
+	logPrefix := BuildKey(StoragePrefixes.ContractLog, nil)
+	txHashSuffix := "-" + hex.EncodeToString(tx.DataSeal.Hash)
+
+	for _, s := range tx.NewState {
+		if !bytes.HasPrefix(s.Key, logPrefix) {
+			continue
+		}
+
+		logKey := string(s.Key[len(logPrefix):])
+		if !strings.HasSuffix(logKey, txHashSuffix) {
+			continue
+		}
+
+		topics, data, valid := decodeContractLog(s.NewVal)
+		if !valid {
+			continue
+		}
+
+		logs = append(logs, chainStructure.ReceiptLog{
+			Address: []byte(strings.TrimSuffix(logKey, txHashSuffix)),
+			Topics:  topics,
+			Data:    data,
+		})
+	}
+
+	return
+}
+
+func (l Ledger) BuildReceipts(txList TransactionList) (receipts []chainStructure.Receipt) {
+	for _, tx := range txList.Transactions {
+		receipt := chainStructure.Receipt{
+			RequestHash: tx.DataSeal.Hash,
+			Action:      tx.Type,
+			Success:     tx.Success,
+			ErrorCode:   tx.ErrorCode,
+			ReturnData:  tx.ReturnData,
+			GasUsed:     tx.GasUsed,
+			Logs:        TransactionLogs(tx),
+		}
+
+		if !tx.Success {
+			receipt.ErrorMessage = errorNameForCode(tx.ErrorCode)
+			if tx.RevertReason != "" {
+				receipt.ErrorMessage += ": " + tx.RevertReason
+			}
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return
+}
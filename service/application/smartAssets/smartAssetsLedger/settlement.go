@@ -0,0 +1,182 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+//a settlement is a signed transaction executed for another application by an internal call, it is not packed in a block
+//of this application, so its new state is calculated when it is called and kept under the undo key of the call,
+//the original values in the state are restored when the caller rolls it back
+
+//settlementBlock is the next block of the chain with the time of the last block, to keep the execution deterministic
+func (l *Ledger) settlementBlock() (blk block.Entity) {
+	if l.chain == nil {
+		return
+	}
+
+	blk.Header.Height = l.chain.CurrentHeight() + 1
+	if last := l.chain.GetLastBlock(); last != nil {
+		blk.Header.Timestamp = last.Header.Timestamp
+	}
+
+	return
+}
+
+//transfers of the base assets and contract calls, like a transfer of a token, can be settled
+func (l *Ledger) settlementState(tx Transaction) (newState []StateData, gasUsed uint64, err error) {
+	if tx.Type != TxType.Transfer.String() && tx.Type != TxType.ContractCall.String() {
+		return nil, 0, errors.New("only transfers and contract calls can be settled")
+	}
+
+	_, err = tx.verify(l.CryptoTools.HashCalculator, l.EthChainID)
+	if err != nil {
+		return
+	}
+
+	_, exists, err := l.getTxFromStorage(tx.DataSeal.Hash)
+	if err != nil {
+		return
+	}
+
+	if exists {
+		return nil, 0, errors.New("duplicate history transaction")
+	}
+
+	cache := l.newTxResultCache()
+	err = l.checkNonce(tx, cache)
+	if err != nil {
+		return
+	}
+
+	newState, gasUsed, err = l.executeTransaction(tx, cache, l.settlementBlock())
+	if err != nil && err != Errors.Success {
+		return nil, 0, err
+	}
+
+	//the calls of a settlement to other applications would not be executed, so they are not allowed
+	callPrefix := BuildKey(StoragePrefixes.SystemCall, nil)
+	for _, s := range newState {
+		if bytes.HasPrefix(s.Key, callPrefix) {
+			return nil, 0, errors.New("system calls can't be made by a settlement")
+		}
+	}
+
+	return newState, gasUsed, nil
+}
+
+func (l *Ledger) VerifySettlement(tx Transaction) (err error) {
+	l.operateLock.Lock()
+	defer l.operateLock.Unlock()
+
+	_, _, err = l.settlementState(tx)
+	return
+}
+
+func (l *Ledger) ExecuteSettlement(tx Transaction, undoKey []byte) (err error) {
+	l.operateLock.Lock()
+	defer l.operateLock.Unlock()
+
+	newState, gasUsed, err := l.settlementState(tx)
+	if err != nil {
+		return
+	}
+
+	tx.TransactionResult.NewState = newState
+	tx.TransactionResult.GasUsed = gasUsed
+	tx.TransactionResult.Success = true
+
+	txData, _ := structSerializer.ToMFBytes(tx)
+	undoData, _ := json.Marshal(tx)
+	kvList := []kvDatabase.KVItem{
+		{
+			Key:    BuildKey(StoragePrefixes.Transaction, tx.DataSeal.Hash),
+			Data:   txData,
+			Exists: true,
+		},
+		{
+			Key:    BuildKey(StoragePrefixes.Settlement, undoKey),
+			Data:   undoData,
+			Exists: true,
+		},
+	}
+
+	for _, s := range newState {
+		kvList = append(kvList, kvDatabase.KVItem {
+			Key:    s.Key,
+			Data:   s.NewVal,
+			Exists: true,
+		})
+	}
+
+	return l.Storage.BatchPut(kvList)
+}
+
+func (l *Ledger) RollbackSettlement(undoKey []byte) (err error) {
+	l.operateLock.Lock()
+	defer l.operateLock.Unlock()
+
+	undoKeyInDB := BuildKey(StoragePrefixes.Settlement, undoKey)
+	kv, err := l.Storage.Get(undoKeyInDB)
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		return errors.New("no such settlement")
+	}
+
+	tx := Transaction{}
+	err = json.Unmarshal(kv.Data, &tx)
+	if err != nil {
+		return
+	}
+
+	restored := map[string] bool{}
+	var restoreList []kvDatabase.KVItem
+	deleteList := [][]byte{undoKeyInDB, BuildKey(StoragePrefixes.Transaction, tx.DataSeal.Hash)}
+	for _, s := range tx.TransactionResult.NewState {
+		if restored[string(s.Key)] {
+			continue
+		}
+		restored[string(s.Key)] = true
+
+		if len(s.OrgVal) == 0 {
+			deleteList = append(deleteList, s.Key)
+			continue
+		}
+
+		restoreList = append(restoreList, kvDatabase.KVItem {
+			Key:    s.Key,
+			Data:   s.OrgVal,
+			Exists: true,
+		})
+	}
+
+	err = l.Storage.BatchDelete(deleteList)
+	if err != nil {
+		return
+	}
+
+	return l.Storage.BatchPut(restoreList)
+}
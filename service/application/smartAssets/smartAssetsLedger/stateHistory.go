@@ -0,0 +1,227 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+const (
+	historyHeightLen = 8
+
+	//first byte of a history value, tells if the key existed before the block
+	historyAbsent  = byte(0)
+	historyPresent = byte(1)
+)
+
+//historyKey is the key of the value a state key had before the block at height was executed,
+//the height is big endian so the versions of a key are ordered in storage.
+func historyKey(key []byte, height uint64) []byte {
+	heightBytes := make([]byte, historyHeightLen)
+	binary.BigEndian.PutUint64(heightBytes, height)
+	return BuildKey(StoragePrefixes.StateHistory, key, heightBytes)
+}
+
+func historyKeys(txList TransactionList, height uint64) (keys [][]byte, orgValues [][]byte) {
+	logPrefix := BuildKey(StoragePrefixes.ContractLog, nil)
+	callPrefix := BuildKey(StoragePrefixes.SystemCall, nil)
+	recorded := map[string] bool{}
+
+	for _, tx := range txList.Transactions {
+		for _, s := range tx.TransactionResult.NewState {
+			if recorded[string(s.Key)] || bytes.HasPrefix(s.Key, logPrefix) || bytes.HasPrefix(s.Key, callPrefix) {
+				continue
+			}
+			recorded[string(s.Key)] = true
+
+			keys = append(keys, historyKey(s.Key, height))
+			orgValues = append(orgValues, s.OrgVal)
+		}
+	}
+
+	return
+}
+
+//historyItems keeps the value of every key changed by the block before its first change,
+//versions already recorded by an earlier request of the same block are kept.
+func (l *Ledger) historyItems(txList TransactionList, height uint64) (kvList []kvDatabase.KVItem) {
+	startKey := BuildKey(StoragePrefixes.HistoryStartBlock, nil)
+	if started, _ := l.Storage.Check(startKey); !started {
+		heightBytes := make([]byte, historyHeightLen)
+		binary.BigEndian.PutUint64(heightBytes, height)
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:    startKey,
+			Data:   heightBytes,
+			Exists: true,
+		})
+	}
+
+	keys, orgValues := historyKeys(txList, height)
+	for i, k := range keys {
+		if exists, _ := l.Storage.Check(k); exists {
+			continue
+		}
+
+		val := []byte{historyAbsent}
+		if len(orgValues[i]) != 0 {
+			val = append([]byte{historyPresent}, orgValues[i]...)
+		}
+
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:    k,
+			Data:   val,
+			Exists: true,
+		})
+	}
+
+	return
+}
+
+//versionedStorage reads the state after the block at height
+type versionedStorage struct {
+	kvDatabase.IDriver
+	height uint64
+}
+
+//laterVersion finds the first version of the key recorded after the height, it searches in growing height ranges
+//around the height first, so frequently changed keys don't need all their versions loaded.
+func (v *versionedStorage) laterVersion(k []byte) (val []byte, found bool) {
+	prefix := BuildKey(StoragePrefixes.StateHistory, k)
+	versionKeyLen := len(prefix) + historyHeightLen
+
+	heightBytes := make([]byte, historyHeightLen)
+	binary.BigEndian.PutUint64(heightBytes, v.height)
+
+	for rangeLen := historyHeightLen - 1; rangeLen >= 0; rangeLen-- {
+		rangePrefix := append(append([]byte{}, prefix...), heightBytes[:rangeLen]...)
+		for _, kv := range v.IDriver.Traversal(rangePrefix) {
+			if len(kv.Key) != versionKeyLen {
+				continue
+			}
+
+			if binary.BigEndian.Uint64(kv.Key[len(prefix):]) > v.height {
+				return kv.Data, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (v *versionedStorage) Get(k []byte) (kv kvDatabase.KVItem, err error) {
+	val, found := v.laterVersion(k)
+	if !found {
+		return v.IDriver.Get(k)
+	}
+
+	kv.Key = k
+	if len(val) != 0 && val[0] == historyPresent {
+		kv.Data = val[1:]
+		kv.Exists = true
+	}
+
+	return
+}
+
+func (v *versionedStorage) Check(k []byte) (exists bool, err error) {
+	kv, err := v.Get(k)
+	return kv.Exists, err
+}
+
+//readOnlyCopy is a ledger with the same settings that reads its state from the driver, it is used to
+//execute queries and replays on a past state.
+func (l *Ledger) readOnlyCopy(driver kvDatabase.IDriver) *Ledger {
+	ro := NewLedger(l.CryptoTools, driver)
+	ro.chain = l.chain
+	ro.ApplicationName = l.ApplicationName
+	ro.EthChainID = l.EthChainID
+	ro.blockGasLimit = l.blockGasLimit
+	ro.minGasPrice = l.minGasPrice
+	ro.feeCollector = l.feeCollector
+	ro.upgradeAdmins = l.upgradeAdmins
+	ro.upgradeThreshold = l.upgradeThreshold
+	ro.genesisAssets = l.genesisAssets
+
+	return ro
+}
+
+//ledgerAtHeight returns a read only ledger on the state after the block at height and the block
+func (l *Ledger) ledgerAtHeight(height uint64) (*Ledger, block.Entity, error) {
+	current := l.chain.CurrentHeight()
+	if height > current {
+		return nil, block.Entity{}, fmt.Errorf("height %d is above the current height %d", height, current)
+	}
+
+	blk, err := l.chain.GetBlockByHeight(height)
+	if err != nil {
+		return nil, blk, err
+	}
+
+	if height == current {
+		return l, blk, nil
+	}
+
+	//blocks executed before the history was kept have no versions to read
+	start, err := l.Storage.Get(BuildKey(StoragePrefixes.HistoryStartBlock, nil))
+	if err != nil {
+		return nil, blk, err
+	}
+
+	if !start.Exists || height + 1 < binary.BigEndian.Uint64(start.Data) {
+		return nil, blk, fmt.Errorf("no state history at height %d", height)
+	}
+
+	return l.readOnlyCopy(&versionedStorage{
+		IDriver: l.Storage,
+		height:  height,
+	}), blk, nil
+}
+
+//BalanceAt returns the balance of the address after the block at height
+func (l *Ledger) BalanceAt(address []byte, height uint64) (*big.Int, error) {
+	hl, _, err := l.ledgerAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return hl.BalanceOf(address)
+}
+
+//NonceAt returns the nonce of the address after the block at height
+func (l *Ledger) NonceAt(address []byte, height uint64) (uint64, error) {
+	hl, _, err := l.ledgerAtHeight(height)
+	if err != nil {
+		return 0, err
+	}
+
+	return hl.NonceOf(address)
+}
+
+//CodeAt returns the code at the address after the block at height
+func (l *Ledger) CodeAt(address []byte, height uint64) ([]byte, error) {
+	hl, _, err := l.ledgerAtHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return hl.GetCode(address)
+}
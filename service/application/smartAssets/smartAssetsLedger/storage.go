@@ -20,16 +20,12 @@ package smartAssetsLedger
 import (
 	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
 	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealEVM/common"
 	"github.com/SealSC/SealEVM/environment"
 	"github.com/SealSC/SealEVM/evmInt256"
 	"errors"
 )
 
-const ContractAddressLen = 24
-func ContractAddressPrefix() []byte {
-	return []byte("SC:")
-}
-
 var StoragePrefixes struct {
 	SystemAssets enum.Element
 	Assets       enum.Element
@@ -41,6 +37,25 @@ var StoragePrefixes struct {
 	ContractCode      enum.Element
 	ContractHash      enum.Element
 	ContractDestructs enum.Element
+
+	LogIndex        enum.Element
+	LogAddressIndex enum.Element
+	LogTopicIndex   enum.Element
+
+	ContractCreator enum.Element
+	ContractABI     enum.Element
+
+	StateHistory      enum.Element
+	HistoryStartBlock enum.Element
+
+	UpgradeApproval  enum.Element
+	ContractUpgrades enum.Element
+
+	SystemCall enum.Element
+
+	Nonce enum.Element
+
+	Settlement enum.Element
 }
 
 func BuildKey(el enum.Element, baseKey []byte, extra ...[]byte)  []byte {
@@ -75,12 +90,42 @@ func (l *Ledger) getTxFromStorage(hash []byte) (tx *Transaction, exists bool, er
 	return
 }
 
+func (l *Ledger) GetTransaction(hash []byte) (tx *Transaction, exists bool, err error) {
+	return l.getTxFromStorage(hash)
+}
+
 type contractStorage struct {
 	basedLedger *Ledger
+
+	//only set when replaying a transaction for its trace
+	tracer *evmTracer
+
+	//result cache of the execution and the original nonces of the contracts created contracts in it,
+	//only set in the storage built for one execution by newContractStorage
+	resultCache  txResultCache
+	nonceChanges map[string] []byte
+
+	//calls of contracts to the system contracts in the execution and the gas they cost
+	systemCalls   map[string] *systemCallSession
+	systemCallGas uint64
+
+	//set when a contract in the execution used CREATE2
+	usedCreate2 bool
+}
+
+//newContractStorage builds the evm storage of one execution, so executions for the pool and for the block don't
+//share the result cache and the nonce changes
+func (l *Ledger) newContractStorage(cache txResultCache) *contractStorage {
+	return &contractStorage{
+		basedLedger:  l,
+		tracer:       l.storageForEVM.tracer,
+		resultCache:  cache,
+		nonceChanges: map[string] []byte{},
+	}
 }
 
 func (c *contractStorage) GetBalance(address *evmInt256.Int) (*evmInt256.Int, error) {
-	balance, err := c.basedLedger.BalanceOf(address.Bytes())
+	balance, err := c.basedLedger.BalanceOf(evmAddress(address))
 
 	var ret *evmInt256.Int
 	if err == nil {
@@ -90,7 +135,7 @@ func (c *contractStorage) GetBalance(address *evmInt256.Int) (*evmInt256.Int, er
 }
 
 func (c *contractStorage) CanTransfer(from, to, val *evmInt256.Int) bool {
-	balance, err := c.basedLedger.BalanceOf(from.Bytes())
+	balance, err := c.basedLedger.BalanceOf(evmAddress(from))
 	if err != nil {
 		return false
 	}
@@ -99,7 +144,11 @@ func (c *contractStorage) CanTransfer(from, to, val *evmInt256.Int) bool {
 }
 
 func (c *contractStorage) GetCode(address *evmInt256.Int) ([]byte, error) {
-	key := BuildKey(StoragePrefixes.ContractCode, address.Bytes())
+	if isSystemContract(evmAddress(address)) {
+		return systemContractCode, nil
+	}
+
+	key := BuildKey(StoragePrefixes.ContractCode, evmAddress(address))
 
 	codeKV, err := c.basedLedger.Storage.Get(key)
 	if err != nil {
@@ -110,11 +159,19 @@ func (c *contractStorage) GetCode(address *evmInt256.Int) ([]byte, error) {
 		return nil, errors.New("no such contract")
 	}
 
+	if c.tracer != nil {
+		c.tracer.codeLoaded(evmAddress(address), codeKV.Data)
+	}
+
 	return codeKV.Data, nil
 }
 
 func (c *contractStorage) GetCodeSize(address *evmInt256.Int) (*evmInt256.Int, error) {
-	key := BuildKey(StoragePrefixes.ContractCode, address.Bytes())
+	if isSystemContract(evmAddress(address)) {
+		return evmInt256.New(int64(len(systemContractCode))), nil
+	}
+
+	key := BuildKey(StoragePrefixes.ContractCode, evmAddress(address))
 
 	codeKV, err := c.basedLedger.Storage.Get(key)
 	if err != nil {
@@ -128,7 +185,13 @@ func (c *contractStorage) GetCodeSize(address *evmInt256.Int) (*evmInt256.Int, e
 }
 
 func (c *contractStorage) GetCodeHash(address *evmInt256.Int) (*evmInt256.Int, error) {
-	key := BuildKey(StoragePrefixes.ContractHash, address.Bytes())
+	if isSystemContract(evmAddress(address)) {
+		ret := evmInt256.New(0)
+		ret.SetBytes(systemContractCodeHash)
+		return ret, nil
+	}
+
+	key := BuildKey(StoragePrefixes.ContractHash, evmAddress(address))
 	hashKV, err := c.basedLedger.Storage.Get(key)
 	if err != nil {
 		return nil, err
@@ -153,41 +216,45 @@ func (c *contractStorage) GetBlockHash(block *evmInt256.Int) (*evmInt256.Int, er
 	return hash, nil
 }
 
-func (c *contractStorage) CreateAddress(caller *evmInt256.Int, tx environment.Transaction) *evmInt256.Int {
-	//in seal abc smart assets application, we always create fixed contract address.
-	return c.CreateFixedAddress(caller, nil, tx)
-}
-
-func (c *contractStorage) CreateFixedAddress(caller *evmInt256.Int, salt *evmInt256.Int, tx environment.Transaction) *evmInt256.Int {
-	addrPrefix := ContractAddressPrefix()
-
-	hashCalc := c.basedLedger.CryptoTools.HashCalculator
-
-	baseBytes := caller.Bytes()
-	baseBytes = append(baseBytes, tx.TxHash...)
-
-	if salt != nil {
-		baseBytes = append(baseBytes, salt.Bytes()...)
+//CreateAddress returns the address of a contract created by a contract with CREATE, the nonce of the creator
+//is increased in the result cache. contracts start with nonce 1 like ethereum, so an unset nonce is taken as 1.
+func (c *contractStorage) CreateAddress(caller *evmInt256.Int, _ environment.Transaction) *evmInt256.Int {
+	creator := evmAddress(caller)
+	key := nonceCacheKey(creator)
+
+	nonce, _ := c.basedLedger.getNonce(creator, c.resultCache)
+	if _, recorded := c.nonceChanges[key]; !recorded {
+		var orgVal []byte
+		if nonce != 0 {
+			orgVal = nonceBytes(nonce)
+		}
+		c.nonceChanges[key] = orgVal
 	}
 
-	addrHashBytes := hashCalc.Sum(baseBytes)
-	orgHashLen := len(addrHashBytes)
-	if orgHashLen > ContractAddressLen {
-		addrHashBytes = addrHashBytes[orgHashLen-ContractAddressLen:]
-	} else if orgHashLen < ContractAddressLen {
-		paddingHashBytes := make([]byte, ContractAddressLen, ContractAddressLen)
-		copy(paddingHashBytes, addrHashBytes)
-		addrHashBytes = paddingHashBytes
+	if nonce == 0 {
+		nonce = 1
 	}
-
-	addrBytes := append(addrPrefix, addrHashBytes...)
+	c.resultCache[key].val.SetUint64(nonce + 1)
 
 	ret := evmInt256.New(0)
-	ret.SetBytes(addrBytes)
+	ret.SetBytes(ContractAddress(creator, nonce))
 	return ret
 }
 
+//CreateFixedAddress is called for CREATE2, which is not supported: the address is derived from the init code hash,
+//and SealEVM doesn't pass the init code to the store. the use is recorded and fails the transaction after the
+//execution, the zero address returned here never reaches the state.
+func (c *contractStorage) CreateFixedAddress(_ *evmInt256.Int, _ *evmInt256.Int, _ environment.Transaction) *evmInt256.Int {
+	c.usedCreate2 = true
+	return evmInt256.New(0)
+}
+
+//Load reads the storage of a contract, reads of the system contracts are served by systemCallLoad
 func (c *contractStorage) Load(n string, k string) (*evmInt256.Int, error) {
+	if app, exists := systemContracts[string(evmAddress(common.BytesDataToEVMIntHash([]byte(n))))]; exists {
+		return c.systemCallLoad(app, k)
+	}
+
 	key := BuildKey(StoragePrefixes.ContractData, []byte(n), []byte(k))
 	data, err := c.basedLedger.Storage.Get(key)
 
@@ -200,5 +267,9 @@ func (c *contractStorage) Load(n string, k string) (*evmInt256.Int, error) {
 		ret.SetBytes(data.Data)
 	}
 
+	if c.tracer != nil {
+		c.tracer.storageRead(n, k, data.Data)
+	}
+
 	return ret, nil
 }
@@ -0,0 +1,368 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsABI"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"github.com/SealSC/SealEVM/evmInt256"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+//SealEVM has no hook for calls to precompiled addresses, so every system contract has the code built here,
+//which passes its call data to the store by storage reads of its own address and reads the result back the same way.
+//the store runs the call when the code reads the run key, so the caller gets the result in the same evm call.
+//a storage key read by the code is laid out as
+//    1 byte kind | 2 bytes index | 13 bytes call id | 16 bytes value
+//the call id is the head of the keccak256 hash of the call data.
+//a key of kind input carries the 16 bytes of the call data at the index in its value and reads 0,
+//a key of kind run carries the size of the call data and reads the status in its top byte and the result size,
+//a key of kind result reads the 32 bytes of the result at the index.
+//the evm caches storage reads, a second read of a key returns the first result, so the same call data in one
+//execution gets the same result, which is right for the queries and verifies served here.
+const (
+	systemCallKeyInput  = 1
+	systemCallKeyRun    = 2
+	systemCallKeyResult = 3
+
+	systemCallIDLen    = 13
+	systemCallChunkLen = 16
+	systemCallMaxData  = 0x10000 * systemCallChunkLen
+)
+
+//SystemContractABI is the abi of every system contract, the result is the json result of the internal call.
+//calls of type Execute are not served by the call, a contract emits the SystemCall event for them.
+const SystemContractABI = `[{"type":"function","name":"systemCall","stateMutability":"view",
+	"inputs":[{"name":"callType","type":"string"},{"name":"action","type":"string"},{"name":"data","type":"bytes"}],
+	"outputs":[{"name":"result","type":"bytes"}]}]`
+
+var systemContractABI, _ = smartAssetsABI.Parse([]byte(SystemContractABI))
+
+const (
+	opADD          = 0x01
+	opSUB          = 0x03
+	opLT           = 0x10
+	opISZERO       = 0x15
+	opAND          = 0x16
+	opOR           = 0x17
+	opSHL          = 0x1b
+	opSHR          = 0x1c
+	opSHA3         = 0x20
+	opCALLVALUE    = 0x34
+	opCALLDATALOAD = 0x35
+	opCALLDATASIZE = 0x36
+	opCALLDATACOPY = 0x37
+	opPOP          = 0x50
+	opMSTORE       = 0x52
+	opSLOAD        = 0x54
+	opJUMP         = 0x56
+	opJUMPI        = 0x57
+	opJUMPDEST     = 0x5b
+	opPUSH1        = 0x60
+	opPUSH2        = 0x61
+	opDUP1         = 0x80
+	opDUP2         = 0x81
+	opDUP3         = 0x82
+	opDUP4         = 0x83
+	opSWAP1        = 0x90
+	opRETURN       = 0xf3
+	opREVERT       = 0xfd
+)
+
+//evmAssembler builds evm code with jumps to labels
+type evmAssembler struct {
+	code   []byte
+	labels map[string] int
+	jumps  map[int] string
+}
+
+func (a *evmAssembler) op(ops ...byte) {
+	a.code = append(a.code, ops...)
+}
+
+func (a *evmAssembler) push(v byte) {
+	a.code = append(a.code, opPUSH1, v)
+}
+
+func (a *evmAssembler) pushLabel(name string) {
+	a.jumps[len(a.code) + 1] = name
+	a.code = append(a.code, opPUSH2, 0, 0)
+}
+
+func (a *evmAssembler) label(name string) {
+	a.labels[name] = len(a.code)
+	a.code = append(a.code, opJUMPDEST)
+}
+
+func (a *evmAssembler) assemble() []byte {
+	for at, name := range a.jumps {
+		binary.BigEndian.PutUint16(a.code[at:], uint16(a.labels[name]))
+	}
+
+	return a.code
+}
+
+//buildSystemContractCode builds the code of the system contracts, the stack is noted at the head of the loops
+func buildSystemContractCode() []byte {
+	a := &evmAssembler{
+		labels: map[string] int{},
+		jumps:  map[int] string{},
+	}
+
+	//system contracts take no value
+	a.op(opCALLVALUE, opISZERO)
+	a.pushLabel("begin")
+	a.op(opJUMPI)
+	a.push(0)
+	a.op(opDUP1, opREVERT)
+
+	a.label("begin")
+	a.op(opCALLDATASIZE)
+	a.push(0)
+	a.push(0)
+	a.op(opCALLDATACOPY)
+	a.op(opCALLDATASIZE)
+	a.push(0)
+	a.op(opSHA3)
+	a.push(256 - systemCallIDLen * 8)
+	a.op(opSHR)
+	a.push(128)
+	a.op(opSHL)
+	a.push(0)
+	//id i
+
+	a.label("input")
+	a.op(opCALLDATASIZE, opDUP2)
+	a.push(4)
+	a.op(opSHL, opLT, opISZERO)
+	a.pushLabel("run")
+	a.op(opJUMPI)
+	a.op(opDUP1)
+	a.push(4)
+	a.op(opSHL, opCALLDATALOAD)
+	a.push(128)
+	a.op(opSHR, opDUP3, opOR, opDUP2)
+	a.push(232)
+	a.op(opSHL, opOR)
+	a.push(systemCallKeyInput)
+	a.push(248)
+	a.op(opSHL, opOR, opSLOAD, opPOP)
+	a.push(1)
+	a.op(opADD)
+	a.pushLabel("input")
+	a.op(opJUMP)
+
+	a.label("run")
+	a.op(opPOP, opCALLDATASIZE, opDUP2, opOR)
+	a.push(systemCallKeyRun)
+	a.push(248)
+	a.op(opSHL, opOR, opSLOAD)
+	//id r
+	a.op(opDUP1)
+	a.push(1)
+	a.push(128)
+	a.op(opSHL)
+	a.push(1)
+	a.op(opSWAP1, opSUB, opAND)
+	a.push(0)
+	//id r size j
+
+	a.label("result")
+	a.op(opDUP2, opDUP2)
+	a.push(5)
+	a.op(opSHL, opLT, opISZERO)
+	a.pushLabel("done")
+	a.op(opJUMPI)
+	a.op(opDUP4, opDUP2)
+	a.push(232)
+	a.op(opSHL, opOR)
+	a.push(systemCallKeyResult)
+	a.push(248)
+	a.op(opSHL, opOR, opSLOAD, opDUP2)
+	a.push(5)
+	a.op(opSHL, opMSTORE)
+	a.push(1)
+	a.op(opADD)
+	a.pushLabel("result")
+	a.op(opJUMP)
+
+	a.label("done")
+	a.op(opPOP, opSWAP1)
+	a.push(248)
+	a.op(opSHR)
+	//id size failed
+	a.pushLabel("failed")
+	a.op(opJUMPI)
+	a.push(0)
+	a.op(opRETURN)
+
+	a.label("failed")
+	a.push(0)
+	a.op(opREVERT)
+
+	return a.assemble()
+}
+
+var systemContractCode = buildSystemContractCode()
+var systemContractCodeHash = keccak256(systemContractCode)
+
+//systemCallSession is a call to a system contract passed to the store by the reads of its code
+type systemCallSession struct {
+	input  map[uint16] []byte
+	result []byte
+}
+
+func (s *systemCallSession) callData(id []byte, size uint64) ([]byte, error) {
+	if size > systemCallMaxData {
+		return nil, errors.New("system call data too large")
+	}
+
+	data := make([]byte, 0, size + systemCallChunkLen)
+	for i := uint64(0); i * systemCallChunkLen < size; i++ {
+		chunk, exists := s.input[uint16(i)]
+		if !exists {
+			return nil, errors.New("system call data is incomplete")
+		}
+		data = append(data, chunk...)
+	}
+	data = data[:size]
+
+	if !bytes.Equal(keccak256(data)[:systemCallIDLen], id) {
+		return nil, errors.New("system call data doesn't match the call")
+	}
+
+	return data, nil
+}
+
+//abiEncodeBytes encodes the data as the only bytes or string value of an abi tuple
+func abiEncodeBytes(data []byte) []byte {
+	enc := make([]byte, 64 + (len(data) + 31) / 32 * 32)
+	enc[31] = 32
+	binary.BigEndian.PutUint64(enc[56:64], uint64(len(data)))
+	copy(enc[64:], data)
+	return enc
+}
+
+//abiRevertData encodes the message as the data of a revert with Error(string)
+func abiRevertData(message string) []byte {
+	return append([]byte{0x08, 0xc3, 0x79, 0xa0}, abiEncodeBytes([]byte(message))...)
+}
+
+//parseSystemCallData parses the call data of a system contract, encoded by SystemContractABI or
+//as the json of chainStructure.InternalCallRequest
+func parseSystemCallData(data []byte) (req chainStructure.InternalCallRequest, isABI bool, err error) {
+	call, abiErr := systemContractABI.DecodeCall(data)
+	if abiErr != nil {
+		req, err = chainStructure.ParseInternalCall(data)
+		return
+	}
+
+	isABI = true
+	req.Type, _ = call.Inputs[0].Value.(string)
+	req.Action, _ = call.Inputs[1].Value.(string)
+	dataHex, _ := call.Inputs[2].Value.(string)
+	req.Data, err = hex.DecodeString(strings.TrimPrefix(dataHex, "0x"))
+	return
+}
+
+//runSystemCall runs a call made by a contract to a system contract, and returns the abi encoded result or revert data
+func (c *contractStorage) runSystemCall(app string, data []byte) (result []byte, failed bool) {
+	req, _, err := parseSystemCallData(data)
+	if err != nil {
+		return abiRevertData(err.Error()), true
+	}
+
+	if req.Type != chainStructure.InternalCallQuery && req.Type != chainStructure.InternalCallVerify {
+		return abiRevertData(req.Type + " can't be called by a contract, emit a SystemCall event for it"), true
+	}
+
+	c.systemCallGas += systemCallGasOf(app, req)
+	ret, err := c.basedLedger.internalCall(app, req)
+	if err != nil {
+		return abiRevertData(err.Error()), true
+	}
+
+	retJson, _ := json.Marshal(ret)
+	return abiEncodeBytes(retJson), false
+}
+
+//systemCallLoad serves a storage read of the code of a system contract
+func (c *contractStorage) systemCallLoad(app string, k string) (*evmInt256.Int, error) {
+	if len(k) > 32 {
+		return nil, errors.New("invalid system call key")
+	}
+
+	key := make([]byte, 32)
+	copy(key[32 - len(k):], k)
+
+	kind := key[0]
+	index := binary.BigEndian.Uint16(key[1:3])
+	id := key[3:16]
+	value := key[16:]
+
+	if c.systemCalls == nil {
+		c.systemCalls = map[string] *systemCallSession{}
+	}
+
+	session, exists := c.systemCalls[string(id)]
+	if !exists {
+		session = &systemCallSession{
+			input: map[uint16] []byte{},
+		}
+		c.systemCalls[string(id)] = session
+	}
+
+	ret := evmInt256.New(0)
+	switch kind {
+	case systemCallKeyInput:
+		session.input[index] = append([]byte{}, value...)
+
+	case systemCallKeyRun:
+		var failed bool
+		data, err := session.callData(id, binary.BigEndian.Uint64(value[8:]))
+		if err != nil {
+			session.result, failed = abiRevertData(err.Error()), true
+		} else {
+			session.result, failed = c.runSystemCall(app, data)
+		}
+
+		word := make([]byte, 32)
+		if failed {
+			word[0] = 1
+		}
+		binary.BigEndian.PutUint64(word[24:], uint64(len(session.result)))
+		ret.SetBytes(word)
+
+	case systemCallKeyResult:
+		word := make([]byte, 32)
+		if start := int(index) * 32; start < len(session.result) {
+			copy(word, session.result[start:])
+		}
+		ret.SetBytes(word)
+
+	default:
+		return nil, errors.New("invalid system call key")
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,429 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/service/application/basicAssets/basicAssetsLedger"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsABI"
+	"github.com/SealSC/SealABC/service/application/traceableStorage/tsData"
+	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//system contracts are fixed addresses bridging contracts to the other applications of the chain by internal calls.
+//a contract calls a system contract by SystemContractABI for a query or a verify, and gets the json result in the
+//same call, see systemContractCode. a transaction or an eth call to a system contract may carry the call encoded the
+//same way or a json chainStructure.InternalCallRequest in its data.
+//calls changing other applications can't run inside the evm, since the evm execution is not rolled back with them,
+//so a contract emits SystemCall(address indexed target, string callType, string action, bytes data) for them instead.
+//every such log is verified by the target application after the contract call succeeded, a failed verify fails
+//the whole transaction. calls of type Execute are run by the target application when the block is executed and
+//rolled back with it, so the contract call and the changes it made to other applications are atomic.
+//calls are verified against the state before the block, so the resources an Execute call changes are reserved in the
+//block, a later call to the same resources in the block fails its transaction instead of failing the block execution.
+const systemCallEventABI = `[{"type":"event","name":"SystemCall","anonymous":false,"inputs":[
+	{"name":"target","type":"address","indexed":true},
+	{"name":"callType","type":"string","indexed":false},
+	{"name":"action","type":"string","indexed":false},
+	{"name":"data","type":"bytes","indexed":false}]}]`
+
+//gas charged for a system call besides the gas used by the evm, by the called application and the call type.
+//every byte of the call data costs systemCallDataGas more.
+var systemCallGas = map[string] map[string] uint64{
+	"Basic Assets": {
+		chainStructure.InternalCallQuery:   2000,
+		chainStructure.InternalCallVerify:  8000,
+		chainStructure.InternalCallExecute: 25000,
+	},
+	"Universal Identification": {
+		chainStructure.InternalCallQuery:   2000,
+		chainStructure.InternalCallVerify:  6000,
+		chainStructure.InternalCallExecute: 20000,
+	},
+	"Traceable Storage": {
+		chainStructure.InternalCallQuery:   2000,
+		chainStructure.InternalCallVerify:  5000,
+		chainStructure.InternalCallExecute: 20000,
+	},
+	"Memo": {
+		chainStructure.InternalCallQuery:   2000,
+		chainStructure.InternalCallVerify:  3000,
+		chainStructure.InternalCallExecute: 10000,
+	},
+}
+
+const systemCallDataGas = 16
+
+func systemCallGasOf(app string, req chainStructure.InternalCallRequest) uint64 {
+	return systemCallGas[app][req.Type] + uint64(len(req.Data)) * systemCallDataGas
+}
+
+var systemCallEvent, _ = smartAssetsABI.Parse([]byte(systemCallEventABI))
+
+var systemContracts = map[string] string{
+	string(systemContractAddress(1)): "Basic Assets",
+	string(systemContractAddress(2)): "Universal Identification",
+	string(systemContractAddress(3)): "Traceable Storage",
+	string(systemContractAddress(4)): "Memo",
+}
+
+//systemCallRecord is kept in the new state of a transaction for every call to execute when its block is executed
+type systemCallRecord struct {
+	Application string
+	Request     chainStructure.InternalCallRequest
+}
+
+//systemCallResources returns the resources changed by an Execute call to the application,
+//two calls to the same resource can't be executed in one block
+var systemCallResources = map[string] func(req chainStructure.InternalCallRequest) ([]string, error){
+	"Basic Assets": basicAssetsCallResources,
+	"Universal Identification": uidCallResources,
+	"Traceable Storage": tsCallResources,
+}
+
+//basicAssetsCallResources returns the spent outputs of a transfer
+func basicAssetsCallResources(req chainStructure.InternalCallRequest) (resources []string, err error) {
+	tx := basicAssetsLedger.Transaction{}
+	err = json.Unmarshal(req.Data, &tx)
+	if err != nil {
+		return
+	}
+
+	for _, in := range tx.Input {
+		resources = append(resources, fmt.Sprintf("%x:%d", in.Transaction, in.OutputIndex))
+	}
+
+	return
+}
+
+//uidCallResources returns the identification created or changed by an uid action
+func uidCallResources(req chainStructure.InternalCallRequest) (resources []string, err error) {
+	action := struct {
+		Identification string
+		UID            struct {
+			Identification string
+		}
+	}{}
+
+	err = json.Unmarshal(req.Data, &action)
+	if err != nil {
+		return
+	}
+
+	id := action.Identification
+	if id == "" {
+		id = action.UID.Identification
+	}
+
+	return []string{id}, nil
+}
+
+//tsCallResources returns the traceable data saved by a request and the previous data it links to
+func tsCallResources(req chainStructure.InternalCallRequest) (resources []string, err error) {
+	tsReq := tsData.TSServiceRequest{}
+	err = json.Unmarshal(req.Data, &tsReq)
+	if err != nil {
+		return
+	}
+
+	resources = []string{tsReq.Data.OnChainID}
+	if tsReq.Data.PrevOnChainID != "" {
+		resources = append(resources, tsReq.Data.PrevOnChainID)
+	}
+
+	return
+}
+
+func systemCallResourceKey(app string, resource string) string {
+	return CachedSystemCallResourcePrefix + app + ":" + resource
+}
+
+//reserveSystemCall reserves the resources of an Execute call in the block for the transaction,
+//calls without known resources reserve their call data, so the same call can't be executed twice in a block
+func (c txResultCache) reserveSystemCall(tx Transaction, app string, req chainStructure.InternalCallRequest) error {
+	resources := []string{fmt.Sprintf("%s:%x", req.Action, req.Data)}
+	if parse, exists := systemCallResources[app]; exists {
+		parsed, err := parse(req)
+		if err != nil {
+			return Errors.SystemCallFailed.NewErrorWithNewMessage(err.Error())
+		}
+		resources = append(resources, parsed...)
+	}
+
+	for _, r := range resources {
+		if _, exists := c[systemCallResourceKey(app, r)]; exists {
+			return Errors.SystemCallFailed.NewErrorWithNewMessage("resource " + r + " of " + app + " is used by another call in the block")
+		}
+	}
+
+	for _, r := range resources {
+		c[systemCallResourceKey(app, r)] = &txResultCacheData{
+			Data: tx.DataSeal.Hash,
+		}
+	}
+
+	return nil
+}
+
+//releaseSystemCalls releases the resources reserved by a failed transaction
+func (c txResultCache) releaseSystemCalls(tx Transaction) {
+	for k, data := range c {
+		if strings.HasPrefix(k, CachedSystemCallResourcePrefix) && bytes.Equal(data.Data, tx.DataSeal.Hash) {
+			delete(c, k)
+		}
+	}
+}
+
+func systemContractAddress(index byte) []byte {
+	addr := make([]byte, AddressLen)
+	addr[AddressLen - 2] = 0x10
+	addr[AddressLen - 1] = index
+	return addr
+}
+
+//SystemContracts returns the application bridged by every system contract, keyed by hex address
+func SystemContracts() map[string] string {
+	contracts := map[string] string{}
+	for addr, app := range systemContracts {
+		contracts[hex.EncodeToString([]byte(addr))] = app
+	}
+
+	return contracts
+}
+
+func isSystemContract(address []byte) bool {
+	_, exists := systemContracts[string(address)]
+	return exists
+}
+
+func systemCallKey(txHash []byte, index int) []byte {
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, uint32(index))
+	return BuildKey(StoragePrefixes.SystemCall, txHash, indexBytes)
+}
+
+func (l *Ledger) internalCall(app string, req chainStructure.InternalCallRequest) (interface{}, error) {
+	if l.chain == nil {
+		return nil, errors.New("no chain for system calls")
+	}
+
+	return l.chain.InternalCall(l.ApplicationName, app, req.Bytes())
+}
+
+//verifySystemCall verifies the call by its application, and returns the state to execute it later for an Execute call
+func (l *Ledger) verifySystemCall(tx Transaction, index int, app string, req chainStructure.InternalCallRequest, cache txResultCache) ([]StateData, interface{}, error) {
+	callType := req.Type
+	switch callType {
+	case chainStructure.InternalCallQuery, chainStructure.InternalCallVerify, chainStructure.InternalCallExecute:
+	default:
+		return nil, nil, Errors.SystemCallFailed.NewErrorWithNewMessage(callType + " can't be called by a transaction")
+	}
+
+	if callType == chainStructure.InternalCallExecute {
+		req.Type = chainStructure.InternalCallVerify
+	}
+
+	ret, err := l.internalCall(app, req)
+	if err != nil {
+		return nil, nil, Errors.SystemCallFailed.NewErrorWithNewMessage(err.Error())
+	}
+
+	if callType != chainStructure.InternalCallExecute {
+		return nil, ret, nil
+	}
+
+	err = cache.reserveSystemCall(tx, app, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record, _ := json.Marshal(systemCallRecord{
+		Application: app,
+		Request: chainStructure.InternalCallRequest{
+			Type:   chainStructure.InternalCallExecute,
+			Action: req.Action,
+			Data:   req.Data,
+		},
+	})
+
+	return []StateData{{
+		Key:    systemCallKey(tx.DataSeal.Hash, index),
+		NewVal: record,
+	}}, ret, nil
+}
+
+func chargeSystemCallGas(cache txResultCache, gas uint64) error {
+	if cache[CachedTxGasKey].gasLeft < gas {
+		cache[CachedTxGasKey].gasLeft = 0
+		return Errors.SystemCallFailed.NewErrorWithNewMessage("out of gas")
+	}
+
+	cache[CachedTxGasKey].gasLeft -= gas
+	return nil
+}
+
+//preSystemContractCall runs the internal call in the data of a transaction sent to a system contract,
+//the result is abi encoded for a call encoded by SystemContractABI
+func (l *Ledger) preSystemContractCall(tx Transaction, app string, cache txResultCache) ([]StateData, txResultCache, error) {
+	if value, valid := big.NewInt(0).SetString(tx.Value, 10); tx.Value != "" && (!valid || value.Sign() != 0) {
+		return nil, cache, Errors.InvalidTransferValue
+	}
+
+	req, isABI, err := parseSystemCallData(tx.Data)
+	if err != nil {
+		return nil, cache, Errors.SystemCallFailed.NewErrorWithNewMessage(err.Error())
+	}
+
+	err = chargeSystemCallGas(cache, systemCallGasOf(app, req))
+	if err != nil {
+		return nil, cache, err
+	}
+
+	newState, ret, err := l.verifySystemCall(tx, 0, app, req, cache)
+	if err != nil {
+		return nil, cache, err
+	}
+
+	retJson, _ := json.Marshal(ret)
+	if isABI {
+		retJson = abiEncodeBytes(retJson)
+	}
+	cache[CachedContractReturnData].Data = retJson
+	return newState, cache, Errors.Success
+}
+
+func systemCallFromLog(log chainStructure.ReceiptLog) (app string, req chainStructure.InternalCallRequest, isSystemCall bool, err error) {
+	ev := systemCallEvent.Events["SystemCall(address,string,string,bytes)"]
+	if len(log.Topics) == 0 || !bytes.Equal(log.Topics[0], ev.ID) {
+		return
+	}
+
+	isSystemCall = true
+	decoded, err := systemCallEvent.DecodeLog(log.Topics, log.Data)
+	if err != nil {
+		return
+	}
+
+	target, _ := hex.DecodeString(strings.TrimPrefix(decoded.Inputs[0].Value.(string), "0x"))
+	app, exists := systemContracts[string(target)]
+	if !exists {
+		err = errors.New("no system contract at " + decoded.Inputs[0].Value.(string))
+		return
+	}
+
+	req.Type = decoded.Inputs[1].Value.(string)
+	req.Action = decoded.Inputs[2].Value.(string)
+	req.Data, err = hex.DecodeString(strings.TrimPrefix(decoded.Inputs[3].Value.(string), "0x"))
+	return
+}
+
+//systemCallsOfLogs verifies the SystemCall logs in the new state of a succeeded contract execution in their order,
+//and returns the state to execute them when the block is executed
+func (l *Ledger) systemCallsOfLogs(tx Transaction, newState []StateData, cache txResultCache) ([]StateData, error) {
+	logTx := tx
+	logTx.NewState = newState
+
+	var callState []StateData
+	count := 0
+	for _, log := range TransactionLogs(logTx) {
+		app, req, isSystemCall, err := systemCallFromLog(log)
+		if !isSystemCall {
+			continue
+		}
+
+		if err != nil {
+			return nil, Errors.SystemCallFailed.NewErrorWithNewMessage(err.Error())
+		}
+
+		err = chargeSystemCallGas(cache, systemCallGasOf(app, req))
+		if err != nil {
+			return nil, err
+		}
+
+		state, _, err := l.verifySystemCall(tx, count, app, req, cache)
+		if err != nil {
+			return nil, err
+		}
+
+		callState = append(callState, state...)
+		count++
+	}
+
+	return callState, nil
+}
+
+func systemCallsOfTxList(txList TransactionList) (keys [][]byte, records []systemCallRecord) {
+	prefix := BuildKey(StoragePrefixes.SystemCall, nil)
+	for _, tx := range txList.Transactions {
+		for _, s := range tx.NewState {
+			if !bytes.HasPrefix(s.Key, prefix) {
+				continue
+			}
+
+			record := systemCallRecord{}
+			if json.Unmarshal(s.NewVal, &record) != nil {
+				continue
+			}
+
+			keys = append(keys, s.Key)
+			records = append(records, record)
+		}
+	}
+
+	return
+}
+
+func (l *Ledger) rollbackSystemCalls(keys [][]byte, records []systemCallRecord) (err error) {
+	for i := len(records) - 1; i >= 0; i-- {
+		req := records[i].Request
+		req.Type = chainStructure.InternalCallRollback
+		req.UndoKey = keys[i]
+
+		_, err = l.internalCall(records[i].Application, req)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//executeSystemCalls executes the system calls recorded by the transactions,
+//the executed ones are rolled back if any of them failed
+func (l *Ledger) executeSystemCalls(txList TransactionList) error {
+	keys, records := systemCallsOfTxList(txList)
+	for i, record := range records {
+		req := record.Request
+		req.Type = chainStructure.InternalCallExecute
+		req.UndoKey = keys[i]
+
+		_, err := l.internalCall(record.Application, req)
+		if err != nil {
+			_ = l.rollbackSystemCalls(keys[:i], records[:i])
+			return err
+		}
+	}
+
+	return nil
+}
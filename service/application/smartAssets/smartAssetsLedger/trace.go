@@ -0,0 +1,262 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsLedger
+
+import (
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+//maxTraceBlocks limits how many blocks back a transaction can be replayed from the latest state, the state before
+//older transactions is not available for tracing, neither is the state before a transaction in a pruned block body
+const maxTraceBlocks = 1000
+
+type TraceCall struct {
+	Depth    int
+	Contract string
+	CodeSize int
+}
+
+type TraceStorageAccess struct {
+	Contract string
+	Key      string
+	Value    string
+}
+
+type TraceLog struct {
+	Address string
+	Topics  []string
+	Data    string
+}
+
+//CallTrace is a call level trace of a transaction, it is what the replay of the transaction observed through
+//the storage of the evm. it is not an instruction level trace, there are no opcode steps or gas per step in it.
+//Calls holds the contracts in the order their code was loaded, the top level contract first,
+//and Consistent tells whether the replay got the same result as the block did.
+type CallTrace struct {
+	TxHash      string
+	BlockHeight uint64
+	TxIndex     int
+	Type        string
+	From        string
+	To          string
+	Success     bool
+	ErrorCode   int64
+	Error       string
+	GasUsed     uint64
+	ReturnData  string
+	Consistent  bool
+
+	Calls         []TraceCall
+	StorageReads  []TraceStorageAccess
+	StorageWrites []TraceStorageAccess
+	Logs          []TraceLog
+}
+
+type evmTracer struct {
+	trace *CallTrace
+}
+
+func (t *evmTracer) codeLoaded(address []byte, code []byte) {
+	t.trace.Calls = append(t.trace.Calls, TraceCall{
+		Depth:    len(t.trace.Calls),
+		Contract: hex.EncodeToString(address),
+		CodeSize: len(code),
+	})
+}
+
+func (t *evmTracer) storageRead(ns string, key string, val []byte) {
+	t.trace.StorageReads = append(t.trace.StorageReads, TraceStorageAccess{
+		Contract: hex.EncodeToString([]byte(ns)),
+		Key:      hex.EncodeToString([]byte(key)),
+		Value:    hex.EncodeToString(val),
+	})
+}
+
+func (t *evmTracer) storageWritten(ns string, key string, val []byte) {
+	t.trace.StorageWrites = append(t.trace.StorageWrites, TraceStorageAccess{
+		Contract: hex.EncodeToString([]byte(ns)),
+		Key:      hex.EncodeToString([]byte(key)),
+		Value:    hex.EncodeToString(val),
+	})
+}
+
+//historicalStorage reads the state before a transaction, keys changed since then are read from their original values
+type historicalStorage struct {
+	kvDatabase.IDriver
+	orgValues map[string] []byte
+}
+
+func (h *historicalStorage) Get(k []byte) (kv kvDatabase.KVItem, err error) {
+	if orgVal, changed := h.orgValues[string(k)]; changed {
+		return kvDatabase.KVItem{
+			Key:    k,
+			Data:   orgVal,
+			Exists: len(orgVal) != 0,
+		}, nil
+	}
+
+	return h.IDriver.Get(k)
+}
+
+func (h *historicalStorage) Check(k []byte) (exists bool, err error) {
+	kv, err := h.Get(k)
+	return kv.Exists, err
+}
+
+func (h *historicalStorage) undo(tx Transaction) {
+	for i := len(tx.NewState) - 1; i >= 0; i-- {
+		s := tx.NewState[i]
+		h.orgValues[string(s.Key)] = s.OrgVal
+	}
+}
+
+func (l *Ledger) txListOfBlock(blk block.Entity) (txList TransactionList, err error) {
+	for _, req := range blk.Body.Requests {
+		if req.RequestApplication != l.ApplicationName {
+			continue
+		}
+
+		reqTxList := TransactionList{}
+		err = structSerializer.FromMFBytes(req.Data, &reqTxList)
+		if err != nil {
+			return
+		}
+
+		txList.Transactions = append(txList.Transactions, reqTxList.Transactions...)
+	}
+
+	return
+}
+
+//stateBeforeTx walks back from the latest block and undoes every transaction until the one to trace
+func (l *Ledger) stateBeforeTx(txHash []byte) (hs *historicalStorage, tx Transaction, blk block.Entity, txIdx int, err error) {
+	hs = &historicalStorage{
+		IDriver:   l.Storage,
+		orgValues: map[string] []byte{},
+	}
+
+	last := l.chain.GetLastBlock()
+	if last == nil {
+		return nil, tx, blk, 0, errors.New("no block")
+	}
+
+	for height := last.Header.Height; last.Header.Height - height < maxTraceBlocks; height-- {
+		err = l.chain.CheckBlockBodyAvailable(height)
+		if err != nil {
+			return nil, tx, blk, 0, Errors.StateNotAvailable.NewErrorWithNewMessage(
+				"state not available, transaction not found in the kept blocks: " + err.Error())
+		}
+
+		blk, err = l.chain.GetBlockByHeight(height)
+		if err != nil {
+			return
+		}
+
+		txList, listErr := l.txListOfBlock(blk)
+		if listErr != nil {
+			return nil, tx, blk, 0, listErr
+		}
+
+		for i := len(txList.Transactions) - 1; i >= 0; i-- {
+			hs.undo(txList.Transactions[i])
+			if bytes.Equal(txList.Transactions[i].DataSeal.Hash, txHash) {
+				return hs, txList.Transactions[i], blk, i, nil
+			}
+		}
+
+		if height == 0 {
+			break
+		}
+	}
+
+	return nil, tx, blk, 0, Errors.StateNotAvailable.NewErrorWithNewMessage(
+		fmt.Sprintf("state not available, transaction not found in the last %d blocks", maxTraceBlocks))
+}
+
+//TraceTransactionCalls replays a stored transaction on the state before it at its block, only the transactions
+//of the last maxTraceBlocks blocks with their bodies kept can be traced
+func (l *Ledger) TraceTransactionCalls(txHash []byte) (trace *CallTrace, err error) {
+	_, exists, err := l.GetTransaction(txHash)
+	if err != nil {
+		return
+	}
+
+	if !exists {
+		return nil, errors.New("no such transaction")
+	}
+
+	hs, tx, blk, txIdx, err := l.stateBeforeTx(txHash)
+	if err != nil {
+		return
+	}
+
+	trace = &CallTrace{
+		TxHash:      hex.EncodeToString(tx.DataSeal.Hash),
+		BlockHeight: blk.Header.Height,
+		TxIndex:     txIdx,
+		Type:        tx.Type,
+		From:        hex.EncodeToString(tx.From),
+		To:          hex.EncodeToString(tx.To),
+	}
+
+	replayLedger := l.readOnlyCopy(hs)
+	replayLedger.storageForEVM.tracer = &evmTracer{trace: trace}
+
+	replayed := tx
+	replayed.TransactionResult = TransactionResult{SequenceNumber: tx.SequenceNumber}
+
+	cache := replayLedger.newTxResultCache()
+	newState, gasUsed, execErr := replayLedger.executeTransaction(replayed, cache, blk)
+
+	replayLedger.setTxNewState(execErr, newState, gasUsed, &replayed)
+	if cache[CachedContractReturnData] != nil {
+		replayed.ReturnData = cache[CachedContractReturnData].Data
+	}
+
+	trace.Success = replayed.Success
+	trace.ErrorCode = replayed.ErrorCode
+	if !replayed.Success {
+		revertErr, _ := replayLedger.revertError(execErr, tx.To, replayed.ReturnData)
+		trace.Error = revertErr.Error()
+	}
+	trace.GasUsed = replayed.GasUsed
+	trace.ReturnData = hex.EncodeToString(replayed.ReturnData)
+	trace.Consistent = replayed.Success == tx.Success &&
+		replayed.ErrorCode == tx.ErrorCode &&
+		replayed.GasUsed == tx.GasUsed &&
+		len(replayed.NewState) == len(tx.NewState)
+
+	for _, log := range TransactionLogs(replayed) {
+		traceLog := TraceLog{
+			Address: hex.EncodeToString(log.Address),
+			Data:    hex.EncodeToString(log.Data),
+		}
+
+		for _, topic := range log.Topics {
+			traceLog.Topics = append(traceLog.Topics, hex.EncodeToString(topic))
+		}
+		trace.Logs = append(trace.Logs, traceLog)
+	}
+
+	return
+}
@@ -34,9 +34,11 @@ const (
 )
 
 var TxType struct {
-	Transfer       enum.Element
-	CreateContract enum.Element
-	ContractCall   enum.Element
+	Transfer        enum.Element
+	CreateContract  enum.Element
+	ContractCall    enum.Element
+	RegisterABI     enum.Element
+	UpgradeContract enum.Element
 }
 
 func GetTxTypeCodeForName(name string) int {
@@ -49,6 +51,12 @@ func GetTxTypeCodeForName(name string) int {
 
 	case TxType.ContractCall.String():
 		return TxType.ContractCall.Int()
+
+	case TxType.RegisterABI.String():
+		return TxType.RegisterABI.Int()
+
+	case TxType.UpgradeContract.String():
+		return TxType.UpgradeContract.Int()
 	}
 
 	return 1000
@@ -62,6 +70,16 @@ type TransactionData struct {
 	Data           []byte
 	Memo           string
 	SerialNumber   string
+
+	//count of the transactions sent by From before this one
+	Nonce          uint64
+
+	//decimal price of one gas and the max gas can be used by the transaction
+	GasPrice       string
+	GasLimit       uint64
+
+	ChainID        string
+	ExpiryHeight   uint64
 }
 
 type StateData struct {
@@ -76,7 +94,11 @@ type TransactionResult struct {
 	SequenceNumber uint32
 	NewAddress     []byte
 	ReturnData     []byte
+	GasUsed        uint64
 	NewState       []StateData
+
+	//readable reason decoded from the return data of a reverted contract execution
+	RevertReason   string
 }
 
 type Transaction struct {
@@ -104,8 +126,21 @@ func (t *Transaction) getHash() []byte {
 	return t.DataSeal.Hash
 }
 
-func (t *Transaction) verify(hashCalc hashes.IHashCalculator) (passed bool, err error) {
-	if !bytes.Equal(t.From, t.DataSeal.SignerPublicKey) {
+//verifySeal verifies the transaction seal, a seal of ethereum transaction is verified by the signed raw transaction in it
+func (t *Transaction) verifySeal(hashCalc hashes.IHashCalculator, ethChainID uint64) (passed bool, err error) {
+	if t.DataSeal.SignerAlgorithm == EthereumSignerAlgorithm {
+		if ethChainID == 0 {
+			return false, errors.New("ethereum transaction is not enabled")
+		}
+
+		return t.verifyEthTransaction(ethChainID)
+	}
+
+	return t.DataSeal.Verify(t.getData(), hashCalc)
+}
+
+func (t *Transaction) verify(hashCalc hashes.IHashCalculator, ethChainID uint64) (passed bool, err error) {
+	if !bytes.Equal(t.From, senderAddress(t.DataSeal)) {
 		return false, errors.New("invalid sender")
 	}
 
@@ -117,7 +152,7 @@ func (t *Transaction) verify(hashCalc hashes.IHashCalculator) (passed bool, err
 		return false, errors.New("data too large")
 	}
 
-	passed, err = t.DataSeal.Verify(t.getData(), hashCalc)
+	passed, err = t.verifySeal(hashCalc, ethChainID)
 	if !passed {
 		return
 	}
@@ -134,8 +169,12 @@ type txResultCacheData struct {
 
 const (
 	CachedBlockGasKey             = "blockGas"
+	CachedTxGasKey                = "txGas"
 	CachedContractReturnData      = "contractReturnData"
 	CachedContractCreationAddress = "contractCreationAddress"
+
+	//prefix of the resources reserved by the system calls in the block
+	CachedSystemCallResourcePrefix = "systemCallResource:"
 )
 
 type txResultCache map[string] *txResultCacheData
@@ -37,6 +37,7 @@ var QueryTypes struct{
 	ContractList    enum.Element
 	ContractCall    enum.Element
 	TransferList    enum.Element
+	Logs            enum.Element
 }
 
 var QueryParameterFields struct{
@@ -44,6 +45,11 @@ var QueryParameterFields struct{
 	Contract enum.Element
 	TxHash   enum.Element
 	Page     enum.Element
+
+	Address    enum.Element
+	Topics     enum.Element
+	FromHeight enum.Element
+	ToHeight   enum.Element
 }
 
 const rowsPerPage = 20
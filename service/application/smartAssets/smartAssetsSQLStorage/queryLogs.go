@@ -0,0 +1,108 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsSQLStorage
+
+import (
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsSQLTables"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var contractLogRowType = smartAssetsSQLTables.ContractLogRow{}
+var contractLogTableName = smartAssetsSQLTables.ContractLog.Name()
+
+//inCondition builds "col in (?,?...)" for a comma separated value list
+func inCondition(col string, list []string) (condition string, args []interface{}) {
+	var holders []string
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		holders = append(holders, "?")
+		args = append(args, v)
+	}
+
+	if len(holders) == 0 {
+		return
+	}
+
+	condition = fmt.Sprintf("`%s` in (%s)", col, strings.Join(holders, ","))
+	return
+}
+
+func (s Storage) queryLogs(param queryParam) (interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	addrCondition, addrArgs := inCondition("c_address", strings.Split(param[QueryParameterFields.Address.String()], ","))
+	if addrCondition != "" {
+		conditions = append(conditions, addrCondition)
+		args = append(args, addrArgs...)
+	}
+
+	if topicsJson := param[QueryParameterFields.Topics.String()]; topicsJson != "" {
+		var topics [][]string
+		if err := json.Unmarshal([]byte(topicsJson), &topics); err != nil {
+			return nil, errors.New("invalid parameters")
+		}
+
+		for i, t := range topics {
+			if i >= 4 {
+				break
+			}
+
+			topicCondition, topicArgs := inCondition(fmt.Sprintf("c_topic%d", i), t)
+			if topicCondition != "" {
+				conditions = append(conditions, topicCondition)
+				args = append(args, topicArgs...)
+			}
+		}
+	}
+
+	for field, op := range map[string] string {
+		QueryParameterFields.FromHeight.String(): ">=",
+		QueryParameterFields.ToHeight.String():   "<=",
+	} {
+		if val := param[field]; val != "" {
+			height, err := strconv.ParseUint(val, 10, 64)
+			if err != nil {
+				return nil, errors.New("invalid parameters")
+			}
+
+			conditions = append(conditions, "`c_height`" + op + "?")
+			args = append(args, height)
+		}
+	}
+
+	commonParam := commonPagingQueryParam{
+		queryParam:    param,
+		rowType:       contractLogRowType,
+		table:         contractLogTableName,
+	}
+
+	if len(conditions) != 0 {
+		commonParam.condition = " where " + strings.Join(conditions, " and ") + " "
+		commonParam.conditionArgs = args
+	}
+
+	return s.commonPagingQuery(commonParam)
+}
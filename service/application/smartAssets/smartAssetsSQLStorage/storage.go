@@ -19,12 +19,18 @@ package smartAssetsSQLStorage
 
 import (
 	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsLedger"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
 )
 
+type ContractCallDecoder func(tx smartAssetsLedger.Transaction) (*smartAssetsLedger.DecodedContractCall, error)
+
 type Storage struct {
 	queryHandlers map[string] queryHandler
 	Driver simpleSQLDatabase.IDriver
+
+	//decodes contract calls by the registered abi before they are stored, nil means not decoded
+	CallDecoder ContractCallDecoder
 }
 
 func Load() {
@@ -47,6 +53,7 @@ func NewStorage(sqlDriver simpleSQLDatabase.IDriver) (s *Storage) {
 		QueryTypes.ContractList.String():    s.queryContractList,
 		QueryTypes.ContractCall.String():    s.queryContractCallByHash,
 		QueryTypes.TransferList.String():    s.queryTransferList,
+		QueryTypes.Logs.String():            s.queryLogs,
 	}
 
 	return
@@ -36,7 +36,8 @@ func (s Storage) getClassifiedTableRows(txType string) smartAssetsSQLTables.ISQL
 		rows := smartAssetsSQLTables.ContractCall.NewRows().(smartAssetsSQLTables.ContractCallRows)
 		return &rows
 
-	case smartAssetsLedger.TxType.CreateContract.String():
+	case smartAssetsLedger.TxType.CreateContract.String(),
+		smartAssetsLedger.TxType.UpgradeContract.String():
 		rows := smartAssetsSQLTables.Contract.NewRows().(smartAssetsSQLTables.ContractRows)
 		return &rows
 	}
@@ -74,13 +75,34 @@ func (s Storage) StoreTransaction(tx smartAssetsLedger.Transaction, blk block.En
 		return
 	}
 
-	classifiedRows.Insert(tx, blk)
-	_, err = s.Driver.Insert(classifiedRows, true)
-	if err != nil {
-		log.Log.Error("insert classified rows [" + tx.Type + "] failed: " + err.Error())
+	callRows, isCall := classifiedRows.(*smartAssetsSQLTables.ContractCallRows)
+	if isCall && s.CallDecoder != nil {
+		decoded, decodeErr := s.CallDecoder(tx)
+		if decodeErr != nil {
+			log.Log.Warn("decode contract call failed: ", decodeErr.Error())
+		}
+		callRows.InsertDecoded(tx, blk, decoded)
+	} else {
+		classifiedRows.Insert(tx, blk)
+	}
+
+	if classifiedRows.Count() != 0 {
+		_, err = s.Driver.Insert(classifiedRows, true)
+		if err != nil {
+			log.Log.Error("insert classified rows [" + tx.Type + "] failed: " + err.Error())
+		}
 	}
 
 
+	logRows := smartAssetsSQLTables.ContractLog.NewRows().(smartAssetsSQLTables.ContractLogRows)
+	logRows.Insert(tx, blk)
+	if len(logRows.Rows) != 0 {
+		_, err = s.Driver.Insert(&logRows, true)
+		if err != nil {
+			log.Log.Error("insert contract log rows failed: " + err.Error())
+		}
+	}
+
 	addressListRows := smartAssetsSQLTables.AddressList.NewRows().(smartAssetsSQLTables.AddressListRows)
 	for _, v := range tx.TransactionResult.NewState {
 		if isBalance, addr := s.isNewBalance(v.Key); isBalance {
@@ -23,6 +23,7 @@ import (
 	"github.com/SealSC/SealABC/metadata/block"
 	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsLedger"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"time"
@@ -38,6 +39,9 @@ type ContractTable struct {
 	ContractAddress enum.Element `col:"c_contract_address"`
 	ContractData    enum.Element `col:"c_contract_data"`
 	Memo            enum.Element `col:"c_memo"`
+	Action          enum.Element `col:"c_action"`
+	CodeHash        enum.Element `col:"c_code_hash"`
+	OldCodeHash     enum.Element `col:"c_old_code_hash"`
 	Time            enum.Element `col:"c_time"`
 
 	simpleSQLDatabase.BasicTable
@@ -68,6 +72,9 @@ type ContractRow struct {
 	ContractAddress string
 	ContractData    string
 	Memo            string
+	Action          string
+	CodeHash        string
+	OldCodeHash     string
 	Time            string
 }
 
@@ -75,7 +82,13 @@ type ContractRows struct {
 	simpleSQLDatabase.BasicRows
 }
 
+//ContractRows holds creations and upgrades, an upgrade approval which didn't change the code has no row
 func (t *ContractRows) Insert(tx smartAssetsLedger.Transaction, blk block.Entity) {
+	if tx.Type == smartAssetsLedger.TxType.UpgradeContract.String() {
+		t.insertUpgrade(tx, blk)
+		return
+	}
+
 	timestamp := time.Unix(int64(blk.Header.Timestamp), 0)
 	newAddressRow := ContractRow{
 		Height:          fmt.Sprintf("%d", blk.Header.Height),
@@ -86,12 +99,51 @@ func (t *ContractRows) Insert(tx smartAssetsLedger.Transaction, blk block.Entity
 		ContractAddress: hex.EncodeToString(tx.TransactionResult.NewAddress),
 		ContractData:    hex.EncodeToString(tx.TransactionResult.ReturnData),
 		Memo:            tx.Memo,
+		Action:          smartAssetsLedger.TxType.CreateContract.String(),
+		CodeHash:        contractHashOf(tx, tx.TransactionResult.NewAddress),
 		Time:            timestamp.Format(common.BASIC_TIME_FORMAT),
 	}
 
 	t.Rows = append(t.Rows, newAddressRow)
 }
 
+func contractHashOf(tx smartAssetsLedger.Transaction, address []byte) string {
+	hashKey := smartAssetsLedger.BuildKey(smartAssetsLedger.StoragePrefixes.ContractHash, address)
+	for _, s := range tx.TransactionResult.NewState {
+		if bytes.Equal(s.Key, hashKey) {
+			return hex.EncodeToString(s.NewVal)
+		}
+	}
+
+	return ""
+}
+
+func (t *ContractRows) insertUpgrade(tx smartAssetsLedger.Transaction, blk block.Entity) {
+	hashKey := smartAssetsLedger.BuildKey(smartAssetsLedger.StoragePrefixes.ContractHash, tx.To)
+	for _, s := range tx.TransactionResult.NewState {
+		if !bytes.Equal(s.Key, hashKey) {
+			continue
+		}
+
+		timestamp := time.Unix(int64(blk.Header.Timestamp), 0)
+		t.Rows = append(t.Rows, ContractRow{
+			Height:          fmt.Sprintf("%d", blk.Header.Height),
+			TxHash:          hex.EncodeToString(tx.DataSeal.Hash),
+			SequenceNumber:  fmt.Sprintf("%d", tx.SequenceNumber),
+			Creator:         hex.EncodeToString(tx.From),
+			CreationData:    hex.EncodeToString(tx.Data),
+			ContractAddress: hex.EncodeToString(tx.To),
+			ContractData:    hex.EncodeToString(tx.Data),
+			Memo:            tx.Memo,
+			Action:          smartAssetsLedger.TxType.UpgradeContract.String(),
+			CodeHash:        hex.EncodeToString(s.NewVal),
+			OldCodeHash:     hex.EncodeToString(s.OrgVal),
+			Time:            timestamp.Format(common.BASIC_TIME_FORMAT),
+		})
+		return
+	}
+}
+
 func (t *ContractRows) Table() simpleSQLDatabase.ITable {
 	return &Contract
 }
@@ -39,6 +39,7 @@ type ContractCallTable struct {
 	Data            enum.Element `col:"c_data"`
 	Result          enum.Element `col:"c_result"`
 	Value           enum.Element `col:"c_value"`
+	Decoded         enum.Element `col:"c_decoded"`
 	Time            enum.Element `col:"c_time"`
 
 	simpleSQLDatabase.BasicTable
@@ -69,6 +70,7 @@ type ContractCallRow struct {
 	Data            string
 	Result          string
 	Value           string
+	Decoded         string
 	Time            string
 }
 
@@ -77,6 +79,16 @@ type ContractCallRows struct {
 }
 
 func (t *ContractCallRows) Insert(tx smartAssetsLedger.Transaction, blk block.Entity) {
+	t.InsertDecoded(tx, blk, nil)
+}
+
+//InsertDecoded inserts a contract call with its input, outputs and logs decoded by the registered abi
+func (t *ContractCallRows) InsertDecoded(tx smartAssetsLedger.Transaction, blk block.Entity, decoded *smartAssetsLedger.DecodedContractCall) {
+	decodedJson := []byte("")
+	if decoded != nil {
+		decodedJson, _ = json.Marshal(decoded)
+	}
+
 	timestamp := time.Unix(int64(blk.Header.Timestamp), 0)
 	result, _ := json.Marshal(tx.TransactionResult)
 	newAddressRow := ContractCallRow{
@@ -88,6 +100,7 @@ func (t *ContractCallRows) Insert(tx smartAssetsLedger.Transaction, blk block.En
 		Data:            hex.EncodeToString(tx.Data),
 		Result:          string(result),
 		Value:           tx.Value,
+		Decoded:         string(decodedJson),
 		Time:            timestamp.Format(common.BASIC_TIME_FORMAT),
 	}
 
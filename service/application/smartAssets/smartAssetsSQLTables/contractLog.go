@@ -0,0 +1,111 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package smartAssetsSQLTables
+
+import (
+	"github.com/SealSC/SealABC/common"
+	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/service/application/smartAssets/smartAssetsLedger"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type ContractLogTable struct {
+	ID             enum.Element `col:"c_id" ignoreInsert:"true"`
+	Height         enum.Element `col:"c_height"`
+	TxHash         enum.Element `col:"c_tx_hash"`
+	SequenceNumber enum.Element `col:"c_sequence_number"`
+	LogIndex       enum.Element `col:"c_log_index"`
+	Address        enum.Element `col:"c_address"`
+	Topic0         enum.Element `col:"c_topic0"`
+	Topic1         enum.Element `col:"c_topic1"`
+	Topic2         enum.Element `col:"c_topic2"`
+	Topic3         enum.Element `col:"c_topic3"`
+	Data           enum.Element `col:"c_data"`
+	Time           enum.Element `col:"c_time"`
+
+	simpleSQLDatabase.BasicTable
+}
+
+var ContractLog ContractLogTable
+
+func (t ContractLogTable) NewRows() interface{} {
+	return simpleSQLDatabase.NewRowsInstance(ContractLogRows{})
+}
+
+func (t ContractLogTable) Name() (name string) {
+	return "t_smart_assets_contract_log"
+}
+
+func (t *ContractLogTable) load() {
+	enum.SimpleBuild(t)
+	t.Instance = *t
+}
+
+type ContractLogRow struct {
+	ID             string
+	Height         string
+	TxHash         string
+	SequenceNumber string
+	LogIndex       string
+	Address        string
+	Topic0         string
+	Topic1         string
+	Topic2         string
+	Topic3         string
+	Data           string
+	Time           string
+}
+
+type ContractLogRows struct {
+	simpleSQLDatabase.BasicRows
+}
+
+//Insert adds a row for every log of the transaction, log index is counted in the transaction
+func (t *ContractLogRows) Insert(tx smartAssetsLedger.Transaction, blk block.Entity) {
+	timestamp := time.Unix(int64(blk.Header.Timestamp), 0)
+	for i, txLog := range smartAssetsLedger.TransactionLogs(tx) {
+		topics := make([]string, 4)
+		for ti, topic := range txLog.Topics {
+			if ti >= len(topics) {
+				break
+			}
+			topics[ti] = hex.EncodeToString(topic)
+		}
+
+		t.Rows = append(t.Rows, ContractLogRow{
+			Height:         fmt.Sprintf("%d", blk.Header.Height),
+			TxHash:         hex.EncodeToString(tx.DataSeal.Hash),
+			SequenceNumber: fmt.Sprintf("%d", tx.SequenceNumber),
+			LogIndex:       fmt.Sprintf("%d", i),
+			Address:        hex.EncodeToString(txLog.Address),
+			Topic0:         topics[0],
+			Topic1:         topics[1],
+			Topic2:         topics[2],
+			Topic3:         topics[3],
+			Data:           hex.EncodeToString(txLog.Data),
+			Time:           timestamp.Format(common.BASIC_TIME_FORMAT),
+		})
+	}
+}
+
+func (t *ContractLogRows) Table() simpleSQLDatabase.ITable {
+	return &ContractLog
+}
@@ -32,6 +32,7 @@ func Load()  {
 	AddressList.load()
 	ContractCall.load()
 	Contract.load()
+	ContractLog.load()
 	Transaction.load()
 	Transfer.load()
 }
@@ -60,12 +60,22 @@ func (t *TraceableStorageApplication) PushClientRequest(req blockchainRequest.En
 		return
 	}
 
+	_, err = req.VerifySeal(t.tsLedger.CryptoTools.HashCalculator)
+	if err != nil {
+		return
+	}
+
 	tsReq := tsData.TSServiceRequest{}
 	err = json.Unmarshal(req.Data, &tsReq)
 	if err != nil {
 		return
 	}
 
+	err = req.RequireSigner(tsReq.Data.Seal.SignerPublicKey)
+	if err != nil {
+		return
+	}
+
 	err = t.tsLedger.VerifyRequest(tsReq)
 	if err == nil {
 		t.poolLock.RLock()
@@ -153,6 +163,81 @@ func (t *TraceableStorageApplication) Execute (
 	return
 }
 
+func (t *TraceableStorageApplication) Rollback(
+	req blockchainRequest.Entity,
+	_ block.Entity,
+	_ uint32,
+) (err error) {
+	var reqList = RequestList{}
+	err = structSerializer.FromMFBytes(req.Data, &reqList)
+	if err != nil {
+		return
+	}
+
+	for i := len(reqList.Requests) - 1; i >= 0; i-- {
+		tsReq := tsData.TSServiceRequest{}
+		err = json.Unmarshal(reqList.Requests[i].Data, &tsReq)
+		if err != nil {
+			return
+		}
+
+		err = t.tsLedger.RollbackRequest(tsReq)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+//ApplicationInternalCall lets other applications read traceable data or verify, execute and roll back a signed request
+func (t *TraceableStorageApplication) ApplicationInternalCall(_ string, callData []byte) (ret interface{}, err error) {
+	req, err := chainStructure.ParseInternalCall(callData)
+	if err != nil {
+		return
+	}
+
+	if req.Type == chainStructure.InternalCallQuery {
+		return t.tsLedger.GetLocalData(string(req.Data))
+	}
+
+	tsReq := tsData.TSServiceRequest{}
+	err = json.Unmarshal(req.Data, &tsReq)
+	if err != nil {
+		return
+	}
+
+	switch req.Type {
+	case chainStructure.InternalCallVerify:
+		err = t.tsLedger.VerifyRequest(tsReq)
+
+	case chainStructure.InternalCallExecute:
+		err = t.tsLedger.VerifyRequest(tsReq)
+		if err != nil {
+			return
+		}
+		ret, err = t.tsLedger.ExecuteRequest(tsReq)
+
+	case chainStructure.InternalCallRollback:
+		err = t.tsLedger.RollbackRequest(tsReq)
+	}
+	return
+}
+
+func (t *TraceableStorageApplication) UnpackingActionsAsRequests(req blockchainRequest.Entity) (list []blockchainRequest.Entity, err error) {
+	if !req.Packed {
+		return []blockchainRequest.Entity{req}, nil
+	}
+
+	var reqList = RequestList{}
+	err = structSerializer.FromMFBytes(req.Data, &reqList)
+	if err != nil {
+		return
+	}
+
+	return reqList.Requests, nil
+}
+
 func (t *TraceableStorageApplication) Information() (info service.BasicInformation) {
 	info.Name = t.Name()
 	info.Description = "this is an traceableStorage application"
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package tsLedger
+
+import (
+	"errors"
+	"github.com/SealSC/SealABC/metadata/seal"
+	"github.com/SealSC/SealABC/service/application/traceableStorage/tsData"
+)
+
+func (t *TSLedger) rollbackStore(data tsData.TSData) (err error) {
+	return t.Storage.Delete([]byte(data.OnChainID))
+}
+
+func (t *TSLedger) rollbackModify(data tsData.TSData) (err error) {
+	prevData, err := t.GetLocalData(data.PrevOnChainID)
+	if err != nil {
+		return
+	}
+
+	prevData.NextOnChainID = ""
+	prevData.CompleteSeal = seal.Entity{}
+	err = t.Storage.Put(prevData.ToKVStoreItem())
+	if err != nil {
+		return
+	}
+
+	return t.Storage.Delete([]byte(data.OnChainID))
+}
+
+func (t *TSLedger) RollbackRequest(req tsData.TSServiceRequest) (err error) {
+	switch req.ReqType {
+	case tsData.RequestTypes.Store.String():
+		return t.rollbackStore(req.Data)
+
+	case tsData.RequestTypes.Modify.String():
+		return t.rollbackModify(req.Data)
+
+	default:
+		return errors.New("invalid request type")
+	}
+}
@@ -18,6 +18,7 @@
 package uidInterface
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
 	"github.com/SealSC/SealABC/dataStructure/merkleTree"
@@ -26,6 +27,7 @@ import (
 	"github.com/SealSC/SealABC/metadata/blockchainRequest"
 	"github.com/SealSC/SealABC/metadata/seal"
 	"github.com/SealSC/SealABC/service"
+	"github.com/SealSC/SealABC/service/application/universalIdentification/uidData"
 	"github.com/SealSC/SealABC/service/application/universalIdentification/uidLedger"
 	"github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
@@ -52,8 +54,28 @@ func (u *UniversalIdentificationApplication) Name() (name string) {
 	return "Universal Identification"
 }
 
+//verifyClientAction verifies the seal of the action and that it is sealed by the signer of the uid action data
+func (u *UniversalIdentificationApplication) verifyClientAction(req blockchainRequest.Entity) (result interface{}, err error) {
+	_, err = req.VerifySeal(u.ledger.CryptoTools.HashCalculator)
+	if err != nil {
+		return
+	}
+
+	signer, err := u.ledger.ActionSigner(req.Data)
+	if err != nil {
+		return
+	}
+
+	err = req.RequireSigner(signer)
+	if err != nil {
+		return
+	}
+
+	return u.ledger.VerifyAction(req.RequestAction, req.Data)
+}
+
 func (u *UniversalIdentificationApplication) PushClientRequest(req blockchainRequest.Entity) (result interface{}, err error) {
-	result, err = u.ledger.VerifyAction(req.RequestAction, req.Data)
+	result, err = u.verifyClientAction(req)
 
 	if err != nil {
 		return
@@ -90,7 +112,7 @@ func (u *UniversalIdentificationApplication) PreExecute(req blockchainRequest.En
 			continue
 		}
 
-		_, err = u.ledger.VerifyAction(req.RequestAction, req.Data)
+		_, err = u.verifyClientAction(req)
 		if err != nil {
 			return
 		}
@@ -143,21 +165,80 @@ func (u *UniversalIdentificationApplication) Execute(
 		u.poolLock.Unlock()
 	}()
 
-	for _, req := range reqList.Actions {
-		reqKey := string(req.Seal.Hash)
-		if _, exist := u.reqPool[reqKey]; exist {
-			err = errors.New("request not exist")
+	err = u.ledger.ExecuteActionsWithUndo(req.Seal.Hash, func() error {
+		for _, req := range reqList.Actions {
+			reqKey := string(req.Seal.Hash)
+			if _, exist := u.reqPool[reqKey]; exist {
+				return errors.New("request not exist")
+			}
+
+			exeErr := u.ledger.ExecuteAction(req.RequestAction, req.Data)
+			if exeErr != nil {
+				return exeErr
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return
+	}
+
+	u.removeRequestFromPool(reqList.Actions)
+	return
+}
+
+func (u *UniversalIdentificationApplication) Rollback(
+	req blockchainRequest.Entity,
+	_ block.Entity,
+	_ uint32,
+) (err error) {
+	return u.ledger.Rollback(req.Seal.Hash)
+}
+
+//ApplicationInternalCall lets other applications query an uid or verify, execute and roll back a signed uid action
+func (u *UniversalIdentificationApplication) ApplicationInternalCall(_ string, callData []byte) (ret interface{}, err error) {
+	req, err := chainStructure.ParseInternalCall(callData)
+	if err != nil {
+		return
+	}
+
+	switch req.Type {
+	case chainStructure.InternalCallQuery:
+		query := uidData.UIDQuery{}
+		err = json.Unmarshal(req.Data, &query)
+		if err != nil {
 			return
 		}
+		return u.ledger.QueryUID(query)
+
+	case chainStructure.InternalCallVerify:
+		return u.ledger.VerifyAction(req.Action, req.Data)
+
+	case chainStructure.InternalCallExecute:
+		u.poolLock.Lock()
+		defer u.poolLock.Unlock()
 
-		err = u.ledger.ExecuteAction(req.RequestAction, req.Data)
+		_, err = u.ledger.VerifyAction(req.Action, req.Data)
 		if err != nil {
 			return
 		}
+
+		err = u.ledger.ExecuteActionsWithUndo(req.UndoKey, func() error {
+			return u.ledger.ExecuteAction(req.Action, req.Data)
+		})
+		return
+
+	case chainStructure.InternalCallRollback:
+		u.poolLock.Lock()
+		defer u.poolLock.Unlock()
+
+		err = u.ledger.Rollback(req.UndoKey)
+		return
 	}
 
-	u.removeRequestFromPool(reqList.Actions)
-	return
+	return nil, chainStructure.UnsupportedInternalCall(u.Name(), req)
 }
 
 func (u *UniversalIdentificationApplication) RequestsForBlock(_ block.Entity) (reqList []blockchainRequest.Entity, cnt uint32) {
@@ -196,6 +277,20 @@ func (u *UniversalIdentificationApplication) RequestsForBlock(_ block.Entity) (r
 	return []blockchainRequest.Entity{packedReq}, 1
 }
 
+func (u *UniversalIdentificationApplication) UnpackingActionsAsRequests(req blockchainRequest.Entity) (list []blockchainRequest.Entity, err error) {
+	if !req.Packed {
+		return []blockchainRequest.Entity{req}, nil
+	}
+
+	actList := ActionList{}
+	err = structSerializer.FromMFBytes(req.Data, &actList)
+	if err != nil {
+		return
+	}
+
+	return actList.Actions, nil
+}
+
 func (u *UniversalIdentificationApplication) Information() (info service.BasicInformation) {
 	info.Name = u.Name()
 	info.Description = "this is a universal identification application"
@@ -18,9 +18,11 @@
 package uidLedger
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/SealSC/SealABC/crypto"
 	"github.com/SealSC/SealABC/dataStructure/enum"
+	"github.com/SealSC/SealABC/metadata/seal"
 	"github.com/SealSC/SealABC/service/application/universalIdentification/uidData"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
 	"github.com/SealSC/SealABC/storage/db/dbInterface/simpleSQLDatabase"
@@ -32,7 +34,8 @@ func Load()  {
 }
 
 func NewLedger(kvDriver kvDatabase.IDriver, sqlDriver simpleSQLDatabase.IDriver) (ledger UIDLedger) {
-	ledger.KVStorage = kvDriver
+	ledger.journal = kvDatabase.NewJournal(kvDriver)
+	ledger.KVStorage = ledger.journal
 
 	ledger.validators = map[string]actionValidator {
 		uidData.UIDActionTypes.Create.String(): ledger.verifyUIDCreation,
@@ -58,8 +61,12 @@ type UIDLedger struct {
 
 	CryptoTools crypto.Tools
 	KVStorage   kvDatabase.IDriver
+
+	journal *kvDatabase.Journal
 }
 
+const undoKeyPrefix = "uidUndo:"
+
 func (u *UIDLedger) VerifyAction(action string, data []byte) (ret interface{}, err error){
 	if validate, exists := u.validators[action]; exists {
 		return validate(data)
@@ -68,6 +75,20 @@ func (u *UIDLedger) VerifyAction(action string, data []byte) (ret interface{}, e
 	return nil, errors.New("action not supported")
 }
 
+//ActionSigner gets the signer of the action data, every uid action is sealed by its top level Seal
+func (u *UIDLedger) ActionSigner(data []byte) (signer []byte, err error) {
+	sealed := struct {
+		Seal seal.Entity
+	}{}
+
+	err = json.Unmarshal(data, &sealed)
+	if err != nil {
+		return nil, errors.New("invalid action data: " + err.Error())
+	}
+
+	return sealed.Seal.SignerPublicKey, nil
+}
+
 func (u *UIDLedger) ExecuteAction(action string, data []byte) (err error)  {
 	if executor, exists := u.executors[action]; exists {
 		return executor(data)
@@ -80,3 +101,47 @@ func (u *UIDLedger) calcIdentification(pubKey []byte, namespace string) string {
 	rawID := string(pubKey) + namespace
 	return u.CryptoTools.HashCalculator.SumHex([]byte(rawID))
 }
+
+//ExecuteActionsWithUndo executes the actions and saves the original data of the changed uid under the given key
+func (u *UIDLedger) ExecuteActionsWithUndo(undoKey []byte, executeActions func() error) (err error) {
+	u.journal.Begin()
+	err = executeActions()
+	preImages := u.journal.End()
+
+	if err != nil {
+		_ = u.journal.Undo(preImages)
+		return
+	}
+
+	undoData, _ := json.Marshal(preImages)
+	return u.KVStorage.Put(kvDatabase.KVItem{
+		Key:    append([]byte(undoKeyPrefix), undoKey...),
+		Data:   undoData,
+		Exists: true,
+	})
+}
+
+func (u *UIDLedger) Rollback(undoKey []byte) (err error) {
+	key := append([]byte(undoKeyPrefix), undoKey...)
+	kv, err := u.KVStorage.Get(key)
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		return errors.New("no undo record")
+	}
+
+	var preImages []kvDatabase.KVItem
+	err = json.Unmarshal(kv.Data, &preImages)
+	if err != nil {
+		return
+	}
+
+	err = u.journal.Undo(preImages)
+	if err != nil {
+		return
+	}
+
+	return u.KVStorage.Delete(key)
+}
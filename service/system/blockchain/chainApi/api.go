@@ -18,16 +18,24 @@
 package chainApi
 
 import (
+    "github.com/SealSC/SealABC/log"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainApi/ethRPC"
     "github.com/SealSC/SealABC/service/system/blockchain/chainApi/httpJSON"
     "github.com/SealSC/SealABC/service/system/blockchain/chainApi/httpJSON/actions"
     "github.com/SealSC/SealABC/service/system/blockchain/chainSQLStorage"
     "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
     "github.com/SealSC/SealABC/dataStructure/enum"
     "github.com/SealSC/SealABC/service/system/blockchain/chainNetwork"
+    "github.com/SealSC/SealABC/network/http"
 )
 
 type ApiServers struct {
     HttpJSON        *httpJSON.ApiServer
+    EthRPC          *ethRPC.Server
+
+    ethRPCConfig    http.Config
+    chain           *chainStructure.Blockchain
+    p2p             *chainNetwork.P2PService
 }
 
 func Load()  {
@@ -35,9 +43,28 @@ func Load()  {
 }
 
 func NewServer(cfg Config, chain *chainStructure.Blockchain, p2p *chainNetwork.P2PService, sqlStorage *chainSQLStorage.Storage) *ApiServers {
-    api := ApiServers{}
+    api := ApiServers{
+        ethRPCConfig: cfg.EthRPC,
+        chain:        chain,
+        p2p:          p2p,
+    }
 
     api.HttpJSON = httpJSON.NewApiServer(cfg.HttpJSON, chain, p2p, sqlStorage)
 
     return &api
 }
+
+//RegisterEthereumBackend starts the ethereum json-rpc server on the first application able to serve it
+func (a *ApiServers) RegisterEthereumBackend(exe chainStructure.IBlockchainExternalApplication) {
+    if a.EthRPC != nil || a.ethRPCConfig.Address == "" {
+        return
+    }
+
+    backend, ok := exe.(ethRPC.IBackend)
+    if !ok || backend.EthChainID() == 0 {
+        return
+    }
+
+    a.EthRPC = ethRPC.NewServer(a.ethRPCConfig, a.chain, a.p2p, backend)
+    log.Log.Println("ethereum json-rpc server of ", exe.Name(), " started at ", a.ethRPCConfig.Address)
+}
@@ -23,4 +23,7 @@ import (
 
 type Config struct {
     HttpJSON http.Config
+
+    //ethereum compatible json-rpc server, not started when the address is empty
+    EthRPC   http.Config
 }
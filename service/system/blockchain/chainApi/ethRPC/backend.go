@@ -0,0 +1,58 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ethRPC
+
+import (
+    "github.com/SealSC/SealABC/metadata/blockchainRequest"
+    "math/big"
+)
+
+//Transaction is the ethereum view of an application transaction
+type Transaction struct {
+    Hash            []byte
+    From            []byte
+    To              []byte
+    ContractAddress []byte
+}
+
+//IBackend is implemented by the application which serves the ethereum compatible calls
+type IBackend interface {
+    //chain id of the signed ethereum transactions accepted by the application, zero means disabled
+    EthChainID() uint64
+
+    //the lowest gas price accepted
+    EthGasPrice() *big.Int
+
+    //the state queries read the state after the block at height, the current height reads the latest state
+    EthGetBalance(address []byte, height uint64) (balance *big.Int, err error)
+    EthGetCode(address []byte, height uint64) (code []byte, err error)
+
+    //pending counts the transactions of the address waiting in the pool, the height is ignored then
+    EthGetTransactionCount(address []byte, height uint64, pending bool) (count uint64, err error)
+
+    //execute a message on the state after the block at height without changing it
+    EthCall(from []byte, to []byte, data []byte, value *big.Int, height uint64) (ret []byte, gasUsed uint64, err error)
+
+    //build an unsigned request of the application from a signed raw ethereum transaction
+    EthRequestFromRawTransaction(raw []byte, chainID string) (req blockchainRequest.Entity, txHash []byte, err error)
+
+    EthGetTransaction(txHash []byte) (tx Transaction, err error)
+
+    //replay a transaction at its block and return the calls and storage accesses the replay observed,
+    //it is a call level trace, not the instruction level trace of debug_traceTransaction
+    EthTraceTransactionCalls(txHash []byte) (trace interface{}, err error)
+}
@@ -0,0 +1,591 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ethRPC
+
+import (
+    "github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+    "github.com/SealSC/SealABC/log"
+    "github.com/SealSC/SealABC/metadata/block"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+const (
+    clientVersion    = "SealABC"
+    maxLogBlockRange = 1000
+)
+
+const (
+    blockTagLatest   = "latest"
+    blockTagPending  = "pending"
+    blockTagEarliest = "earliest"
+)
+
+type callArgs struct {
+    From  string `json:"from"`
+    To    string `json:"to"`
+    Data  string `json:"data"`
+    Input string `json:"input"`
+    Value string `json:"value"`
+}
+
+type logFilter struct {
+    FromBlock string        `json:"fromBlock"`
+    ToBlock   string        `json:"toBlock"`
+    BlockHash string        `json:"blockHash"`
+    Address   interface{}   `json:"address"`
+    Topics    []interface{} `json:"topics"`
+}
+
+type ethLog struct {
+    Address          string   `json:"address"`
+    Topics           []string `json:"topics"`
+    Data             string   `json:"data"`
+    BlockNumber      string   `json:"blockNumber"`
+    BlockHash        string   `json:"blockHash"`
+    TransactionHash  string   `json:"transactionHash"`
+    TransactionIndex string   `json:"transactionIndex"`
+    LogIndex         string   `json:"logIndex"`
+    Removed          bool     `json:"removed"`
+}
+
+type ethReceipt struct {
+    TransactionHash   string      `json:"transactionHash"`
+    TransactionIndex  string      `json:"transactionIndex"`
+    BlockHash         string      `json:"blockHash"`
+    BlockNumber       string      `json:"blockNumber"`
+    From              interface{} `json:"from"`
+    To                interface{} `json:"to"`
+    ContractAddress   interface{} `json:"contractAddress"`
+    CumulativeGasUsed string      `json:"cumulativeGasUsed"`
+    GasUsed           string      `json:"gasUsed"`
+    Logs              []ethLog    `json:"logs"`
+    LogsBloom         string      `json:"logsBloom"`
+    Status            string      `json:"status"`
+}
+
+//resolveBlockTag returns the block height of a block number or tag, an empty tag means latest
+func (s *Server) resolveBlockTag(tag string) (uint64, error) {
+    switch tag {
+    case "", blockTagLatest, blockTagPending:
+        return s.chain.CurrentHeight(), nil
+
+    case blockTagEarliest:
+        return 0, nil
+    }
+
+    return decodeUint64(tag)
+}
+
+//stateHeight returns the height of the state read by the block tag at idx, the latest state when it is left out,
+//the state of a pruned block can't be read
+func (s *Server) stateHeight(params []json.RawMessage, idx int) (height uint64, pending bool, rpcErr *rpcError) {
+    tag := ""
+    if idx < len(params) {
+        var err error
+        tag, err = stringParam(params, idx)
+        if err != nil {
+            return 0, false, newRPCError(errCodeInvalidParams, err)
+        }
+    }
+
+    height, err := s.resolveBlockTag(tag)
+    if err != nil {
+        return 0, false, newRPCError(errCodeInvalidParams, err)
+    }
+
+    current := s.chain.CurrentHeight()
+    if height > current {
+        return 0, false, invalidParams("block %d is above the current height %d", height, current)
+    }
+
+    err = s.chain.CheckBlockAvailable(height)
+    if err != nil {
+        return 0, false, newRPCError(errCodeServer, err)
+    }
+
+    return height, tag == blockTagPending, nil
+}
+
+func (s *Server) addressAndStateParams(params []json.RawMessage) (addr []byte, height uint64, pending bool, rpcErr *rpcError) {
+    hexAddr, err := stringParam(params, 0)
+    if err != nil {
+        rpcErr = newRPCError(errCodeInvalidParams, err)
+        return
+    }
+
+    addr, err = decodeBytes(hexAddr)
+    if err != nil {
+        rpcErr = invalidParams("invalid address: %s", err.Error())
+        return
+    }
+
+    height, pending, rpcErr = s.stateHeight(params, 1)
+    return
+}
+
+func (s *Server) clientVersion(_ []json.RawMessage) (interface{}, *rpcError) {
+    return clientVersion, nil
+}
+
+func (s *Server) netVersion(_ []json.RawMessage) (interface{}, *rpcError) {
+    return strconv.FormatUint(s.backend.EthChainID(), 10), nil
+}
+
+func (s *Server) chainID(_ []json.RawMessage) (interface{}, *rpcError) {
+    return encodeUint64(s.backend.EthChainID()), nil
+}
+
+func (s *Server) blockNumber(_ []json.RawMessage) (interface{}, *rpcError) {
+    return encodeUint64(s.chain.CurrentHeight()), nil
+}
+
+func (s *Server) gasPrice(_ []json.RawMessage) (interface{}, *rpcError) {
+    return encodeBig(s.backend.EthGasPrice()), nil
+}
+
+func (s *Server) getBalance(params []json.RawMessage) (interface{}, *rpcError) {
+    addr, height, _, rpcErr := s.addressAndStateParams(params)
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+
+    balance, err := s.backend.EthGetBalance(addr, height)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    return encodeBig(balance), nil
+}
+
+func (s *Server) getCode(params []json.RawMessage) (interface{}, *rpcError) {
+    addr, height, _, rpcErr := s.addressAndStateParams(params)
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+
+    code, err := s.backend.EthGetCode(addr, height)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    return encodeBytes(code), nil
+}
+
+func (s *Server) getTransactionCount(params []json.RawMessage) (interface{}, *rpcError) {
+    addr, height, pending, rpcErr := s.addressAndStateParams(params)
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+
+    count, err := s.backend.EthGetTransactionCount(addr, height, pending)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    return encodeUint64(count), nil
+}
+
+func (s *Server) doCall(params []json.RawMessage) (ret []byte, gasUsed uint64, rpcErr *rpcError) {
+    if len(params) == 0 {
+        return nil, 0, invalidParams("missing value for required argument 0")
+    }
+
+    args := callArgs{}
+    err := json.Unmarshal(params[0], &args)
+    if err != nil {
+        return nil, 0, newRPCError(errCodeInvalidParams, err)
+    }
+
+    height, _, rpcErr := s.stateHeight(params, 1)
+    if rpcErr != nil {
+        return
+    }
+
+    from, fromErr := decodeBytes(args.From)
+    to, toErr := decodeBytes(args.To)
+    value, valueErr := decodeBig(args.Value)
+    if args.Input == "" {
+        args.Input = args.Data
+    }
+    data, dataErr := decodeBytes(args.Input)
+
+    for _, e := range []error{fromErr, toErr, valueErr, dataErr} {
+        if e != nil {
+            return nil, 0, newRPCError(errCodeInvalidParams, e)
+        }
+    }
+
+    ret, gasUsed, err = s.backend.EthCall(from, to, data, value, height)
+    if err != nil {
+        rpcErr = newRPCError(errCodeServer, err)
+        if len(ret) != 0 {
+            //the backend may have put the decoded revert reason in the error
+            message := "execution reverted"
+            if strings.HasPrefix(err.Error(), message) {
+                message = err.Error()
+            }
+
+            rpcErr = &rpcError{
+                Code:    errCodeExecution,
+                Message: message,
+                Data:    encodeBytes(ret),
+            }
+        }
+    }
+
+    return
+}
+
+func (s *Server) call(params []json.RawMessage) (interface{}, *rpcError) {
+    ret, _, rpcErr := s.doCall(params)
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+
+    return encodeBytes(ret), nil
+}
+
+func (s *Server) estimateGas(params []json.RawMessage) (interface{}, *rpcError) {
+    _, gasUsed, rpcErr := s.doCall(params)
+    if rpcErr != nil {
+        return nil, rpcErr
+    }
+
+    return encodeUint64(gasUsed), nil
+}
+
+func (s *Server) sendRawTransaction(params []json.RawMessage) (interface{}, *rpcError) {
+    hexRaw, err := stringParam(params, 0)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    raw, err := decodeBytes(hexRaw)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    req, txHash, err := s.backend.EthRequestFromRawTransaction(raw, s.chain.ChainID())
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    //the ethereum signature is verified by the application, the request itself is sealed by this node
+    req.ChainID = s.chain.ChainID()
+    entityBytes, _ := structSerializer.ToMFBytes(req.EntityData)
+    err = req.Seal.Sign(entityBytes, s.chain.Config.CryptoTools, s.chain.Config.Signer.PrivateKeyBytes())
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    _, err = s.chain.Executor.PushRequest(req)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    broadcastErr := s.p2p.BroadcastRequest(req)
+    if broadcastErr != nil {
+        log.Log.Warn("broadcast ethereum transaction failed: ", broadcastErr)
+    }
+
+    return encodeBytes(txHash), nil
+}
+
+//ethTransactionIndexes returns the position of every receipt among the ethereum transactions of the block, which are
+//the actions of the application of the receipt, the actions of other applications in the block are not counted
+func ethTransactionIndexes(receipts []chainStructure.Receipt) (indexes []uint64) {
+    counts := map[string] uint64{}
+    for _, r := range receipts {
+        indexes = append(indexes, counts[r.Application])
+        counts[r.Application] += 1
+    }
+
+    return
+}
+
+func (s *Server) buildLogs(receipt chainStructure.Receipt, blockHash []byte, txIndex uint64, firstIndex uint64) (logs []ethLog) {
+    logs = []ethLog{}
+    for i, l := range receipt.Logs {
+        topics := make([]string, 0, len(l.Topics))
+        for _, t := range l.Topics {
+            topics = append(topics, encodeBytes(t))
+        }
+
+        logs = append(logs, ethLog{
+            Address:          encodeBytes(l.Address),
+            Topics:           topics,
+            Data:             encodeBytes(l.Data),
+            BlockNumber:      encodeUint64(receipt.BlockHeight),
+            BlockHash:        encodeBytes(blockHash),
+            TransactionHash:  encodeBytes(receipt.RequestHash),
+            TransactionIndex: encodeUint64(txIndex),
+            LogIndex:         encodeUint64(firstIndex + uint64(i)),
+        })
+    }
+
+    return
+}
+
+func (s *Server) getTransactionReceipt(params []json.RawMessage) (interface{}, *rpcError) {
+    hexHash, err := stringParam(params, 0)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    hash, err := decodeBytes(hexHash)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    receipt, err := s.chain.GetReceipt(hash)
+    if err != nil {
+        //unknown or pending transaction has no receipt
+        return nil, nil
+    }
+
+    tx, err := s.backend.EthGetTransaction(hash)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    blk, err := s.chain.GetBlockByHeight(receipt.BlockHeight)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    //log index and cumulative gas are counted in the whole block
+    var firstLogIndex uint64
+    var txIndex uint64
+    cumulativeGas := receipt.GasUsed
+    blockReceipts, _ := s.chain.GetReceiptsOfBlock(blk)
+    txIndexes := ethTransactionIndexes(blockReceipts)
+    for i, r := range blockReceipts {
+        if bytes.Equal(r.RequestHash, receipt.RequestHash) {
+            txIndex = txIndexes[i]
+            break
+        }
+        firstLogIndex += uint64(len(r.Logs))
+        cumulativeGas += r.GasUsed
+    }
+
+    status := "0x0"
+    if receipt.Success {
+        status = "0x1"
+    }
+
+    return ethReceipt{
+        TransactionHash:   encodeBytes(hash),
+        TransactionIndex:  encodeUint64(txIndex),
+        BlockHash:         encodeBytes(blk.Seal.Hash),
+        BlockNumber:       encodeUint64(receipt.BlockHeight),
+        From:              encodeAddress(tx.From),
+        To:                encodeAddress(tx.To),
+        ContractAddress:   encodeAddress(tx.ContractAddress),
+        CumulativeGasUsed: encodeUint64(cumulativeGas),
+        GasUsed:           encodeUint64(receipt.GasUsed),
+        Logs:              s.buildLogs(receipt, blk.Seal.Hash, txIndex, firstLogIndex),
+        LogsBloom:         encodeBytes(make([]byte, 256)),
+        Status:            status,
+    }, nil
+}
+
+//traceTransactionCalls returns the call level trace of the backend, there are no opcode steps in it
+func (s *Server) traceTransactionCalls(params []json.RawMessage) (interface{}, *rpcError) {
+    hexHash, err := stringParam(params, 0)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    hash, err := decodeBytes(hexHash)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    trace, err := s.backend.EthTraceTransactionCalls(hash)
+    if err != nil {
+        return nil, newRPCError(errCodeServer, err)
+    }
+
+    return trace, nil
+}
+
+//hexList accepts a single hex string or a list of hex strings, nil means any
+func hexList(v interface{}) (list [][]byte, err error) {
+    switch val := v.(type) {
+    case nil:
+        return nil, nil
+
+    case string:
+        b, decErr := decodeBytes(val)
+        if decErr != nil {
+            return nil, decErr
+        }
+        return [][]byte{b}, nil
+
+    case []interface{}:
+        for _, el := range val {
+            str, ok := el.(string)
+            if !ok {
+                return nil, errors.New("hex string expected")
+            }
+
+            b, decErr := decodeBytes(str)
+            if decErr != nil {
+                return nil, decErr
+            }
+            list = append(list, b)
+        }
+        return
+    }
+
+    return nil, errors.New("hex string or list expected")
+}
+
+func matchAny(val []byte, candidates [][]byte) bool {
+    if len(candidates) == 0 {
+        return true
+    }
+
+    for _, c := range candidates {
+        if bytes.Equal(val, c) {
+            return true
+        }
+    }
+
+    return false
+}
+
+func matchLog(l chainStructure.ReceiptLog, addresses [][]byte, topics [][][]byte) bool {
+    if !matchAny(l.Address, addresses) {
+        return false
+    }
+
+    if len(topics) > len(l.Topics) {
+        return false
+    }
+
+    for i, t := range topics {
+        if !matchAny(l.Topics[i], t) {
+            return false
+        }
+    }
+
+    return true
+}
+
+func (s *Server) getLogs(params []json.RawMessage) (interface{}, *rpcError) {
+    if len(params) == 0 {
+        return nil, invalidParams("missing value for required argument 0")
+    }
+
+    filter := logFilter{}
+    err := json.Unmarshal(params[0], &filter)
+    if err != nil {
+        return nil, newRPCError(errCodeInvalidParams, err)
+    }
+
+    addresses, err := hexList(filter.Address)
+    if err != nil {
+        return nil, invalidParams("invalid address: %s", err.Error())
+    }
+
+    var topics [][][]byte
+    for _, t := range filter.Topics {
+        topicList, topicErr := hexList(t)
+        if topicErr != nil {
+            return nil, invalidParams("invalid topic: %s", topicErr.Error())
+        }
+        topics = append(topics, topicList)
+    }
+
+    var blocks []block.Entity
+    if filter.BlockHash != "" {
+        hash, decErr := decodeBytes(filter.BlockHash)
+        if decErr != nil {
+            return nil, newRPCError(errCodeInvalidParams, decErr)
+        }
+
+        row, getErr := s.chain.GetBlockRowByHash(fmt.Sprintf("%x", hash))
+        if getErr != nil {
+            return nil, newRPCError(errCodeServer, getErr)
+        }
+
+        height, parseErr := strconv.ParseUint(row.Height, 10, 64)
+        if parseErr != nil {
+            return nil, newRPCError(errCodeServer, parseErr)
+        }
+
+        blk, getErr := s.chain.GetBlockByHeight(height)
+        if getErr != nil {
+            return nil, newRPCError(errCodeServer, getErr)
+        }
+        blocks = append(blocks, blk)
+    } else {
+        from, fromErr := s.resolveBlockTag(filter.FromBlock)
+        to, toErr := s.resolveBlockTag(filter.ToBlock)
+        if fromErr != nil || toErr != nil {
+            return nil, invalidParams("invalid block range")
+        }
+
+        if to > s.chain.CurrentHeight() {
+            to = s.chain.CurrentHeight()
+        }
+
+        if from > to {
+            return []ethLog{}, nil
+        }
+
+        if to - from >= maxLogBlockRange {
+            return nil, newRPCError(errCodeServer, fmt.Errorf("block range is larger than %d", maxLogBlockRange))
+        }
+
+        for h := from; h <= to; h++ {
+            blk, getErr := s.chain.GetBlockByHeight(h)
+            if getErr != nil {
+                return nil, newRPCError(errCodeServer, getErr)
+            }
+            blocks = append(blocks, blk)
+        }
+    }
+
+    result := []ethLog{}
+    for _, blk := range blocks {
+        receipts, getErr := s.chain.GetReceiptsOfBlock(blk)
+        if getErr != nil {
+            return nil, newRPCError(errCodeServer, getErr)
+        }
+
+        var logIndex uint64
+        txIndexes := ethTransactionIndexes(receipts)
+        for idx, r := range receipts {
+            for i, l := range s.buildLogs(r, blk.Seal.Hash, txIndexes[idx], logIndex) {
+                if matchLog(r.Logs[i], addresses, topics) {
+                    result = append(result, l)
+                }
+            }
+            logIndex += uint64(len(r.Logs))
+        }
+    }
+
+    return result, nil
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ethRPC
+
+import (
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math/big"
+    "strconv"
+    "strings"
+)
+
+const jsonRPCVersion = "2.0"
+
+const (
+    errCodeParse          = -32700
+    errCodeInvalidRequest = -32600
+    errCodeMethodNotFound = -32601
+    errCodeInvalidParams  = -32602
+    errCodeServer         = -32000
+    errCodeExecution      = 3
+)
+
+type rpcRequest struct {
+    JsonRPC string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id"`
+    Method  string          `json:"method"`
+    Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+    Code    int         `json:"code"`
+    Message string      `json:"message"`
+    Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+    JsonRPC string          `json:"jsonrpc"`
+    ID      json.RawMessage `json:"id"`
+    Result  interface{}     `json:"result,omitempty"`
+    Error   *rpcError       `json:"error,omitempty"`
+}
+
+func newRPCError(code int, err error) *rpcError {
+    return &rpcError{
+        Code:    code,
+        Message: err.Error(),
+    }
+}
+
+func invalidParams(format string, args ...interface{}) *rpcError {
+    return &rpcError{
+        Code:    errCodeInvalidParams,
+        Message: fmt.Sprintf(format, args...),
+    }
+}
+
+func encodeUint64(v uint64) string {
+    return "0x" + strconv.FormatUint(v, 16)
+}
+
+func encodeBig(v *big.Int) string {
+    if v == nil {
+        return "0x0"
+    }
+
+    return "0x" + v.Text(16)
+}
+
+func encodeBytes(b []byte) string {
+    return "0x" + hex.EncodeToString(b)
+}
+
+func encodeAddress(b []byte) interface{} {
+    if len(b) == 0 {
+        return nil
+    }
+
+    return encodeBytes(b)
+}
+
+func trimHexPrefix(s string) string {
+    if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+        return s[2:]
+    }
+
+    return s
+}
+
+func decodeBytes(s string) ([]byte, error) {
+    s = trimHexPrefix(s)
+    if len(s) % 2 == 1 {
+        s = "0" + s
+    }
+
+    return hex.DecodeString(s)
+}
+
+func decodeUint64(s string) (uint64, error) {
+    s = trimHexPrefix(s)
+    if s == "" {
+        return 0, errors.New("empty hex number")
+    }
+
+    return strconv.ParseUint(s, 16, 64)
+}
+
+func decodeBig(s string) (*big.Int, error) {
+    s = trimHexPrefix(s)
+    if s == "" {
+        return big.NewInt(0), nil
+    }
+
+    v, ok := big.NewInt(0).SetString(s, 16)
+    if !ok {
+        return nil, errors.New("invalid hex number " + s)
+    }
+
+    return v, nil
+}
+
+//parseParams splits the positional parameters of a request
+func parseParams(raw json.RawMessage) (params []json.RawMessage, err error) {
+    if len(raw) == 0 || string(raw) == "null" {
+        return
+    }
+
+    err = json.Unmarshal(raw, &params)
+    return
+}
+
+func stringParam(params []json.RawMessage, idx int) (s string, err error) {
+    if idx >= len(params) {
+        return "", fmt.Errorf("missing value for required argument %d", idx)
+    }
+
+    err = json.Unmarshal(params[idx], &s)
+    return
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package ethRPC
+
+import (
+    "github.com/SealSC/SealABC/network/http"
+    "github.com/SealSC/SealABC/service"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainNetwork"
+    "github.com/SealSC/SealABC/service/system/blockchain/chainStructure"
+    "github.com/gin-gonic/gin"
+    "bytes"
+    "encoding/json"
+    "errors"
+    "io/ioutil"
+    netHttp "net/http"
+)
+
+type rpcMethod func(params []json.RawMessage) (result interface{}, err *rpcError)
+
+type Server struct {
+    server   http.Server
+    basePath string
+    chain    *chainStructure.Blockchain
+    p2p      *chainNetwork.P2PService
+    backend  IBackend
+    methods  map[string] rpcMethod
+}
+
+func (s *Server) Address() string {
+    return s.server.Config.Address
+}
+
+func (s *Server) dispatch(req rpcRequest) (res rpcResponse) {
+    res.JsonRPC = jsonRPCVersion
+    res.ID = req.ID
+    if res.ID == nil {
+        res.ID = json.RawMessage("null")
+    }
+
+    if req.JsonRPC != jsonRPCVersion || req.Method == "" {
+        res.Error = newRPCError(errCodeInvalidRequest, errors.New("invalid request"))
+        return
+    }
+
+    method, exists := s.methods[req.Method]
+    if !exists {
+        res.Error = newRPCError(errCodeMethodNotFound, errors.New("the method " + req.Method + " does not exist/is not available"))
+        return
+    }
+
+    params, err := parseParams(req.Params)
+    if err != nil {
+        res.Error = newRPCError(errCodeInvalidParams, err)
+        return
+    }
+
+    res.Result, res.Error = method(params)
+    if res.Error == nil && res.Result == nil {
+        //a null result must still be in the response
+        res.Result = json.RawMessage("null")
+    }
+
+    return
+}
+
+func (s *Server) Handle(ctx *gin.Context) {
+    body, err := ioutil.ReadAll(ctx.Request.Body)
+    if err != nil {
+        ctx.JSON(netHttp.StatusOK, rpcResponse{
+            JsonRPC: jsonRPCVersion,
+            ID:      json.RawMessage("null"),
+            Error:   newRPCError(errCodeParse, err),
+        })
+        return
+    }
+
+    body = bytes.TrimSpace(body)
+    if len(body) > 0 && body[0] == '[' {
+        var reqList []rpcRequest
+        err = json.Unmarshal(body, &reqList)
+        if err == nil && len(reqList) > 0 {
+            resList := make([]rpcResponse, 0, len(reqList))
+            for _, req := range reqList {
+                resList = append(resList, s.dispatch(req))
+            }
+
+            ctx.JSON(netHttp.StatusOK, resList)
+            return
+        }
+    } else {
+        req := rpcRequest{}
+        err = json.Unmarshal(body, &req)
+        if err == nil {
+            ctx.JSON(netHttp.StatusOK, s.dispatch(req))
+            return
+        }
+    }
+
+    if err == nil {
+        err = errors.New("empty batch request")
+    }
+
+    ctx.JSON(netHttp.StatusOK, rpcResponse{
+        JsonRPC: jsonRPCVersion,
+        ID:      json.RawMessage("null"),
+        Error:   newRPCError(errCodeParse, err),
+    })
+}
+
+func (s *Server) RouteRegister(router gin.IRouter) {
+    router.POST(s.basePath + "/", s.Handle)
+}
+
+func (s *Server) BasicInformation() (info http.HandlerBasicInformation) {
+    info.Description = "ethereum compatible json-rpc 2.0 endpoint of the smart contract application."
+    info.Path = s.basePath + "/"
+    info.Method = service.ApiProtocolMethod.HttpPost.String()
+
+    info.Parameters.Type = service.ApiParameterType.JSON.String()
+    info.Parameters.Template = rpcRequest{
+        JsonRPC: jsonRPCVersion,
+        ID:      json.RawMessage("1"),
+        Method:  "eth_blockNumber",
+        Params:  json.RawMessage("[]"),
+    }
+    return
+}
+
+func NewServer(cfg http.Config,
+    chain *chainStructure.Blockchain,
+    p2p *chainNetwork.P2PService,
+    backend IBackend) *Server {
+
+    s := &Server{
+        server:   http.Server{
+            Config: &cfg,
+        },
+        basePath: cfg.BasePath,
+        chain:    chain,
+        p2p:      p2p,
+        backend:  backend,
+    }
+
+    s.methods = map[string] rpcMethod {
+        "web3_clientVersion":            s.clientVersion,
+        "net_version":                   s.netVersion,
+        "eth_chainId":                   s.chainID,
+        "eth_blockNumber":               s.blockNumber,
+        "eth_gasPrice":                  s.gasPrice,
+        "eth_getBalance":                s.getBalance,
+        "eth_getCode":                   s.getCode,
+        "eth_getTransactionCount":       s.getTransactionCount,
+        "eth_call":                      s.call,
+        "eth_estimateGas":               s.estimateGas,
+        "eth_sendRawTransaction":        s.sendRawTransaction,
+        "eth_getTransactionReceipt":     s.getTransactionReceipt,
+        "eth_getLogs":                   s.getLogs,
+        "sealabc_traceTransactionCalls": s.traceTransactionCalls,
+    }
+
+    s.server.Config.AllowCORS = true
+    s.server.Config.RequestHandler = []http.IRequestHandler{s}
+
+    _ = s.server.Start()
+
+    return s
+}
@@ -119,6 +119,7 @@ func NewActions(basePath string, chain *chainStructure.Blockchain, p2p *chainNet
         &callApplication{},
         &getBlockByHash{},
         &getBlockByHeight{},
+        &getReceipt{},
         &getTransactions{},
         &queryApplication{},
     }
@@ -32,7 +32,7 @@ func (g *getBlockByHash)Handle(ctx *gin.Context) {
     hash := ctx.Param(URLParameterKeys.HexHash.String())
     blk, err := g.chain.GetBlockRowByHash(hash)
     if err != nil {
-        res.BadRequest(err.Error())
+        res.NotFoundRequest(err.Error())
         return
     }
 
@@ -40,6 +40,7 @@ func (g *getBlockByHeight)Handle(ctx *gin.Context) {
 
     blk, err := g.chain.GetBlockRowByHeight(height)
     if err != nil {
+        res.NotFoundRequest(err.Error())
         return
     }
 
@@ -18,6 +18,7 @@
 package chainNetwork
 
 import (
+    "errors"
     "sync"
     "github.com/SealSC/SealABC/network"
     "time"
@@ -27,6 +28,14 @@ import (
 var syncBlockWait sync.WaitGroup
 var Syncing = false
 
+//pendingSync is the block sync request waiting for its reply, only the reply of this request ends the wait
+var pendingSync struct {
+    lock    sync.Mutex
+    node    string
+    height  uint64
+    waiting bool
+}
+
 func (p *P2PService) StartSync(nodes []network.Node, targetHeight uint64) {
     if Syncing {
         return
@@ -49,11 +58,10 @@ func (p *P2PService) StartSync(nodes []network.Node, targetHeight uint64) {
         var syncErr error = nil
         seedIdx := 0
         for i := 0; i< seedsCnt; i++ {
-            seedIdx = (int(s) + 1) % seedsCnt
-            syncErr = p.syncBlockFrom(nodes[seedIdx], s + 1)
+            seedIdx = (int(s) + 1 + i) % seedsCnt
+            syncErr = p.requestBlock(nodes[seedIdx], s + 1)
 
             if syncErr == nil {
-                syncBlockWait.Add(1)
                 break
             }
         }
@@ -72,8 +80,42 @@ func (p *P2PService) StartSync(nodes []network.Node, targetHeight uint64) {
 }
 
 func (p *P2PService) syncBlockFrom(node network.Node, height uint64) (err error) {
-    reqMsg := newSyncBlockMessage(height)
+    if p.isRefusedPeer(node) {
+        err = errors.New("peer " + node.ServeAddress + " is refused")
+        return
+    }
+
+    reqMsg := newSyncBlockMessage(height, p.chain.GenesisHash())
     p.NetworkService.SendTo(node, reqMsg)
     return
 }
 
+//requestBlock sends the sync request after it is registered, so a fast reply can't arrive before the wait
+func (p *P2PService) requestBlock(node network.Node, height uint64) (err error) {
+    pendingSync.lock.Lock()
+    pendingSync.node = node.ServeAddress
+    pendingSync.height = height
+    pendingSync.waiting = true
+    syncBlockWait.Add(1)
+    pendingSync.lock.Unlock()
+
+    err = p.syncBlockFrom(node, height)
+    if err != nil {
+        finishSyncRequest(node.ServeAddress, height)
+    }
+    return
+}
+
+//finishSyncRequest ends the wait if the reply answers the pending request, replies nobody waits for are dropped
+func finishSyncRequest(from string, height uint64) bool {
+    pendingSync.lock.Lock()
+    defer pendingSync.lock.Unlock()
+
+    if !pendingSync.waiting || pendingSync.node != from || pendingSync.height != height {
+        return false
+    }
+
+    pendingSync.waiting = false
+    syncBlockWait.Done()
+    return true
+}
@@ -31,6 +31,9 @@ type P2PService struct{
     chain                   *chainStructure.Blockchain
     networkMessageHandler map[string] p2pMessageHandler
 
+    peersLock               sync.RWMutex
+    refusedPeers            map[string] bool
+
     //export
     NetworkService        network.IService
 }
@@ -69,6 +72,7 @@ func Load() {
 
 func NewNetwork(cfg network.Config, chain *chainStructure.Blockchain) (*P2PService) {
     p2p := P2PService{}
+    p2p.refusedPeers = map[string] bool{}
     p2p.networkMessageHandler = map[string] p2pMessageHandler {
         MessageTypes.PushRequest.String():    p2p.handlePushRequest,
         MessageTypes.SyncBlock.String():      p2p.handleSyncBlock,
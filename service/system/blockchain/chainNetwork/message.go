@@ -27,7 +27,7 @@ import (
 )
 
 const messageFamily = "seal-chain-message"
-const messageVersion = "0.1"
+const messageVersion = "0.2"
 
 var MessageTypes struct{
     PushRequest     enum.Element
@@ -35,15 +35,38 @@ var MessageTypes struct{
     SyncBlockReply  enum.Element
 }
 
+//every chain message carries the genesis hash of the sender, peers on another chain are refused
+type chainMessageHeader struct {
+    GenesisHash []byte
+}
+
 type syncBlockReplyMessage struct {
+    chainMessageHeader
     Success bool
     Block   block.Entity
 }
 
 type syncBlockMessage struct {
+    chainMessageHeader
     BlockHeight uint64
 }
 
+type pushRequestMessage struct {
+    chainMessageHeader
+    Request blockchainRequest.Entity
+}
+
+func getGenesisHashFromMessage(msg message.Message) (hash []byte, err error) {
+    header := chainMessageHeader{}
+    err = json.Unmarshal(msg.Payload, &header)
+    if err != nil {
+        return
+    }
+
+    hash = header.GenesisHash
+    return
+}
+
 func getBlockFromSyncReplyMessage(msg message.Message) (blk *block.Entity, err error) {
     replyMsg := syncBlockReplyMessage{}
     err = json.Unmarshal(msg.Payload, &replyMsg)
@@ -60,6 +83,17 @@ func getBlockFromSyncReplyMessage(msg message.Message) (blk *block.Entity, err e
     return
 }
 
+func getHeightFromSyncReplyMessage(msg message.Message) (height uint64, err error) {
+    replyMsg := syncBlockReplyMessage{}
+    err = json.Unmarshal(msg.Payload, &replyMsg)
+    if err != nil {
+        return
+    }
+
+    height = replyMsg.Block.Header.Height
+    return
+}
+
 func getHeightFromSyncMessage(msg message.Message) (height uint64, err error) {
     syncBlkMsg := syncBlockMessage{}
     err = json.Unmarshal(msg.Payload, &syncBlkMsg)
@@ -72,26 +106,30 @@ func getHeightFromSyncMessage(msg message.Message) (height uint64, err error) {
 }
 
 func getBlockchainRequestFromPushRequestMessage(msg message.Message) (req blockchainRequest.Entity, err error) {
-    err = json.Unmarshal(msg.Payload, &req)
+    pushMsg := pushRequestMessage{}
+    err = json.Unmarshal(msg.Payload, &pushMsg)
     if err != nil {
         return
     }
 
+    req = pushMsg.Request
     return
 }
 
-func newSyncBlockMessage(height uint64) (msg message.Message) {
+func newSyncBlockMessage(height uint64, genesisHash []byte) (msg message.Message) {
     syncMsg := syncBlockMessage{
         BlockHeight: height,
     }
+    syncMsg.GenesisHash = genesisHash
 
     payload, _ := json.Marshal(syncMsg)
     msg = newMessage(MessageTypes.SyncBlock, payload)
     return
 }
 
-func newSyncBlockReplyMessage(blk *block.Entity, height uint64) (msg message.Message) {
+func newSyncBlockReplyMessage(blk *block.Entity, height uint64, genesisHash []byte) (msg message.Message) {
     replyMsg := syncBlockReplyMessage{}
+    replyMsg.GenesisHash = genesisHash
 
     if blk == nil {
         replyMsg.Success = false
@@ -107,8 +145,13 @@ func newSyncBlockReplyMessage(blk *block.Entity, height uint64) (msg message.Mes
     return
 }
 
-func NewPushRequest(req blockchainRequest.Entity) (msg message.Message, err error) {
-    payload, err := json.Marshal(req)
+func NewPushRequest(req blockchainRequest.Entity, genesisHash []byte) (msg message.Message, err error) {
+    pushMsg := pushRequestMessage{
+        Request: req,
+    }
+    pushMsg.GenesisHash = genesisHash
+
+    payload, err := json.Marshal(pushMsg)
     if err != nil {
         return
     }
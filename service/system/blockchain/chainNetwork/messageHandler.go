@@ -18,6 +18,8 @@
 package chainNetwork
 
 import (
+    "bytes"
+    "encoding/hex"
     "github.com/SealSC/SealABC/network"
     "github.com/SealSC/SealABC/log"
     "github.com/SealSC/SealABC/metadata/blockchainRequest"
@@ -43,7 +45,7 @@ func (p *P2PService)handleSyncBlock(msg network.Message) (reply *network.Message
     height, err := getHeightFromSyncMessage(msg.Message)
     if err != nil {
         log.Log.Error(err.Error())
-        replyMsg := newSyncBlockReplyMessage(nil, height)
+        replyMsg := newSyncBlockReplyMessage(nil, height, p.chain.GenesisHash())
         reply = &network.Message{
             Message: replyMsg,
         }
@@ -51,16 +53,23 @@ func (p *P2PService)handleSyncBlock(msg network.Message) (reply *network.Message
         return reply
     }
     blk, err := p.chain.GetBlockByHeight(height)
+    if err == nil {
+        //pruned block can't be used to sync
+        err = p.chain.CheckBlockBodyAvailable(height)
+    }
+
     if err != nil {
-        log.Log.Error("get block@", height, " failed")
-        replyMsg := newSyncBlockReplyMessage(nil, height)
+        log.Log.Error("get block@", height, " failed: ", err.Error())
+        replyMsg := newSyncBlockReplyMessage(nil, height, p.chain.GenesisHash())
         reply = &network.Message{
             Message: replyMsg,
         }
+
+        return reply
     }
 
     log.Log.Warn("block@", height, " sync to remote: ", msg.From.ServeAddress)
-    replyMsg := newSyncBlockReplyMessage(&blk, height)
+    replyMsg := newSyncBlockReplyMessage(&blk, height, p.chain.GenesisHash())
     reply = &network.Message{
         Message: replyMsg,
     }
@@ -75,19 +84,87 @@ func  (p *P2PService)handleSyncBlockReply(msg network.Message) (_ *network.Messa
         }
     }()
 
+    height, err := getHeightFromSyncReplyMessage(msg.Message)
+    if err != nil {
+        log.Log.Warn("drop invalid sync reply from ", msg.From.ServeAddress, ": ", err.Error())
+        return
+    }
+
+    pendingSync.lock.Lock()
+    requested := pendingSync.waiting && pendingSync.node == msg.From.ServeAddress && pendingSync.height == height
+    pendingSync.lock.Unlock()
+
+    if !requested {
+        log.Log.Warn("drop unrequested block@", height, " from ", msg.From.ServeAddress)
+        return
+    }
+    defer finishSyncRequest(msg.From.ServeAddress, height)
+
     blk, err := getBlockFromSyncReplyMessage(msg.Message)
+    if err == nil {
+        _, err = blk.Verify(p.chain.Config.CryptoTools)
+    }
+
+    if err == nil {
+        err = p.chain.VerifyBlockSigner(*blk)
+    }
+
     if err != nil {
         log.Log.Error("get block failed: ", err.Error())
     } else {
         p.chain.AddBlock(*blk)
     }
 
-    //todo: call block sync module's method, not call syncBlockWait directly
-    syncBlockWait.Done()
     return
 }
 
+func (p *P2PService) isRefusedPeer(node network.Node) bool {
+    p.peersLock.RLock()
+    defer p.peersLock.RUnlock()
+
+    return p.refusedPeers[node.ServeAddress]
+}
+
+//checkPeerGenesis refuses the sender if it runs a chain with another genesis, nodes without genesis file check nothing
+func (p *P2PService) checkPeerGenesis(msg network.Message) bool {
+    if p.isRefusedPeer(msg.From) {
+        return false
+    }
+
+    localHash := p.chain.GenesisHash()
+    if len(localHash) == 0 {
+        return true
+    }
+
+    remoteHash, err := getGenesisHashFromMessage(msg.Message)
+    if err == nil && bytes.Equal(localHash, remoteHash) {
+        return true
+    }
+
+    log.Log.Warn("refuse peer ", msg.From.ServeAddress, " with different genesis: ", hex.EncodeToString(remoteHash))
+
+    p.peersLock.Lock()
+    p.refusedPeers[msg.From.ServeAddress] = true
+    p.peersLock.Unlock()
+    return false
+}
+
 func (p *P2PService)handleP2PMessage(msg network.Message) (reply *network.Message) {
+    if msg.Version != messageVersion {
+        log.Log.Warn("drop chain message with unsupported version ", msg.Version, " from ", msg.From.ServeAddress)
+        return
+    }
+
+    if !p.checkPeerGenesis(msg) {
+        //keep block sync going when the refused peer is the one we are waiting for
+        if msg.Type == MessageTypes.SyncBlockReply.String() {
+            if height, err := getHeightFromSyncReplyMessage(msg.Message); err == nil {
+                finishSyncRequest(msg.From.ServeAddress, height)
+            }
+        }
+        return
+    }
+
     if h, exists := p.networkMessageHandler[msg.Type]; exists {
         return h(msg)
     }
@@ -95,7 +172,7 @@ func (p *P2PService)handleP2PMessage(msg network.Message) (reply *network.Messag
 }
 
 func (p *P2PService) BroadcastRequest(req blockchainRequest.Entity) (err error) {
-    msg, err := NewPushRequest(req)
+    msg, err := NewPushRequest(req, p.chain.GenesisHash())
     if err != nil {
         return
     }
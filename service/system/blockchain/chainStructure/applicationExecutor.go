@@ -23,6 +23,7 @@ import (
     "github.com/SealSC/SealABC/metadata/blockchainRequest"
     "github.com/SealSC/SealABC/service"
     "errors"
+    "fmt"
     "sync"
 )
 
@@ -30,6 +31,7 @@ type applicationExecutor struct {
     ExternalExecutors   map[string]IBlockchainExternalApplication
 
     externalExeLock     sync.RWMutex
+    chain               *Blockchain
 }
 
 type IBlockchainExternalApplication interface {
@@ -51,6 +53,15 @@ type IBlockchainExternalApplication interface {
     //handle consensus failed
     Cancel(req blockchainRequest.Entity) (err error)
 
+    //undo the state changes made by an executed request, called in reverse execute order when rolling back blocks
+    Rollback(req blockchainRequest.Entity, header block.Entity, actIndex uint32) (err error)
+
+    //build receipts for the actions of an executed request, return nothing to use the default receipts
+    BuildReceipts(req blockchainRequest.Entity, result applicationResult.Entity) (receipts []Receipt, err error)
+
+    //initialize application state from its config in the genesis file
+    ApplyGenesis(config []byte, genesisBlock block.Entity) (err error)
+
     //build request list for new block
     RequestsForBlock(block block.Entity) (entity []blockchainRequest.Entity, cnt uint32)
 
@@ -73,6 +84,9 @@ func (BlankApplication) Query(req []byte) (result interface{}, err error) {retur
 func (BlankApplication) PreExecute(req blockchainRequest.Entity, header block.Entity) (result []byte, err error) {return }
 func (BlankApplication) Execute(req blockchainRequest.Entity, header block.Entity, actIndex uint32) (result applicationResult.Entity, err error) {return }
 func (BlankApplication) Cancel(req blockchainRequest.Entity) (err error) {return }
+func (BlankApplication) Rollback(req blockchainRequest.Entity, header block.Entity, actIndex uint32) (err error) {return }
+func (BlankApplication) BuildReceipts(req blockchainRequest.Entity, result applicationResult.Entity) (receipts []Receipt, err error) {return }
+func (BlankApplication) ApplyGenesis(config []byte, genesisBlock block.Entity) (err error) {return }
 func (BlankApplication) RequestsForBlock(block block.Entity) (entity []blockchainRequest.Entity, cnt uint32) {return }
 func (BlankApplication) ApplicationInternalCall(src string, callData []byte) (ret interface{}, err error) {return }
 func (BlankApplication) Information() (info service.BasicInformation) {return }
@@ -107,6 +121,51 @@ func (a *applicationExecutor) RegisterApplicationExecutor(s IBlockchainExternalA
     return
 }
 
+//verifyReplayProtection checks an action is made for this chain, not expired and never executed before
+func (a *applicationExecutor) verifyReplayProtection(act blockchainRequest.Entity, height uint64) (err error) {
+    if act.ChainID != a.chain.ChainID() {
+        return fmt.Errorf("request %x is not for chain [%s]", act.Seal.Hash, a.chain.ChainID())
+    }
+
+    if act.ExpiryHeight != 0 && height > act.ExpiryHeight {
+        return fmt.Errorf("request %x expired at height %d", act.Seal.Hash, act.ExpiryHeight)
+    }
+
+    if a.chain.IsRequestExecuted(act.Seal.Hash) {
+        return fmt.Errorf("request %x has been executed", act.Seal.Hash)
+    }
+
+    return
+}
+
+//verifyRequest verifies the request seal and replay protection of a request from client.
+//packed requests are built by block proposer, the application verifies seals of the actions in it,
+//so only replay protection of each action is checked here.
+func (a *applicationExecutor) verifyRequest(exe IBlockchainExternalApplication, req blockchainRequest.Entity, height uint64) (err error) {
+    if !req.Packed {
+        _, err = req.VerifySeal(a.chain.Config.CryptoTools.HashCalculator)
+        if err != nil {
+            return
+        }
+
+        return a.verifyReplayProtection(req, height)
+    }
+
+    actions, err := exe.UnpackingActionsAsRequests(req)
+    if err != nil {
+        return
+    }
+
+    for _, act := range actions {
+        err = a.verifyReplayProtection(act, height)
+        if err != nil {
+            return
+        }
+    }
+
+    return
+}
+
 func (a *applicationExecutor)PreExecute(act blockchainRequest.Entity, blk block.Entity) (result []byte, err error) {
     a.externalExeLock.RLock()
     defer a.externalExeLock.RUnlock()
@@ -116,9 +175,36 @@ func (a *applicationExecutor)PreExecute(act blockchainRequest.Entity, blk block.
         return
     }
 
+    err = a.verifyRequest(exe, act, blk.Header.Height)
+    if err != nil {
+        return
+    }
+
     return exe.PreExecute(act, blk)
 }
 
+//VerifyRequestsOfBlock checks no action appears twice in the block, the executed index is written when the block
+//is committed, so verifyReplayProtection can't find the copies of an action in the same block
+func (a *applicationExecutor)VerifyRequestsOfBlock(blk block.Entity) (err error) {
+    a.externalExeLock.RLock()
+    defer a.externalExeLock.RUnlock()
+
+    hashes, err := a.chain.actionHashesOfBlock(blk)
+    if err != nil {
+        return
+    }
+
+    seen := map[string]bool{}
+    for _, h := range hashes {
+        if seen[string(h)] {
+            return fmt.Errorf("request %x appears more than once in the block", h)
+        }
+        seen[string(h)] = true
+    }
+
+    return
+}
+
 func (a *applicationExecutor)ExecuteRequest(req blockchainRequest.Entity, blk block.Entity, actIndex uint32) (result applicationResult.Entity, err error) {
     a.externalExeLock.RLock()
     defer a.externalExeLock.RUnlock()
@@ -131,22 +217,61 @@ func (a *applicationExecutor)ExecuteRequest(req blockchainRequest.Entity, blk bl
     return exe.Execute(req, blk, actIndex)
 }
 
+func (a *applicationExecutor)RollbackRequest(req blockchainRequest.Entity, blk block.Entity, actIndex uint32) (err error) {
+    a.externalExeLock.RLock()
+    defer a.externalExeLock.RUnlock()
+
+    exe, err := a.getExternalExecutor(req.RequestApplication)
+    if err != nil {
+        return
+    }
+
+    return exe.Rollback(req, blk, actIndex)
+}
+
 func (a *applicationExecutor)GetRequestListToBuildBlock(block block.Entity) (reqList []blockchainRequest.Entity) {
     a.externalExeLock.RLock()
     defer a.externalExeLock.RUnlock()
 
+    seen := map[string]bool{}
     for _, exe := range a.ExternalExecutors {
         req, cnt := exe.RequestsForBlock(block)
         if cnt == 0 {
             continue
         }
 
-        reqList = append(reqList, req...)
+        for _, r := range req {
+            //expired or executed client requests would make the block invalid
+            if !r.Packed && a.verifyReplayProtection(r, block.Header.Height) != nil {
+                continue
+            }
+
+            //so would an action already in the block
+            actions, err := a.chain.actionsOfRequest(r)
+            if err != nil || hasSeenAction(seen, actions) {
+                continue
+            }
+
+            for _, act := range actions {
+                seen[string(act.Seal.Hash)] = true
+            }
+            reqList = append(reqList, r)
+        }
     }
 
     return
 }
 
+func hasSeenAction(seen map[string]bool, actions []blockchainRequest.Entity) bool {
+    for _, act := range actions {
+        if seen[string(act.Seal.Hash)] {
+            return true
+        }
+    }
+
+    return false
+}
+
 func (a *applicationExecutor)PushRequest(req blockchainRequest.Entity) (result interface{}, err error) {
     a.externalExeLock.RLock()
     defer a.externalExeLock.RUnlock()
@@ -156,6 +281,15 @@ func (a *applicationExecutor)PushRequest(req blockchainRequest.Entity) (result i
         return
     }
 
+    if req.Packed {
+        err = errors.New("packed request is not allowed from client")
+        return
+    }
+
+    err = a.verifyRequest(exe, req, a.chain.CurrentHeight() + 1)
+    if err != nil {
+        return
+    }
 
     return exe.PushClientRequest(req)
 }
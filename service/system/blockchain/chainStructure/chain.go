@@ -25,6 +25,11 @@ import (
 )
 
 type Blockchain struct {
+    //pruned heights are read by rpc and sync without the operate lock, they are accessed atomically
+    //and kept first in the struct to be 64-bit aligned on 32-bit platforms
+    bodyPrunedHeight  uint64
+    blockPrunedHeight uint64
+
     Config    Config
     Executor  applicationExecutor
 
@@ -32,6 +37,9 @@ type Blockchain struct {
     SQLStorage    *chainSQLStorage.Storage
     currentHeight uint64
     operateLock     sync.RWMutex
+
+    genesis       *Genesis
+    genesisBlock  *block.Entity
 }
 
 func (b *Blockchain) SetSQLStorage(sqlStorage *chainSQLStorage.Storage)  {
@@ -41,17 +49,19 @@ func (b *Blockchain) SetSQLStorage(sqlStorage *chainSQLStorage.Storage)  {
 func (b *Blockchain) LoadBlockchain(cfg Config) (err error) {
     b.Config = cfg
     b.Executor.ExternalExecutors = map[string]IBlockchainExternalApplication{}
+    b.Executor.chain = b
+    b.loadPruneState()
 
     lastBlock := b.GetLastBlock()
     if lastBlock == nil {
         b.currentHeight = 0
-        return
+        return b.loadGenesis()
     }
 
     log.Log.Println("get latest block: ", lastBlock.Header.Height)
 
     b.lastBlock = lastBlock
     b.currentHeight = lastBlock.Header.Height
-    return
+    return b.loadGenesis()
 }
 
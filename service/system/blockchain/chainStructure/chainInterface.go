@@ -25,5 +25,7 @@ type IChainInterface interface {
 	GetBlockByHeight(height uint64) (blk block.Entity, err error)
 	GetLastBlock() (last *block.Entity)
 	CurrentHeight() (height uint64)
+	ChainID() string
+	CheckBlockBodyAvailable(height uint64) (err error)
 	InternalCall(src string, dst string, data []byte) (ret interface{}, err error)
 }
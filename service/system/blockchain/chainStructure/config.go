@@ -22,6 +22,7 @@ import (
     "github.com/SealSC/SealABC/crypto/signers/signerCommon"
     "github.com/SealSC/SealABC/service/system/blockchain/chainSQLStorage"
     "github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+    "time"
 )
 
 type Config struct {
@@ -30,4 +31,12 @@ type Config struct {
     NewWhenGenesis  bool
     StorageDriver   kvDatabase.IDriver
     SQLStorage      *chainSQLStorage.Storage
+
+    //path of the genesis json file, block 0 is built from it when not empty
+    GenesisFile     string
+
+    //one of archive, full and pruned, empty means archive
+    StorageMode     string
+    KeepBlocks      uint64
+    PruneInterval   time.Duration
 }
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package chainStructure
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/common/utility/serializer/structSerializer"
+	"github.com/SealSC/SealABC/crypto/hashes"
+	"github.com/SealSC/SealABC/log"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"io/ioutil"
+	"sort"
+)
+
+//Genesis is the content of the genesis file, every node builds the same block 0 from it.
+//Applications holds the genesis config of each application keyed by application name,
+//the application decodes it in its ApplyGenesis method.
+type Genesis struct {
+	ChainID      string
+	Timestamp    uint64
+	Validators   []string
+	Applications map[string]json.RawMessage
+}
+
+func LoadGenesis(file string) (genesis Genesis, err error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, &genesis)
+	if err != nil {
+		return
+	}
+
+	if genesis.ChainID == "" {
+		err = errors.New("no chain id in genesis file")
+	}
+	return
+}
+
+//Hash is calculated over the compact json of the genesis, so formatting of the file does not matter
+func (g Genesis) Hash(hashCalc hashes.IHashCalculator) []byte {
+	data, _ := json.Marshal(g)
+	return hashCalc.Sum(data)
+}
+
+//buildGenesisBlock builds block 0 without any signature, the genesis hash is used as transactions root
+func (b *Blockchain) buildGenesisBlock(genesis Genesis) (blk block.Entity) {
+	blk.Header.Version = "1"
+	blk.Header.Height = 0
+	blk.Header.Timestamp = genesis.Timestamp
+	blk.Header.TransactionsRoot = genesis.Hash(b.Config.CryptoTools.HashCalculator)
+
+	headerBytes, _ := structSerializer.ToMFBytes(blk.Header)
+	blk.Seal.Hash = b.Config.CryptoTools.HashCalculator.Sum(headerBytes)
+	blk.BlankSeal = blk.Seal
+	return
+}
+
+func (b *Blockchain) loadGenesis() (err error) {
+	if b.Config.GenesisFile == "" {
+		return
+	}
+
+	genesis, err := LoadGenesis(b.Config.GenesisFile)
+	if err != nil {
+		return
+	}
+
+	genesisBlock := b.buildGenesisBlock(genesis)
+	if b.lastBlock != nil {
+		localGenesis, loadErr := b.loadBlockByHeight(0)
+		if loadErr == nil && !bytes.Equal(localGenesis.Seal.Hash, genesisBlock.Seal.Hash) {
+			return errors.New("local genesis block is different from the genesis file")
+		}
+	}
+
+	for _, v := range genesis.Validators {
+		if _, decodeErr := hex.DecodeString(v); decodeErr != nil {
+			return errors.New("invalid validator in genesis file: " + v)
+		}
+	}
+
+	b.genesis = &genesis
+	b.genesisBlock = &genesisBlock
+	return
+}
+
+func (b *Blockchain) Genesis() *Genesis {
+	return b.genesis
+}
+
+func (b *Blockchain) ChainID() string {
+	if b.genesis == nil {
+		return ""
+	}
+
+	return b.genesis.ChainID
+}
+
+//GenesisHash is the hash of block 0 built from the genesis file, empty if no genesis file configured
+func (b *Blockchain) GenesisHash() []byte {
+	if b.genesisBlock == nil {
+		return nil
+	}
+
+	return b.genesisBlock.Seal.Hash
+}
+
+//IsValidator tells if the key is in the initial validator set of the genesis,
+//every key is accepted when no genesis file or no validators configured
+func (b *Blockchain) IsValidator(publicKey []byte) bool {
+	if b.genesis == nil || len(b.genesis.Validators) == 0 {
+		return true
+	}
+
+	for _, v := range b.genesis.Validators {
+		key, err := hex.DecodeString(v)
+		if err == nil && bytes.Equal(key, publicKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//VerifyBlockSigner refuses the blocks not signed by a genesis validator, block 0 is built from the genesis and unsigned
+func (b *Blockchain) VerifyBlockSigner(blk block.Entity) error {
+	if blk.Header.Height == 0 || b.IsValidator(blk.Seal.SignerPublicKey) {
+		return nil
+	}
+
+	return errors.New("block signer " + hex.EncodeToString(blk.Seal.SignerPublicKey) + " is not a validator")
+}
+
+//ApplyGenesis let the registered applications initialize their state from the genesis file
+//and stores block 0, it does nothing if the chain already has blocks.
+func (b *Blockchain) ApplyGenesis() (err error) {
+	b.operateLock.Lock()
+	defer b.operateLock.Unlock()
+
+	if b.genesisBlock == nil || b.lastBlock != nil {
+		return
+	}
+
+	//applications are applied in the order of their names, so every node builds the same state
+	var names []string
+	for name := range b.genesis.Applications {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	blk := *b.genesisBlock
+	for _, name := range names {
+		app, getErr := b.Executor.getExternalExecutor(name)
+		if getErr != nil {
+			return getErr
+		}
+
+		err = app.ApplyGenesis(b.genesis.Applications[name], blk)
+		if err != nil {
+			return
+		}
+	}
+
+	blockBytes, err := json.Marshal(blk)
+	if err != nil {
+		return
+	}
+
+	heightKey := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(heightKey, 0)
+
+	err = b.Config.StorageDriver.BatchPut([]kvDatabase.KVItem{
+		{Key: heightKey, Data: blockBytes},
+		{Key: blk.Seal.Hash, Data: heightKey},
+		{Key: []byte(lastBlockKey), Data: blockBytes},
+	})
+	if err != nil {
+		return
+	}
+
+	b.currentHeight = 0
+	b.lastBlock = &blk
+
+	if b.SQLStorage != nil {
+		_ = b.SQLStorage.StoreBlock(blk)
+	}
+
+	log.Log.Println("genesis block created: ", blk.Seal.HexHash())
+	return
+}
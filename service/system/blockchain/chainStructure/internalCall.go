@@ -0,0 +1,65 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package chainStructure
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+//types of the call one application makes to another inside blockchain execute
+const (
+	InternalCallQuery    = "Query"
+	InternalCallVerify   = "Verify"
+	InternalCallExecute  = "Execute"
+	InternalCallRollback = "Rollback"
+)
+
+//InternalCallRequest is the call data passed to ApplicationInternalCall.
+//Action and Data are the request action and request data of the called application,
+//UndoKey identifies an executed call so it can be rolled back later.
+type InternalCallRequest struct {
+	Type    string
+	Action  string
+	Data    []byte
+	UndoKey []byte
+}
+
+func (i InternalCallRequest) Bytes() []byte {
+	data, _ := json.Marshal(i)
+	return data
+}
+
+func ParseInternalCall(callData []byte) (req InternalCallRequest, err error) {
+	err = json.Unmarshal(callData, &req)
+	if err != nil {
+		return
+	}
+
+	switch req.Type {
+	case InternalCallQuery, InternalCallVerify, InternalCallExecute, InternalCallRollback:
+		return
+
+	default:
+		err = errors.New("unsupported internal call type: " + req.Type)
+		return
+	}
+}
+
+func UnsupportedInternalCall(app string, req InternalCallRequest) error {
+	return errors.New(req.Type + " call is not supported by " + app)
+}
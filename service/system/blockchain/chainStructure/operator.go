@@ -31,7 +31,7 @@ import (
 
 const lastBlockKey = "lastBlockKey"
 
-func (b *Blockchain) executeRequest(blk block.Entity) (err error) {
+func (b *Blockchain) executeRequest(blk block.Entity) (receipts []Receipt, err error) {
     for idx, req := range blk.Body.Requests {
         appRet, exeErr := b.Executor.ExecuteRequest(req, blk, uint32(idx))
 
@@ -71,7 +71,8 @@ func (b *Blockchain) executeRequest(blk block.Entity) (err error) {
                 go b.SQLStorage.StoreAddress(blk, newReq)
             }
         }
-        //todo: record result
+
+        receipts = append(receipts, b.buildReceipts(req, blk, uint32(idx), appRet)...)
     }
 
     return
@@ -87,7 +88,7 @@ func (b *Blockchain) InternalCall(src string, dst string, data []byte) (ret inte
 }
 
 func (b *Blockchain) AddBlock(blk block.Entity) (err error) {
-    err = b.executeRequest(blk)
+    receipts, err := b.executeRequest(blk)
     if err != nil {
         log.Log.Error("execute requests in the block failed!")
         return
@@ -104,7 +105,7 @@ func (b *Blockchain) AddBlock(blk block.Entity) (err error) {
     heightKey := make([]byte, 8, 8)
     binary.BigEndian.PutUint64(heightKey, blk.Header.Height)
 
-    err = b.Config.StorageDriver.BatchPut([]kvDatabase.KVItem {
+    kvList := []kvDatabase.KVItem {
         //first: key is height and data is block
         {
             Key: heightKey,
@@ -122,7 +123,19 @@ func (b *Blockchain) AddBlock(blk block.Entity) (err error) {
             Key: []byte(lastBlockKey),
             Data: blockBytes,
         },
-    })
+    }
+
+    //receipts of the requests in this block
+    kvList = append(kvList, receiptsToKVItems(receipts)...)
+
+    //executed index of the actions in this block, used by replay protection
+    executedList, err := b.executedIndexToKVItems(blk)
+    if err != nil {
+        return
+    }
+    kvList = append(kvList, executedList...)
+
+    err = b.Config.StorageDriver.BatchPut(kvList)
 
     if err != nil {
         return
@@ -144,6 +157,10 @@ func (b *Blockchain) GetBlockByHeight(height uint64) (blk block.Entity, err erro
     b.operateLock.RLock()
     defer b.operateLock.RUnlock()
 
+    return b.loadBlockByHeight(height)
+}
+
+func (b *Blockchain) loadBlockByHeight(height uint64) (blk block.Entity, err error) {
     heightKey := make([]byte, 8, 8)
     binary.BigEndian.PutUint64(heightKey, height)
     kv, err := b.Config.StorageDriver.Get(heightKey)
@@ -162,6 +179,11 @@ func (b *Blockchain) GetBlockByHeight(height uint64) (blk block.Entity, err erro
 }
 
 func (b *Blockchain) getBlockFromKVDBByHeight(height uint64) (blk chainTables.BlockListRow, err error)  {
+    err = b.CheckBlockAvailable(height)
+    if err != nil {
+        return
+    }
+
     blkEntity, err := b.GetBlockByHeight(height)
     if err != nil {
         return
@@ -193,6 +215,11 @@ func (b *Blockchain) getBlockFromKVDBByHash(hash []byte) (blk chainTables.BlockL
         return
     }
 
+    err = b.CheckBlockAvailable(binary.BigEndian.Uint64(kvHeight.Data))
+    if err != nil {
+        return
+    }
+
     //get block by height finally
     kvBlock, err := b.Config.StorageDriver.Get(kvHeight.Data)
     if err != nil {
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package chainStructure
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/SealSC/SealABC/log"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	//StorageModeArchive keeps every block, it is the default mode
+	StorageModeArchive = "archive"
+	//StorageModeFull keeps all block headers and the bodies of the last KeepBlocks blocks
+	StorageModeFull = "full"
+	//StorageModePruned keeps application state and only the last KeepBlocks blocks
+	StorageModePruned = "pruned"
+)
+
+//at least the blocks reachable by EVM BLOCKHASH are kept, so every node executes contracts the same way
+const minKeepBlocks = 256
+const defaultPruneInterval = time.Minute
+
+const bodyPrunedHeightKey = "bodyPrunedHeightKey"
+const blockPrunedHeightKey = "blockPrunedHeightKey"
+
+func (b *Blockchain) storageMode() string {
+	switch b.Config.StorageMode {
+	case StorageModeFull, StorageModePruned:
+		return b.Config.StorageMode
+	default:
+		return StorageModeArchive
+	}
+}
+
+func (b *Blockchain) keepBlocks() uint64 {
+	if b.Config.KeepBlocks < minKeepBlocks {
+		return minKeepBlocks
+	}
+
+	return b.Config.KeepBlocks
+}
+
+func (b *Blockchain) loadPrunedHeight(key string) uint64 {
+	kv, err := b.Config.StorageDriver.Get([]byte(key))
+	if err != nil || !kv.Exists || len(kv.Data) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(kv.Data)
+}
+
+func (b *Blockchain) loadPruneState() {
+	atomic.StoreUint64(&b.bodyPrunedHeight, b.loadPrunedHeight(bodyPrunedHeightKey))
+	atomic.StoreUint64(&b.blockPrunedHeight, b.loadPrunedHeight(blockPrunedHeightKey))
+}
+
+//BodyPrunedHeight is the lowest height whose block body is kept, genesis block excepted
+func (b *Blockchain) BodyPrunedHeight() uint64 {
+	return atomic.LoadUint64(&b.bodyPrunedHeight)
+}
+
+//BlockPrunedHeight is the lowest height whose block is kept, genesis block excepted
+func (b *Blockchain) BlockPrunedHeight() uint64 {
+	return atomic.LoadUint64(&b.blockPrunedHeight)
+}
+
+//CheckBlockAvailable returns an error describing why the block at the given height is not kept by this node,
+//the block may have its body pruned in full mode, use CheckBlockBodyAvailable when the requests are needed.
+//genesis block is always kept.
+func (b *Blockchain) CheckBlockAvailable(height uint64) (err error) {
+	if height == 0 {
+		return
+	}
+
+	prunedHeight := b.BlockPrunedHeight()
+	if height < prunedHeight {
+		return fmt.Errorf("block %d has been pruned, this node runs in %s mode and keeps blocks from height %d",
+			height, b.storageMode(), prunedHeight)
+	}
+
+	return
+}
+
+//CheckBlockBodyAvailable returns an error describing why the block at the given height is not kept with its body
+func (b *Blockchain) CheckBlockBodyAvailable(height uint64) (err error) {
+	if height == 0 {
+		return
+	}
+
+	err = b.CheckBlockAvailable(height)
+	if err != nil {
+		return
+	}
+
+	prunedHeight := b.BodyPrunedHeight()
+	if height < prunedHeight {
+		return fmt.Errorf("body of block %d has been pruned, this node runs in %s mode and keeps block bodies from height %d",
+			height, b.storageMode(), prunedHeight)
+	}
+
+	return
+}
+
+//IsBodyPruned returns true when only the header of the block is kept
+func (b *Blockchain) IsBodyPruned(height uint64) bool {
+	return b.CheckBlockBodyAvailable(height) != nil
+}
+
+func (b *Blockchain) pruneBlock(height uint64, mode string) (err error) {
+	b.operateLock.Lock()
+	defer b.operateLock.Unlock()
+
+	blk, err := b.loadBlockByHeight(height)
+	if err != nil {
+		return
+	}
+
+	heightKey := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(heightKey, height)
+
+	nextHeight := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(nextHeight, height+1)
+
+	if mode == StorageModeFull {
+		blk.Body.Requests = nil
+		blockBytes, _ := json.Marshal(blk)
+
+		err = b.Config.StorageDriver.BatchPut([]kvDatabase.KVItem{
+			{Key: heightKey, Data: blockBytes},
+			{Key: []byte(bodyPrunedHeightKey), Data: nextHeight},
+		})
+		if err == nil {
+			atomic.StoreUint64(&b.bodyPrunedHeight, height+1)
+		}
+		return
+	}
+
+	//pruned mode removes block data and receipts, the hash index and the executed index are kept
+	receiptKeys, err := b.receiptKeysOfBlock(blk)
+	if err != nil {
+		return
+	}
+
+	err = b.Config.StorageDriver.BatchDelete(append([][]byte{heightKey}, receiptKeys...))
+	if err != nil {
+		return
+	}
+
+	err = b.Config.StorageDriver.BatchPut([]kvDatabase.KVItem{
+		{Key: []byte(bodyPrunedHeightKey), Data: nextHeight},
+		{Key: []byte(blockPrunedHeightKey), Data: nextHeight},
+	})
+	if err == nil {
+		atomic.StoreUint64(&b.bodyPrunedHeight, height+1)
+		atomic.StoreUint64(&b.blockPrunedHeight, height+1)
+	}
+	return
+}
+
+func (b *Blockchain) prune() {
+	mode := b.storageMode()
+	if mode == StorageModeArchive {
+		return
+	}
+
+	keep := b.keepBlocks()
+	current := b.CurrentHeight()
+	if current <= keep {
+		return
+	}
+
+	start := b.BodyPrunedHeight()
+	if mode == StorageModePruned {
+		start = b.BlockPrunedHeight()
+	}
+
+	if start == 0 {
+		start = 1
+	}
+
+	for h := start; h < current-keep; h++ {
+		err := b.pruneBlock(h, mode)
+		if err != nil {
+			log.Log.Error("prune block ", h, " failed: ", err.Error())
+			return
+		}
+	}
+}
+
+//StartPruner runs the pruner in background according to the storage mode, nothing happens in archive mode
+func (b *Blockchain) StartPruner() {
+	if b.storageMode() == StorageModeArchive {
+		return
+	}
+
+	interval := b.Config.PruneInterval
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+
+	log.Log.Println("block pruner started in ", b.storageMode(), " mode, keep ", b.keepBlocks(), " blocks")
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			b.prune()
+		}
+	}()
+}
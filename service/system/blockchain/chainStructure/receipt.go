@@ -0,0 +1,236 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package chainStructure
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/SealSC/SealABC/metadata/applicationResult"
+	"github.com/SealSC/SealABC/metadata/block"
+	"github.com/SealSC/SealABC/metadata/blockchainRequest"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+)
+
+const receiptKeyPrefix = "receipt:"
+
+//executed index records the height at which an action was executed, it is never pruned so replay protection
+//keeps working after receipts of old blocks are removed
+const executedKeyPrefix = "executed:"
+
+type ReceiptLog struct {
+	Address []byte
+	Topics  [][]byte
+	Data    []byte
+}
+
+type Receipt struct {
+	RequestHash  []byte
+	Application  string
+	Action       string
+	Success      bool
+	ErrorCode    int64
+	ErrorMessage string
+	ReturnData   []byte
+	GasUsed      uint64
+	Logs         []ReceiptLog
+
+	BlockHeight  uint64
+	RequestIndex uint32
+	PackedIndex  uint32
+}
+
+func buildReceiptKey(reqHash []byte) []byte {
+	return append([]byte(receiptKeyPrefix), reqHash...)
+}
+
+//actionsOfRequest returns the actions carried by a block request, a packed request is unpacked by its application
+func (b *Blockchain) actionsOfRequest(req blockchainRequest.Entity) (actions []blockchainRequest.Entity, err error) {
+	if !req.Packed {
+		return []blockchainRequest.Entity{req}, nil
+	}
+
+	app, err := b.Executor.getExternalExecutor(req.RequestApplication)
+	if err != nil {
+		return
+	}
+
+	return app.UnpackingActionsAsRequests(req)
+}
+
+func (b *Blockchain) buildReceipts(req blockchainRequest.Entity, blk block.Entity, reqIndex uint32, appRet applicationResult.Entity) (receipts []Receipt) {
+	app, err := b.Executor.getExternalExecutor(req.RequestApplication)
+	if err != nil {
+		return
+	}
+
+	receipts, err = app.BuildReceipts(req, appRet)
+	if err != nil || len(receipts) == 0 {
+		//application has no receipt of its own, every action of the request is recorded as succeeded
+		receipts = nil
+		actions, _ := b.actionsOfRequest(req)
+
+		var retData []byte
+		if appRet.Data != nil {
+			retData, _ = json.Marshal(appRet.Data)
+		}
+
+		for _, act := range actions {
+			receipts = append(receipts, Receipt{
+				RequestHash: act.Seal.Hash,
+				Action:      act.RequestAction,
+				Success:     true,
+				ReturnData:  retData,
+			})
+		}
+	}
+
+	for i := range receipts {
+		receipts[i].Application = req.RequestApplication
+		receipts[i].BlockHeight = blk.Header.Height
+		receipts[i].RequestIndex = reqIndex
+		receipts[i].PackedIndex = uint32(i)
+	}
+
+	return
+}
+
+func receiptsToKVItems(receipts []Receipt) (kvList []kvDatabase.KVItem) {
+	for _, r := range receipts {
+		if len(r.RequestHash) == 0 {
+			continue
+		}
+
+		data, _ := json.Marshal(r)
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:  buildReceiptKey(r.RequestHash),
+			Data: data,
+		})
+	}
+
+	return
+}
+
+func buildExecutedKey(reqHash []byte) []byte {
+	return append([]byte(executedKeyPrefix), reqHash...)
+}
+
+func (b *Blockchain) actionHashesOfBlock(blk block.Entity) (hashes [][]byte, err error) {
+	for _, req := range blk.Body.Requests {
+		actions, unpackErr := b.actionsOfRequest(req)
+		if unpackErr != nil {
+			return nil, unpackErr
+		}
+
+		for _, act := range actions {
+			hashes = append(hashes, act.Seal.Hash)
+		}
+	}
+
+	return
+}
+
+func (b *Blockchain) receiptKeysOfBlock(blk block.Entity) (keys [][]byte, err error) {
+	hashes, err := b.actionHashesOfBlock(blk)
+	for _, h := range hashes {
+		keys = append(keys, buildReceiptKey(h))
+	}
+
+	return
+}
+
+func (b *Blockchain) executedKeysOfBlock(blk block.Entity) (keys [][]byte, err error) {
+	hashes, err := b.actionHashesOfBlock(blk)
+	for _, h := range hashes {
+		keys = append(keys, buildExecutedKey(h))
+	}
+
+	return
+}
+
+func (b *Blockchain) executedIndexToKVItems(blk block.Entity) (kvList []kvDatabase.KVItem, err error) {
+	keys, err := b.executedKeysOfBlock(blk)
+	if err != nil {
+		return
+	}
+
+	height := make([]byte, 8, 8)
+	binary.BigEndian.PutUint64(height, blk.Header.Height)
+	for _, key := range keys {
+		kvList = append(kvList, kvDatabase.KVItem{
+			Key:  key,
+			Data: height,
+		})
+	}
+
+	return
+}
+
+//IsRequestExecuted returns true if the action has been executed on chain, it works for pruned blocks too
+func (b *Blockchain) IsRequestExecuted(reqHash []byte) bool {
+	kv, err := b.Config.StorageDriver.Get(buildExecutedKey(reqHash))
+	if err == nil && kv.Exists {
+		return true
+	}
+
+	_, err = b.GetReceipt(reqHash)
+	return err == nil
+}
+
+func (b *Blockchain) GetReceipt(reqHash []byte) (receipt Receipt, err error) {
+	kv, err := b.Config.StorageDriver.Get(buildReceiptKey(reqHash))
+	if err != nil {
+		return
+	}
+
+	if !kv.Exists {
+		err = errors.New("no such receipt")
+		return
+	}
+
+	err = json.Unmarshal(kv.Data, &receipt)
+	return
+}
+
+//GetReceiptsOfBlock returns the receipts of all actions in the block, in execute order
+func (b *Blockchain) GetReceiptsOfBlock(blk block.Entity) (receipts []Receipt, err error) {
+	keys, err := b.receiptKeysOfBlock(blk)
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		kv, getErr := b.Config.StorageDriver.Get(key)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if !kv.Exists {
+			continue
+		}
+
+		receipt := Receipt{}
+		err = json.Unmarshal(kv.Data, &receipt)
+		if err != nil {
+			return
+		}
+
+		receipts = append(receipts, receipt)
+	}
+
+	return
+}
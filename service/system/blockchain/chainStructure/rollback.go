@@ -0,0 +1,111 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package chainStructure
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SealSC/SealABC/log"
+	"github.com/SealSC/SealABC/storage/db/dbInterface/kvDatabase"
+)
+
+//RollbackTo reverts the chain to the given height: every block above it is undone
+//by its applications in reverse order and then removed from the key-value storage.
+//SQL storage is not rolled back, it should be rebuilt after the rollback.
+func (b *Blockchain) RollbackTo(height uint64) (err error) {
+	b.operateLock.Lock()
+	defer b.operateLock.Unlock()
+
+	if b.lastBlock == nil {
+		return errors.New("blockchain is empty")
+	}
+
+	if height >= b.currentHeight {
+		return fmt.Errorf("target height %d is not lower than current height %d", height, b.currentHeight)
+	}
+
+	//bodies of the reverted blocks and the block at target height itself must be kept
+	if err = b.CheckBlockBodyAvailable(height + 1); err != nil {
+		return
+	}
+
+	if height != 0 && height < b.BlockPrunedHeight() {
+		return fmt.Errorf("can't roll back to height %d, it has been pruned", height)
+	}
+
+	for h := b.currentHeight; h > height; h-- {
+		blk, loadErr := b.loadBlockByHeight(h)
+		if loadErr != nil {
+			return fmt.Errorf("load block %d failed: %s", h, loadErr.Error())
+		}
+
+		for idx := len(blk.Body.Requests) - 1; idx >= 0; idx-- {
+			req := blk.Body.Requests[idx]
+			rbErr := b.Executor.RollbackRequest(req, blk, uint32(idx))
+			if rbErr != nil {
+				return fmt.Errorf("rollback request %d of block %d for application %s failed: %s",
+					idx, h, req.RequestApplication, rbErr.Error())
+			}
+		}
+
+		prevBlk, loadErr := b.loadBlockByHeight(h - 1)
+		if loadErr != nil {
+			return fmt.Errorf("load block %d failed: %s", h-1, loadErr.Error())
+		}
+
+		prevBytes, _ := json.Marshal(prevBlk)
+		heightKey := make([]byte, 8, 8)
+		binary.BigEndian.PutUint64(heightKey, h)
+
+		receiptKeys, keysErr := b.receiptKeysOfBlock(blk)
+		if keysErr != nil {
+			return fmt.Errorf("get receipts of block %d failed: %s", h, keysErr.Error())
+		}
+
+		executedKeys, keysErr := b.executedKeysOfBlock(blk)
+		if keysErr != nil {
+			return fmt.Errorf("get executed index of block %d failed: %s", h, keysErr.Error())
+		}
+
+		delKeys := append([][]byte{heightKey, blk.Seal.Hash}, receiptKeys...)
+		err = b.Config.StorageDriver.BatchDelete(append(delKeys, executedKeys...))
+		if err != nil {
+			return
+		}
+
+		err = b.Config.StorageDriver.Put(kvDatabase.KVItem{
+			Key:  []byte(lastBlockKey),
+			Data: prevBytes,
+		})
+		if err != nil {
+			return
+		}
+
+		b.currentHeight = prevBlk.Header.Height
+		b.lastBlock = &prevBlk
+
+		log.Log.Println("rolled back block ", h)
+	}
+
+	if b.SQLStorage != nil {
+		log.Log.Warn("sql storage is not rolled back, please rebuild it from the key-value storage")
+	}
+
+	return
+}
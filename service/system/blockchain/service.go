@@ -63,8 +63,17 @@ func NewService(cfg Config) service.IService {
     for _, exe := range cfg.ExternalExecutors {
         _ = chain.Executor.RegisterApplicationExecutor(exe, &chain)
         apiServers.HttpJSON.Actions.RegisterApplicationQueryHandler(exe.Name(), exe.Query)
+        apiServers.RegisterEthereumBackend(exe)
     }
 
+    err = chain.ApplyGenesis()
+    if err != nil {
+        log.Log.Error("apply genesis failed: ", err.Error())
+        return nil
+    }
+
+    chain.StartPruner()
+
     chainService := serviceInterface.NewServiceInterface(cfg.ServiceName, &chain, p2p, apiServers)
 
     //mount to engine
@@ -72,3 +81,25 @@ func NewService(cfg Config) service.IService {
 
     return chainService
 }
+
+//Rollback opens the blockchain without network and api services and reverts it to the given height
+func Rollback(cfg Config, height uint64) (err error) {
+    chain := chainStructure.Blockchain{}
+
+    if cfg.SQLStorage != nil && cfg.EnableSQLDB{
+        chain.SetSQLStorage(&chainSQLStorage.Storage {
+            Driver: cfg.SQLStorage,
+        })
+    }
+
+    err = chain.LoadBlockchain(cfg.Blockchain)
+    if err != nil {
+        return
+    }
+
+    for _, exe := range cfg.ExternalExecutors {
+        _ = chain.Executor.RegisterApplicationExecutor(exe, &chain)
+    }
+
+    return chain.RollbackTo(height)
+}
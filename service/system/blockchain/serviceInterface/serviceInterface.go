@@ -135,6 +135,12 @@ func (b *BlockchainService) verifyBlock(blk block.Entity) (err error) {
 		return
 	}
 
+	err = b.chain.VerifyBlockSigner(blk)
+	if err != nil {
+		log.Log.Error("block @", blk.Header.Height, " refused: ", err.Error())
+		return
+	}
+
 	//genesis block has no prev-block
 	if blk.Header.Height == 0 {
 		return
@@ -172,9 +178,19 @@ func (b *BlockchainService) PreExecute(data interface{}) (result []byte, err err
 		return
 	}
 
+	err = b.chain.Executor.VerifyRequestsOfBlock(blk)
+	if err != nil {
+		log.Log.Error("block @", blk.Header.Height, " refused: ", err.Error())
+		return
+	}
+
 	//pre-executeRequest the request
 	for _, req := range blk.Body.Requests {
 		result, err = b.chain.Executor.PreExecute(req, blk)
+		if err != nil {
+			log.Log.Error("pre-execute request of ", req.RequestApplication, " failed: ", err.Error())
+			return
+		}
 		//todo: handle the result
 	}
 
@@ -18,14 +18,38 @@
 package system
 
 import (
+    "github.com/SealSC/SealABC/cli"
+    "github.com/SealSC/SealABC/log"
     "github.com/SealSC/SealABC/service/system/blockchain"
     "github.com/SealSC/SealABC/service"
+    "os"
 )
 
 func Load() {
     blockchain.Load()
 }
 
+//NewBlockchainService starts the blockchain service, when the node is started with --rollback-to the chain is
+//rolled back before any service starts and the process exits with the result of the rollback
 func NewBlockchainService(cfg blockchain.Config) service.IService {
+    if cli.Parameters.Rollback {
+        exitAfterRollback(cfg, cli.Parameters.RollbackHeight)
+    }
+
     return blockchain.NewService(cfg)
 }
+
+func exitAfterRollback(cfg blockchain.Config, height uint64) {
+    err := RollbackBlockchain(cfg, height)
+    if err != nil {
+        log.Log.Error("rollback blockchain to ", height, " failed: ", err.Error())
+        os.Exit(-1)
+    }
+
+    log.Log.Println("rollback blockchain to ", height, " done")
+    os.Exit(0)
+}
+
+func RollbackBlockchain(cfg blockchain.Config, height uint64) error {
+    return blockchain.Rollback(cfg, height)
+}
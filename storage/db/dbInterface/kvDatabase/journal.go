@@ -0,0 +1,153 @@
+/*
+ * Copyright 2020 The SealABC Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package kvDatabase
+
+import "sync"
+
+//Journal wraps a driver and, while recording, keeps the original value of every key
+//written or deleted through it, so the changes can be undone later.
+type Journal struct {
+	IDriver
+
+	lock      sync.Mutex
+	recording bool
+	touched   map[string]bool
+	preImages []KVItem
+}
+
+func NewJournal(driver IDriver) *Journal {
+	return &Journal{
+		IDriver: driver,
+		touched: map[string]bool{},
+	}
+}
+
+//Begin starts a new record and drops the previous one
+func (j *Journal) Begin() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.recording = true
+	j.touched = map[string]bool{}
+	j.preImages = nil
+}
+
+//End stops recording and returns the original values of the touched keys.
+//a pre-image with Exists set to false means the key did not exist.
+func (j *Journal) End() (preImages []KVItem) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	preImages = j.preImages
+	j.recording = false
+	j.touched = map[string]bool{}
+	j.preImages = nil
+	return
+}
+
+//Undo writes the pre-images back, the journal does not record these changes
+func (j *Journal) Undo(preImages []KVItem) (err error) {
+	var restoreList []KVItem
+	var deleteList [][]byte
+	for _, kv := range preImages {
+		if kv.Exists {
+			restoreList = append(restoreList, kv)
+		} else {
+			deleteList = append(deleteList, kv.Key)
+		}
+	}
+
+	if len(deleteList) > 0 {
+		err = j.IDriver.BatchDelete(deleteList)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(restoreList) > 0 {
+		err = j.IDriver.BatchPut(restoreList)
+	}
+
+	return
+}
+
+func (j *Journal) record(keys ...[]byte) (err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if !j.recording {
+		return
+	}
+
+	for _, k := range keys {
+		if j.touched[string(k)] {
+			continue
+		}
+
+		kv, getErr := j.IDriver.Get(k)
+		if getErr != nil {
+			return getErr
+		}
+
+		j.touched[string(k)] = true
+		j.preImages = append(j.preImages, KVItem{
+			Key:    k,
+			Data:   kv.Data,
+			Exists: kv.Exists,
+		})
+	}
+
+	return
+}
+
+func (j *Journal) Put(kv KVItem) (err error) {
+	err = j.record(kv.Key)
+	if err != nil {
+		return
+	}
+
+	return j.IDriver.Put(kv)
+}
+
+func (j *Journal) Delete(k []byte) (err error) {
+	err = j.record(k)
+	if err != nil {
+		return
+	}
+
+	return j.IDriver.Delete(k)
+}
+
+func (j *Journal) BatchPut(kvList []KVItem) (err error) {
+	for _, kv := range kvList {
+		err = j.record(kv.Key)
+		if err != nil {
+			return
+		}
+	}
+
+	return j.IDriver.BatchPut(kvList)
+}
+
+func (j *Journal) BatchDelete(kList [][]byte) (err error) {
+	err = j.record(kList...)
+	if err != nil {
+		return
+	}
+
+	return j.IDriver.BatchDelete(kList)
+}